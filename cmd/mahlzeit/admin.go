@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"codeberg.org/mahlzeit/mahlzeit/internal/app"
+)
+
+// runUser runs the user command, which adds users ("create"), sets their password ("passwd") or makes them
+// superusers ("promote"). Passwords are read from the standard input.
+func runUser(ctx context.Context, e *env, args []string) error {
+	action, args, err := subcommand("user", args, "create", "passwd", "promote")
+	if err != nil {
+		return err
+	}
+
+	var name, email string
+	var superuser bool
+	usage := "--email EMAIL"
+	if action == "create" {
+		usage = "--name NAME --email EMAIL [--superuser]"
+	}
+	flags := e.flagSet("user "+action, usage)
+	flags.StringVar(&email, "email", "", "email address of the user")
+	if action == "create" {
+		flags.StringVar(&name, "name", "", "name of the user")
+		flags.BoolVar(&superuser, "superuser", false, "make the user a superuser")
+	}
+	if _, err := parseFlags(flags, args, 0); err != nil {
+		return err
+	}
+	if email == "" || (action == "create" && name == "") {
+		flags.Usage()
+		return usageErrorf("user %s: missing required flags", action)
+	}
+
+	a, err := e.application(ctx)
+	if err != nil {
+		return err
+	}
+	defer a.DB.Close()
+
+	if action == "create" {
+		pw, err := readPassword(e)
+		if err != nil {
+			return err
+		}
+		user, err := a.AddUser(ctx, app.AddUserParams{Name: name, Email: email, Password: pw, Activated: true})
+		if err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintf(e.stdout, "Created user %s (%d)\n", user.Name, user.ID)
+		if superuser {
+			return promote(ctx, e, a, user)
+		}
+		return nil
+	}
+
+	user, err := a.GetUserByEmail(ctx, email)
+	if err != nil {
+		return err
+	}
+	if action == "promote" {
+		return promote(ctx, e, a, user)
+	}
+
+	pw, err := readPassword(e)
+	if err != nil {
+		return err
+	}
+	if err := a.SetPassword(ctx, user.ID, pw); err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(e.stdout, "Changed the password of %s\n", user.Name)
+	return nil
+}
+
+// promote makes the user a superuser.
+func promote(ctx context.Context, e *env, a *app.Application, user app.User) error {
+	if err := a.SetSuperuser(ctx, user.ID, true); err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(e.stdout, "%s is a superuser now\n", user.Name)
+	return nil
+}
+
+// runHousehold runs the household command, which adds households ("create") and their members ("add-member").
+func runHousehold(ctx context.Context, e *env, args []string) error {
+	action, args, err := subcommand("household", args, "create", "add-member")
+	if err != nil {
+		return err
+	}
+
+	var email string
+	var householdID int
+	var rest []string
+	if action == "create" {
+		flags := e.flagSet("household create", "NAME")
+		if rest, err = parseFlags(flags, args, 1); err != nil {
+			return err
+		}
+	} else {
+		flags := e.flagSet("household add-member", "--household ID --email EMAIL")
+		flags.IntVar(&householdID, "household", 0, "ID of the household")
+		flags.StringVar(&email, "email", "", "email address of the new member")
+		if _, err := parseFlags(flags, args, 0); err != nil {
+			return err
+		}
+		if householdID == 0 || email == "" {
+			flags.Usage()
+			return usageErrorf("household add-member: missing required flags")
+		}
+	}
+
+	a, err := e.application(ctx)
+	if err != nil {
+		return err
+	}
+	defer a.DB.Close()
+
+	// The command line is only available to the operator, who may administrate all households.
+	ctx = app.AsOperator(ctx)
+	if action == "create" {
+		h, err := a.AddHousehold(ctx, rest[0])
+		if err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintf(e.stdout, "Created household %s (%d)\n", h.Name, h.ID)
+		return nil
+	}
+
+	user, err := a.GetUserByEmail(ctx, email)
+	if err != nil {
+		return err
+	}
+	if err := a.AddHouseholdMember(ctx, householdID, user.ID); err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(e.stdout, "Added %s to household %d\n", user.Name, householdID)
+	return nil
+}
+
+// readPassword reads a password from the first line of the standard input.
+func readPassword(e *env) (string, error) {
+	_, _ = fmt.Fprint(e.stderr, "Password: ")
+	line, err := bufio.NewReader(e.stdin).ReadString('\n')
+	if err != nil && (!errors.Is(err, io.EOF) || line == "") {
+		return "", fmt.Errorf("reading password: %w", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
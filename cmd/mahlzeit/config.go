@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"codeberg.org/mahlzeit/mahlzeit/db/queries"
+	"codeberg.org/mahlzeit/mahlzeit/internal/app"
+	"codeberg.org/mahlzeit/mahlzeit/internal/config"
+	"codeberg.org/mahlzeit/mahlzeit/internal/tracing"
+	"github.com/BurntSushi/toml"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// envPrefix is the prefix of the environment variables of the configuration, e.g. MAHLZEIT_WEB_ENDPOINT.
+const envPrefix = "MAHLZEIT"
+
+// config returns the configuration, which consists of the defaults, the configuration file, the environment
+// variables and the values of --set, with the latter overriding the former. The file is optional, unless it's
+// configured explicitly with --config or $MAHLZEIT_CONFIG.
+func (e *env) config() (app.Configuration, error) {
+	lookup := e.lookupEnv
+	if lookup == nil {
+		lookup = os.LookupEnv
+	}
+
+	path, required := e.configPath, e.configPath != ""
+	if !required {
+		path, required = lookup(envPrefix + "_CONFIG")
+	}
+	if !required {
+		path = "config.toml"
+	}
+
+	cfg := app.DefaultConfiguration()
+	if err := config.LoadFile(&cfg, path, required); err != nil {
+		return app.Configuration{}, err
+	}
+	if err := config.LoadEnv(&cfg, envPrefix, lookup); err != nil {
+		return app.Configuration{}, fmt.Errorf("invalid environment variables:\n%w", err)
+	}
+	for _, o := range e.overrides {
+		key, value, _ := strings.Cut(o, "=")
+		if err := config.Set(&cfg, key, value); err != nil {
+			return app.Configuration{}, usageErrorf("--set %s: %s", o, err)
+		}
+	}
+	return cfg, nil
+}
+
+// application returns an application for the administrative commands, which only requires the database.
+// The caller must close the database.
+func (e *env) application(ctx context.Context) (*app.Application, error) {
+	cfg, err := e.config()
+	if err != nil {
+		return nil, err
+	}
+	pool, err := connectDB(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &app.Application{
+		Queries: queries.New(pool),
+		DB:      pool,
+		Logger:  e.logger,
+	}, nil
+}
+
+// connectDB returns the pool of database connections, which connects lazily.
+func connectDB(ctx context.Context, cfg app.Configuration) (*pgxpool.Pool, error) {
+	// The split up between config parsing and connecting is done to enable lazy connects.
+	// With pgx/v5 this is no longer required, so the following code could be simplified again.
+	dbConf, err := pgxpool.ParseConfig(cfg.Database.ConnectionString)
+	if err != nil {
+		return nil, fmt.Errorf("parsing database configuration failed: %w", err)
+	}
+
+	dbConf.LazyConnect = true
+	if cfg.Tracing.Enabled() {
+		dbConf.ConnConfig.Logger = tracing.QueryLogger{}
+	}
+
+	return pgxpool.ConnectConfig(ctx, dbConf)
+}
+
+// runCheckConfig runs the check-config command, which prints the effective configuration without secrets and
+// validates it.
+func runCheckConfig(_ context.Context, e *env, args []string) error {
+	flags := e.flagSet("check-config", "[flags]")
+	if _, err := parseFlags(flags, args, 0); err != nil {
+		return err
+	}
+
+	cfg, err := e.config()
+	if err != nil {
+		return err
+	}
+	if err := toml.NewEncoder(e.stdout).Encode(config.Redacted(cfg)); err != nil {
+		return err
+	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration:\n%w", err)
+	}
+	_, _ = fmt.Fprintln(e.stdout, "\n# The configuration is valid.")
+	return nil
+}
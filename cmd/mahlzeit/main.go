@@ -2,93 +2,209 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"log"
-	"net"
-	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
-	"time"
-
-	"codeberg.org/mahlzeit/mahlzeit/db/queries"
-	"codeberg.org/mahlzeit/mahlzeit/internal/app"
-	"codeberg.org/mahlzeit/mahlzeit/internal/http/routes"
-	"codeberg.org/mahlzeit/mahlzeit/internal/templates"
-	"codeberg.org/mahlzeit/mahlzeit/web"
-	"github.com/BurntSushi/toml"
-	"github.com/jackc/pgx/v4/pgxpool"
+	"text/tabwriter"
+
 	"go.uber.org/zap"
 )
 
-const ExitCodeOnError = 1
+const (
+	ExitCodeOnError = 1
+	ExitCodeUsage   = 2 // invalid arguments, e.g. unknown commands or flags
+)
 
 func main() {
 	defer recoverPanic()
-	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGTERM)
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
 	defer cancel()
+	go func() {
+		// After the first signal, the default behavior is restored, so that a second one stops the process
+		// immediately instead of waiting for the graceful shutdown.
+		<-ctx.Done()
+		cancel()
+	}()
 
-	if err := run(ctx, os.Args[1:]); err != nil {
-		_, _ = fmt.Fprintf(os.Stderr, "unexpected error during execution: %s", err)
+	e := &env{stdin: os.Stdin, stdout: os.Stdout, stderr: os.Stderr}
+	if err := run(ctx, e, os.Args[1:]); err != nil {
+		code := ExitCodeOnError
+		var usageErr *usageError
+		switch {
+		case errors.Is(err, flag.ErrHelp):
+			// The help was requested explicitly, so it's no error.
+			code = 0
+		case errors.As(err, &usageErr):
+			_, _ = fmt.Fprintf(os.Stderr, "%s\n\nRun 'mahlzeit help' for usage.\n", err)
+			code = ExitCodeUsage
+		default:
+			_, _ = fmt.Fprintf(os.Stderr, "unexpected error during execution: %s\n", err)
+		}
 
 		// Since os.Exit would skip the deferred statements, the context cancellation is invoked
 		// manually at this point.
 		cancel()
 
-		os.Exit(ExitCodeOnError) // nolint:gocritic
+		os.Exit(code) // nolint:gocritic
 	}
 }
 
+// env is the environment of a command.
+type env struct {
+	configPath string   // set by --config, otherwise $MAHLZEIT_CONFIG or "config.toml" is used
+	overrides  []string // set by --set, e.g. "web.endpoint=:8080"
+	lookupEnv  func(string) (string, bool)
+	logger     *zap.Logger
+
+	stdin          io.Reader
+	stdout, stderr io.Writer
+}
+
+// command is a subcommand of mahlzeit, e.g. "serve".
+type command struct {
+	name    string
+	args    string // the arguments in the usage, e.g. "up|down|status"
+	summary string
+	run     func(ctx context.Context, e *env, args []string) error
+}
+
+// commands are all subcommands. Without a command, the server is started.
+var commands = []command{
+	{name: "serve", summary: "Start the web server (default)", run: runServe},
+	{name: "migrate", args: "up|down|status", summary: "Apply, roll back or list the database migrations", run: runMigrate},
+	{name: "user", args: "create|passwd|promote", summary: "Add users, set their password or make them superusers", run: runUser},
+	{name: "household", args: "create|add-member", summary: "Add households and their members", run: runHousehold},
+	{name: "import", args: "--user EMAIL [FILE]", summary: "Import recipes from a JSON file, see export", run: runImport},
+	{name: "export", args: "[FILE]", summary: "Export all recipes into a JSON file", run: runExport},
+	{name: "check-config", summary: "Check the configuration without starting the server", run: runCheckConfig},
+}
+
+// usageError is returned for invalid arguments.
+type usageError struct {
+	msg string
+}
+
+func (e *usageError) Error() string {
+	return e.msg
+}
+
+func usageErrorf(format string, a ...any) error {
+	return &usageError{msg: fmt.Sprintf(format, a...)}
+}
+
 // We deliberately use the main function as the entrypoint to wrap basic command
 // execution into run. That itself makes it testable and the provided [context.Context] can be
 // used for downstream goroutines to cancel their operations.
-func run(ctx context.Context, args []string) error {
-	logger, err := zap.NewDevelopment()
+func run(ctx context.Context, e *env, args []string) error {
+	flags := e.flagSet("mahlzeit", "[flags] <command> [arguments]")
+	flags.Usage = func() { printUsage(e.stderr) }
+	args, err := parseFlags(flags, args, -len(args))
 	if err != nil {
-		return fmt.Errorf("logger setup failed: %w", err)
+		return err
 	}
-	defer logger.Sync()
-	zap.RedirectStdLog(logger)
 
-	var cfg app.Configuration
-	_, err = toml.DecodeFile("config.toml", &cfg)
-	if err != nil {
-		return fmt.Errorf("parsing config.toml failed: %w", err)
+	name := "serve"
+	if len(args) > 0 {
+		name, args = args[0], args[1:]
+	}
+	if name == "help" {
+		printUsage(e.stdout)
+		return nil
 	}
 
-	// The split up between config parsing and connecting is done to enable lazy connects.
-	// With pgx/v5 this is no longer required, so the following code could be simplified again.
-	dbConf, err := pgxpool.ParseConfig(cfg.Database.ConnectionString)
-	if err != nil {
-		return fmt.Errorf("parsing database configuration failed: %w", err)
+	for _, c := range commands {
+		if c.name != name {
+			continue
+		}
+
+		// The server replaces this logger with the configured one, see runServe. The other commands are run
+		// interactively, so they keep logging to stderr.
+		logger, err := zap.NewDevelopment()
+		if err != nil {
+			return fmt.Errorf("logger setup failed: %w", err)
+		}
+		defer logger.Sync()
+		zap.RedirectStdLog(logger)
+		e.logger = logger
+
+		return c.run(ctx, e, args)
 	}
+	return usageErrorf("unknown command %q", name)
+}
 
-	dbConf.LazyConnect = true
+// flagSet returns the flags of a command, which accept the --config and --set flags before and after the command.
+func (e *env) flagSet(name, args string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(e.stderr)
+	flags.Func("config", "path of the configuration `file` (default $MAHLZEIT_CONFIG or config.toml)", func(s string) error {
+		e.configPath = s
+		return nil
+	})
+	flags.Func("set", "override a configuration value with `key=value`, e.g. web.endpoint=:8080 (repeatable)", func(s string) error {
+		if !strings.Contains(s, "=") {
+			return errors.New("expected key=value")
+		}
+		e.overrides = append(e.overrides, s)
+		return nil
+	})
+	flags.Usage = func() {
+		_, _ = fmt.Fprintf(flags.Output(), "Usage: %s %s\n\nFlags:\n", name, args)
+		flags.PrintDefaults()
+	}
+	return flags
+}
 
-	pool, err := pgxpool.ConnectConfig(ctx, dbConf)
-	if err != nil {
-		return err
+// parseFlags parses the flags of a command and ensures that exactly n positional arguments are left, or up to
+// -n if n is negative.
+func parseFlags(flags *flag.FlagSet, args []string, n int) ([]string, error) {
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil, err
+		}
+		return nil, &usageError{msg: err.Error()}
 	}
 
-	app := &app.Application{
-		Templates: templates.NewTemplates(cfg.Web.TemplateDir),
-		Queries:   queries.New(pool),
-		Logger:    logger,
+	rest := flags.Args()
+	if (n >= 0 && len(rest) != n) || (n < 0 && len(rest) > -n) {
+		flags.Usage()
+		return nil, usageErrorf("%s: unexpected number of arguments", flags.Name())
 	}
+	return rest, nil
+}
 
-	if err := web.ServeAssets(ctx, cfg.Web.TemplateDir); err != nil {
-		return fmt.Errorf("starting development asset server: %w", err)
+// subcommand returns the action of a command with subcommands, like "up" of "migrate up".
+func subcommand(name string, args []string, actions ...string) (string, []string, error) {
+	if len(args) == 0 {
+		return "", nil, usageErrorf("usage: mahlzeit %s %s", name, strings.Join(actions, "|"))
 	}
-	logger.Info("starting server", zap.String("endpoint", cfg.Web.Endpoint))
-	h := &http.Server{
-		BaseContext: func(net.Listener) context.Context {
-			return ctx
-		},
-		Addr:              cfg.Web.Endpoint,
-		Handler:           routes.All(app),
-		ReadHeaderTimeout: time.Second, // protect against SLOWLORIS attack
+	for _, a := range actions {
+		if a == args[0] {
+			return a, args[1:], nil
+		}
+	}
+	return "", nil, usageErrorf("unknown %s command %q, expected one of %s", name, args[0], strings.Join(actions, ", "))
+}
+
+func printUsage(w io.Writer) {
+	_, _ = fmt.Fprint(w, "Mahlzeit manages recipes and their households.\n\nUsage: mahlzeit [flags] <command> [arguments]\n\nCommands:\n")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, c := range commands {
+		_, _ = fmt.Fprintf(tw, "  %s %s\t%s\n", c.name, c.args, c.summary)
 	}
-	return h.ListenAndServe()
+	_, _ = fmt.Fprintf(tw, "  help\tShow this help\n")
+	_ = tw.Flush()
+	_, _ = fmt.Fprint(w, "\nFlags:\n"+
+		"  --config FILE        path of the configuration file (default $MAHLZEIT_CONFIG or config.toml)\n"+
+		"  --set KEY=VALUE      override a configuration value, e.g. web.endpoint=:8080 (repeatable)\n\n"+
+		"The configuration is read from the file, then from environment variables like MAHLZEIT_WEB_ENDPOINT,\n"+
+		"and then from --set. Run 'mahlzeit check-config' to see the result.\n\n"+
+		"Run 'mahlzeit <command> --help' for the flags of a command.\n")
 }
 
 func recoverPanic() {
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+
+	"codeberg.org/mahlzeit/mahlzeit/db"
+	"codeberg.org/mahlzeit/mahlzeit/internal/migrate"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// newMigrator returns the migrator for the embedded migrations.
+func newMigrator(pool *pgxpool.Pool) (*migrate.Migrator, error) {
+	migrations, err := fs.Sub(db.Migrations, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	migrator, err := migrate.New(pool, migrations)
+	if err != nil {
+		return nil, fmt.Errorf("loading migrations: %w", err)
+	}
+	return migrator, nil
+}
+
+// runMigrate runs the migrate command, which applies ("up") or rolls back ("down") the migrations or lists
+// their state ("status").
+func runMigrate(ctx context.Context, e *env, args []string) error {
+	action, args, err := subcommand("migrate", args, "up", "down", "status")
+	if err != nil {
+		return err
+	}
+	flags := e.flagSet("migrate "+action, "[flags]")
+	if _, err := parseFlags(flags, args, 0); err != nil {
+		return err
+	}
+
+	cfg, err := e.config()
+	if err != nil {
+		return err
+	}
+	pool, err := connectDB(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+	migrator, err := newMigrator(pool)
+	if err != nil {
+		return err
+	}
+
+	switch action {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, m := range applied {
+			_, _ = fmt.Fprintf(e.stdout, "Applied: %s\n", m.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			_, _ = fmt.Fprintln(e.stdout, "No pending migrations")
+		}
+		return err
+	case "down":
+		m, err := migrator.Down(ctx)
+		if err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintf(e.stdout, "Rolled back: %s\n", m.Name)
+		return nil
+	}
+
+	states, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+	var pending int
+	for _, s := range states {
+		mark, name := " ", s.Name
+		if s.Applied {
+			mark = "X"
+		} else {
+			pending++
+		}
+		if name == "" {
+			name = s.Version + " (missing)"
+		}
+		_, _ = fmt.Fprintf(e.stdout, "[%s] %s\n", mark, name)
+	}
+	_, _ = fmt.Fprintf(e.stdout, "\nApplied: %d\nPending: %d\n", len(states)-pending, pending)
+	return nil
+}
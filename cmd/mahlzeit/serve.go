@@ -0,0 +1,305 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"codeberg.org/mahlzeit/mahlzeit/db/queries"
+	"codeberg.org/mahlzeit/mahlzeit/internal/app"
+	"codeberg.org/mahlzeit/mahlzeit/internal/blob"
+	"codeberg.org/mahlzeit/mahlzeit/internal/http/health"
+	"codeberg.org/mahlzeit/mahlzeit/internal/http/routes"
+	"codeberg.org/mahlzeit/mahlzeit/internal/mail"
+	"codeberg.org/mahlzeit/mahlzeit/internal/metrics"
+	"codeberg.org/mahlzeit/mahlzeit/internal/oidc"
+	"codeberg.org/mahlzeit/mahlzeit/internal/templates"
+	"codeberg.org/mahlzeit/mahlzeit/internal/tracing"
+	"codeberg.org/mahlzeit/mahlzeit/internal/zaphelper"
+	"codeberg.org/mahlzeit/mahlzeit/web"
+	"go.uber.org/zap"
+)
+
+// runServe runs the serve command, which starts the web server.
+func runServe(ctx context.Context, e *env, args []string) error {
+	flags := e.flagSet("serve", "[flags]")
+	if _, err := parseFlags(flags, args, 0); err != nil {
+		return err
+	}
+
+	cfg, err := e.config()
+	if err != nil {
+		return err
+	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration:\n%w", err)
+	}
+	logger, levels, err := zaphelper.NewLogger(cfg.Log)
+	if err != nil {
+		return fmt.Errorf("setting up logging: %w", err)
+	}
+	defer logger.Sync()
+	defer zap.RedirectStdLog(logger)()
+
+	shutdownTracing, err := tracing.Setup(ctx, cfg.Tracing, logger.Named("tracing"))
+	if err != nil {
+		return fmt.Errorf("setting up tracing: %w", err)
+	}
+	defer func() {
+		// The context is already canceled at this point, so the remaining spans get a few seconds of their own.
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			logger.Warn("flushing traces failed", zap.Error(err))
+		}
+	}()
+
+	pool, err := connectDB(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	migrator, err := newMigrator(pool)
+	if err != nil {
+		return err
+	}
+	if cfg.Database.AutoMigrate {
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return fmt.Errorf("applying migrations: %w", err)
+		}
+		for _, m := range applied {
+			logger.Info("applied migration", zap.String("name", m.Name))
+		}
+	}
+
+	blobs, err := newBlobStorage(cfg)
+	if err != nil {
+		return fmt.Errorf("setting up blob storage: %w", err)
+	}
+
+	mailer, err := newMailSender(cfg, logger.Named("mail"))
+	if err != nil {
+		return fmt.Errorf("setting up mail: %w", err)
+	}
+
+	assets, urls, err := newAssets(ctx, cfg, logger)
+	if err != nil {
+		return fmt.Errorf("setting up assets: %w", err)
+	}
+	tmpl, err := newTemplates(cfg, urls)
+	if err != nil {
+		return fmt.Errorf("setting up templates: %w", err)
+	}
+
+	q := queries.New(pool)
+	var m *metrics.Metrics
+	if cfg.Metrics.Endpoint != "" {
+		m = metrics.New()
+		m.RegisterDB(pool, q)
+		tmpl.SetObserver(m.ObserveTemplate)
+	}
+
+	app := &app.Application{
+		Templates: tmpl,
+		Queries:   q,
+		DB:        pool,
+		Logger:    logger,
+		LogLevels: levels,
+		Blobs:     blobs,
+		Mail:      mailer,
+		Metrics:   m,
+		BaseURL:   baseURL(cfg),
+	}
+	if cfg.OIDC.Enabled() {
+		app.OIDC, err = oidc.NewProvider(ctx, cfg.OIDC)
+		if err != nil {
+			return fmt.Errorf("setting up login with OIDC: %w", err)
+		}
+	}
+
+	handler := health.Handler(routes.All(app, assets), logger.Named("health"), map[string]health.Check{
+		"database": pool.Ping,
+		"migrations": func(ctx context.Context) error {
+			pending, err := migrator.Pending(ctx)
+			if err != nil {
+				return err
+			}
+			if len(pending) > 0 {
+				return fmt.Errorf("%d pending migrations, run mahlzeit migrate up", len(pending))
+			}
+			return nil
+		},
+	})
+
+	// The requests are only canceled if they don't finish within the shutdown timeout, see serve.
+	baseCtx, cancelRequests := context.WithCancel(context.Background())
+	defer cancelRequests()
+	servers := []*http.Server{newServer(baseCtx, cfg.Web.Endpoint, handler)}
+	if m != nil {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", m.Handler(logger.Named("metrics")))
+		servers = append(servers, newServer(baseCtx, cfg.Metrics.Endpoint, mux))
+	}
+	return serve(ctx, cfg.Web.ShutdownTimeout, cancelRequests, logger, servers...)
+}
+
+// newServer returns a server for the endpoint, whose requests use the base context.
+func newServer(baseCtx context.Context, endpoint string, handler http.Handler) *http.Server {
+	return &http.Server{
+		BaseContext: func(net.Listener) context.Context {
+			return baseCtx
+		},
+		Addr:              endpoint,
+		Handler:           handler,
+		ReadHeaderTimeout: time.Second, // protect against SLOWLORIS attack
+	}
+}
+
+// serve runs the servers until ctx is done, e.g. by SIGTERM, or one of them fails. The servers then stop accepting
+// connections and wait for running requests up to the timeout, after which they are canceled and their
+// connections closed.
+func serve(ctx context.Context, timeout time.Duration, cancelRequests context.CancelFunc, logger *zap.Logger, servers ...*http.Server) error {
+	errc := make(chan error, len(servers))
+	for _, h := range servers {
+		h := h
+		go func() {
+			logger.Info("starting server", zap.String("endpoint", h.Addr))
+			errc <- h.ListenAndServe()
+		}()
+	}
+
+	var errs []error
+	select {
+	case err := <-errc:
+		// E.g. the address is already in use, so the other servers are stopped, too.
+		errs = append(errs, err)
+	case <-ctx.Done():
+	}
+
+	logger.Info("shutting down server", zap.Duration("timeout", timeout))
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	for _, h := range servers {
+		err := h.Shutdown(shutdownCtx)
+		if errors.Is(err, context.DeadlineExceeded) {
+			logger.Warn("requests didn't finish in time, canceling them", zap.String("endpoint", h.Addr))
+			cancelRequests()
+			err = h.Close()
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("shutting down server %s: %w", h.Addr, err))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	logger.Info("server stopped")
+	return nil
+}
+
+// newAssets returns the handler of the compiled assets and their URLs for the configured mode. In the development
+// mode, the development server of esbuild serves them instead. In the production mode, the assets are compiled
+// once at startup if none are embedded or configured, e.g. after a plain go build.
+func newAssets(ctx context.Context, cfg app.Configuration, logger *zap.Logger) (http.Handler, templates.Assets, error) {
+	switch cfg.Web.Mode {
+	case "development":
+		if err := web.ServeAssets(ctx, "./web"); err != nil {
+			return nil, nil, fmt.Errorf("starting development server: %w", err)
+		}
+		return http.NotFoundHandler(), web.DevAssets{}, nil
+	case "", "production":
+	default:
+		return nil, nil, fmt.Errorf("unknown mode %q", cfg.Web.Mode)
+	}
+
+	assets, err := web.NewAssets(dirOrEmbedded(cfg.Web.AssetDir, web.CompiledAssets()))
+	if err != nil {
+		return nil, nil, err
+	}
+	if !assets.Empty() {
+		return assets, assets, nil
+	}
+
+	logger.Warn("no compiled assets found, building them from ./web")
+	dir, err := os.MkdirTemp("", "mahlzeit-assets-")
+	if err != nil {
+		return nil, nil, fmt.Errorf("creating directory for assets: %w", err)
+	}
+	go func() {
+		<-ctx.Done()
+		_ = os.RemoveAll(dir)
+	}()
+	if err := web.BuildAssets("./web", dir); err != nil {
+		return nil, nil, err
+	}
+	assets, err = web.NewAssets(os.DirFS(dir))
+	if err != nil {
+		return nil, nil, err
+	}
+	return assets, assets, nil
+}
+
+// newTemplates returns the templates, which are embedded unless a directory is configured.
+func newTemplates(cfg app.Configuration, assets templates.Assets) (templates.Templates, error) {
+	tmpl := templates.NewTemplates(dirOrEmbedded(cfg.Web.TemplateDir, web.Templates()), assets)
+	if cfg.Web.TemplateDir == "" {
+		// The embedded templates never change, so they are parsed only once.
+		if err := tmpl.BuildCache(); err != nil {
+			return templates.Templates{}, err
+		}
+	}
+	return tmpl, nil
+}
+
+// dirOrEmbedded returns the files of the directory, or the embedded files if it's empty.
+func dirOrEmbedded(dir string, embedded fs.FS) fs.FS {
+	if dir == "" {
+		return embedded
+	}
+	return os.DirFS(dir)
+}
+
+// newBlobStorage returns the storage for uploaded files, as configured in the storage section.
+func newBlobStorage(cfg app.Configuration) (blob.Storage, error) {
+	switch cfg.Storage.Driver {
+	case "", "filesystem":
+		dir := cfg.Storage.Directory
+		if dir == "" {
+			dir = "./data/blobs"
+		}
+		return blob.NewFilesystem(dir)
+	case "s3":
+		return blob.NewS3(cfg.Storage.S3)
+	}
+	return nil, fmt.Errorf("unknown storage driver %q", cfg.Storage.Driver)
+}
+
+// newMailSender returns the driver for emails, as configured in the mail section.
+func newMailSender(cfg app.Configuration, logger *zap.Logger) (mail.Sender, error) {
+	switch cfg.Mail.Driver {
+	case "", "log":
+		return mail.NewLog(logger), nil
+	case "smtp":
+		return mail.NewSMTP(cfg.Mail.SMTP)
+	}
+	return nil, fmt.Errorf("unknown mail driver %q", cfg.Mail.Driver)
+}
+
+// baseURL returns the configured public URL. Without one, the local endpoint is used, which is fine for the development.
+func baseURL(cfg app.Configuration) string {
+	if cfg.Web.BaseURL != "" {
+		return strings.TrimSuffix(cfg.Web.BaseURL, "/")
+	}
+	if strings.HasPrefix(cfg.Web.Endpoint, ":") {
+		return "http://localhost" + cfg.Web.Endpoint
+	}
+	return "http://" + cfg.Web.Endpoint
+}
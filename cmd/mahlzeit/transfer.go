@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"codeberg.org/mahlzeit/mahlzeit/internal/app"
+)
+
+// runExport runs the export command, which writes all recipes as JSON into a file or the standard output.
+func runExport(ctx context.Context, e *env, args []string) error {
+	flags := e.flagSet("export", "[FILE]")
+	rest, err := parseFlags(flags, args, -1)
+	if err != nil {
+		return err
+	}
+
+	a, err := e.application(ctx)
+	if err != nil {
+		return err
+	}
+	defer a.DB.Close()
+
+	recipes, err := a.ExportRecipes(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(rest) == 0 || rest[0] == "-" {
+		return writeJSON(e.stdout, recipes)
+	}
+	f, err := os.Create(rest[0])
+	if err != nil {
+		return err
+	}
+	if err := writeJSON(f, recipes); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// writeJSON writes v as indented JSON.
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return fmt.Errorf("writing recipes: %w", err)
+	}
+	return nil
+}
+
+// runImport runs the import command, which adds the recipes of a file or the standard input in the format
+// of the export command. The recipes are created by the user with the given email address.
+func runImport(ctx context.Context, e *env, args []string) error {
+	var email string
+	flags := e.flagSet("import", "--user EMAIL [FILE]")
+	flags.StringVar(&email, "user", "", "email address of the user who creates the recipes")
+	rest, err := parseFlags(flags, args, -1)
+	if err != nil {
+		return err
+	}
+	if email == "" {
+		flags.Usage()
+		return usageErrorf("import: missing required flag --user")
+	}
+
+	r := e.stdin
+	if len(rest) > 0 && rest[0] != "-" {
+		f, err := os.Open(rest[0])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	var recipes []app.ExportedRecipe
+	if err := json.NewDecoder(r).Decode(&recipes); err != nil {
+		return fmt.Errorf("reading recipes: %w", err)
+	}
+
+	a, err := e.application(ctx)
+	if err != nil {
+		return err
+	}
+	defer a.DB.Close()
+
+	user, err := a.GetUserByEmail(ctx, email)
+	if err != nil {
+		return err
+	}
+	res, err := a.ImportRecipes(app.WithUserID(ctx, user.ID), recipes)
+	for _, name := range res.Skipped {
+		_, _ = fmt.Fprintf(e.stdout, "Skipped: %s (exists already)\n", name)
+	}
+	_, _ = fmt.Fprintf(e.stdout, "Imported %d of %d recipes\n", len(res.Imported), len(recipes))
+	return err
+}
@@ -0,0 +1,10 @@
+// Package db contains the schema of the database. The migrations are embedded, so that they can be applied by
+// the binary itself. The queries are generated with sqlc into the package queries.
+package db
+
+import "embed"
+
+// Migrations contains the migrations in the format of dbmate, in the directory "migrations".
+//
+//go:embed migrations/*.sql
+var Migrations embed.FS
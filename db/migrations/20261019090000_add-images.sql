@@ -0,0 +1,24 @@
+-- migrate:up
+-- Images belong to a recipe, and optionally to a single step of it.
+-- The files themselves are kept in the blob storage, all variants share the storage_key as prefix.
+create table images
+(
+	id          bigint primary key generated by default as identity,
+	recipe_id   bigint      not null
+		references recipes (id)
+			on delete cascade,
+	step_id     bigint      null
+		references steps (id)
+			on delete cascade,
+	storage_key text        not null,
+	width       integer     not null, -- dimensions of the largest variant
+	height      integer     not null,
+	alt_text    text        not null default '',
+	created_at  timestamptz not null default now(),
+	unique (storage_key)
+);
+
+create index images_recipe_id_idx on images (recipe_id);
+
+-- migrate:down
+drop table images;
@@ -0,0 +1,64 @@
+-- migrate:up
+-- recipe_snapshot returns the complete content of a recipe, including its steps and their ingredients, as JSON.
+-- Times are stored in seconds. If the recipe does not exist, null is returned.
+create function recipe_snapshot(bigint) returns jsonb
+	language sql
+	stable
+as
+$$
+select jsonb_build_object(
+			   'name', recipes.name,
+			   'description', recipes.description,
+			   'workingTime', extract(epoch from recipes.working_time)::bigint,
+			   'waitingTime', extract(epoch from recipes.waiting_time)::bigint,
+			   'source', recipes.source,
+			   'servings', recipes.servings,
+			   'servingsDescription', recipes.servings_description,
+			   'steps', coalesce((select jsonb_agg(jsonb_build_object(
+					   'id', steps.id,
+					   'instruction', steps.instruction,
+					   'time', extract(epoch from steps.time)::bigint,
+					   'ingredients', coalesce((select jsonb_agg(jsonb_build_object(
+							   'id', ingredients.id,
+							   'name', ingredients.name,
+							   'unitID', step_ingredients.unit_id,
+							   'unitName', units.name,
+							   'amount', step_ingredients.amount,
+							   'note', step_ingredients.note
+						   ) order by ingredients.name)
+												from step_ingredients
+														 inner join ingredients on ingredients.id = step_ingredients.ingredients_id
+														 left join units on units.id = step_ingredients.unit_id
+												where step_ingredients.step_id = steps.id), '[]')
+				   ) order by steps.sort_order, steps.id)
+								  from steps
+								  where steps.recipe_id = recipes.id), '[]')
+		   )
+from recipes
+where recipes.id = $1;
+$$;
+
+-- Every change to a recipe is recorded as a revision, which contains the complete recipe after the change.
+create table recipe_revisions
+(
+	id         bigint primary key generated by default as identity,
+	recipe_id  bigint      not null
+		references recipes (id)
+			on delete cascade,
+	created_at timestamptz not null default now(),
+	created_by bigint      null -- null if the author is unknown
+		references users (id)
+			on delete set null,
+	content    jsonb       not null
+);
+
+create index recipe_revisions_recipe_id_idx on recipe_revisions (recipe_id);
+
+-- Existing recipes start with their current state as the first revision.
+insert into recipe_revisions (recipe_id, created_at, created_by, content)
+select id, coalesce(updated_at, created_at), created_by, recipe_snapshot(id)
+from recipes;
+
+-- migrate:down
+drop table recipe_revisions;
+drop function recipe_snapshot(bigint);
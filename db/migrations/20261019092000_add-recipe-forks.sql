@@ -0,0 +1,12 @@
+-- migrate:up
+-- Recipes can be duplicated into variants, e.g. a vegan version. The variant references its origin.
+alter table recipes
+	add column forked_from bigint null
+		references recipes (id)
+			on delete set null;
+
+create index recipes_forked_from_idx on recipes (forked_from);
+
+-- migrate:down
+alter table recipes
+	drop column forked_from;
@@ -0,0 +1,93 @@
+-- migrate:up
+-- Ingredients of a step can be ordered, e.g. in the order they are needed.
+alter table step_ingredients
+	add column sort_order integer not null default 0;
+
+-- Existing ingredients keep their previous order, which was by name.
+update step_ingredients
+set sort_order = ordered.position
+from (select step_ingredients.step_id,
+			 step_ingredients.ingredients_id,
+			 row_number() over (partition by step_ingredients.step_id order by ingredients.name) as position
+	  from step_ingredients
+			   inner join ingredients on ingredients.id = step_ingredients.ingredients_id) as ordered
+where step_ingredients.step_id = ordered.step_id
+  and step_ingredients.ingredients_id = ordered.ingredients_id;
+
+create or replace function recipe_snapshot(bigint) returns jsonb
+	language sql
+	stable
+as
+$$
+select jsonb_build_object(
+			   'name', recipes.name,
+			   'description', recipes.description,
+			   'workingTime', extract(epoch from recipes.working_time)::bigint,
+			   'waitingTime', extract(epoch from recipes.waiting_time)::bigint,
+			   'source', recipes.source,
+			   'servings', recipes.servings,
+			   'servingsDescription', recipes.servings_description,
+			   'steps', coalesce((select jsonb_agg(jsonb_build_object(
+					   'id', steps.id,
+					   'instruction', steps.instruction,
+					   'time', extract(epoch from steps.time)::bigint,
+					   'ingredients', coalesce((select jsonb_agg(jsonb_build_object(
+							   'id', ingredients.id,
+							   'name', ingredients.name,
+							   'unitID', step_ingredients.unit_id,
+							   'unitName', units.name,
+							   'amount', step_ingredients.amount,
+							   'note', step_ingredients.note
+						   ) order by step_ingredients.sort_order, ingredients.name)
+												from step_ingredients
+														 inner join ingredients on ingredients.id = step_ingredients.ingredients_id
+														 left join units on units.id = step_ingredients.unit_id
+												where step_ingredients.step_id = steps.id), '[]')
+				   ) order by steps.sort_order, steps.id)
+								  from steps
+								  where steps.recipe_id = recipes.id), '[]')
+		   )
+from recipes
+where recipes.id = $1;
+$$;
+
+-- migrate:down
+create or replace function recipe_snapshot(bigint) returns jsonb
+	language sql
+	stable
+as
+$$
+select jsonb_build_object(
+			   'name', recipes.name,
+			   'description', recipes.description,
+			   'workingTime', extract(epoch from recipes.working_time)::bigint,
+			   'waitingTime', extract(epoch from recipes.waiting_time)::bigint,
+			   'source', recipes.source,
+			   'servings', recipes.servings,
+			   'servingsDescription', recipes.servings_description,
+			   'steps', coalesce((select jsonb_agg(jsonb_build_object(
+					   'id', steps.id,
+					   'instruction', steps.instruction,
+					   'time', extract(epoch from steps.time)::bigint,
+					   'ingredients', coalesce((select jsonb_agg(jsonb_build_object(
+							   'id', ingredients.id,
+							   'name', ingredients.name,
+							   'unitID', step_ingredients.unit_id,
+							   'unitName', units.name,
+							   'amount', step_ingredients.amount,
+							   'note', step_ingredients.note
+						   ) order by ingredients.name)
+												from step_ingredients
+														 inner join ingredients on ingredients.id = step_ingredients.ingredients_id
+														 left join units on units.id = step_ingredients.unit_id
+												where step_ingredients.step_id = steps.id), '[]')
+				   ) order by steps.sort_order, steps.id)
+								  from steps
+								  where steps.recipe_id = recipes.id), '[]')
+		   )
+from recipes
+where recipes.id = $1;
+$$;
+
+alter table step_ingredients
+	drop column sort_order;
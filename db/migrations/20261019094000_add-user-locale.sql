@@ -0,0 +1,9 @@
+-- migrate:up
+-- The preferred locale of the user interface, e.g. "de". If it's not set, the locale is negotiated
+-- by the Accept-Language header of the browser.
+alter table users
+	add column locale text null;
+
+-- migrate:down
+alter table users
+	drop column locale;
@@ -0,0 +1,56 @@
+-- migrate:up
+-- Recipes are written in their original language. Translations of the texts are stored separately per locale,
+-- e.g. "en". Missing or empty translations fall back to the original text.
+alter table recipes
+	add column language text not null default 'de';
+
+create table recipe_translations
+(
+	recipe_id   bigint not null
+		references recipes (id)
+			on delete cascade,
+	locale      text   not null,
+	name        text   not null default '',
+	description text   not null default '',
+	primary key (recipe_id, locale)
+);
+
+create table step_translations
+(
+	step_id     bigint not null
+		references steps (id)
+			on delete cascade,
+	locale      text   not null,
+	instruction text   not null default '',
+	primary key (step_id, locale)
+);
+
+-- Ingredients and units are shared by all recipes, so their translations are as well.
+create table ingredient_translations
+(
+	ingredient_id bigint not null
+		references ingredients (id)
+			on delete cascade,
+	locale        text   not null,
+	name          text   not null,
+	primary key (ingredient_id, locale)
+);
+
+create table unit_translations
+(
+	unit_id bigint not null
+		references units (id)
+			on delete cascade,
+	locale  text   not null,
+	name    text   not null,
+	primary key (unit_id, locale)
+);
+
+-- migrate:down
+drop table unit_translations;
+drop table ingredient_translations;
+drop table step_translations;
+drop table recipe_translations;
+
+alter table recipes
+	drop column language;
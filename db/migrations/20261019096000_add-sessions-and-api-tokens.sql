@@ -0,0 +1,35 @@
+-- migrate:up
+-- Sessions of users who logged in with the browser. Only a hash of the token in the cookie is stored.
+create table sessions
+(
+	token_hash bytea primary key,
+	user_id    bigint      not null
+		references users (id)
+			on delete cascade,
+	created_at timestamptz not null default now(),
+	expires_at timestamptz not null
+);
+
+create index sessions_user_id_idx on sessions (user_id);
+
+-- Personal tokens for the API, e.g. for scripts. Like sessions, only a hash of the token is stored.
+create table api_tokens
+(
+	id           bigint primary key generated by default as identity,
+	user_id      bigint      not null
+		references users (id)
+			on delete cascade,
+	name         text        not null,
+	token_hash   bytea       not null unique,
+	scope        text        not null
+		constraint api_tokens_scope_check check (scope in ('read', 'write')),
+	created_at   timestamptz not null default now(),
+	expires_at   timestamptz null, -- null if the token never expires
+	last_used_at timestamptz null
+);
+
+create index api_tokens_user_id_idx on api_tokens (user_id);
+
+-- migrate:down
+drop table api_tokens;
+drop table sessions;
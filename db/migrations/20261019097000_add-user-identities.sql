@@ -0,0 +1,17 @@
+-- migrate:up
+-- Identities of users at external identity providers, see package oidc. The subject is only unique per issuer.
+create table user_identities
+(
+	issuer     text        not null,
+	subject    text        not null,
+	user_id    bigint      not null
+		references users (id)
+			on delete cascade,
+	created_at timestamptz not null default now(),
+	primary key (issuer, subject)
+);
+
+create index user_identities_user_id_idx on user_identities (user_id);
+
+-- migrate:down
+drop table user_identities;
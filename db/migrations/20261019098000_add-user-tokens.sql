@@ -0,0 +1,19 @@
+-- migrate:up
+-- One-time tokens that are sent to users by email, e.g. to verify their email address.
+-- Like sessions, only a hash of the token is stored.
+create table user_tokens
+(
+	token_hash bytea primary key,
+	user_id    bigint      not null
+		references users (id)
+			on delete cascade,
+	purpose    text        not null
+		constraint user_tokens_purpose_check check (purpose in ('verify-email', 'reset-password')),
+	created_at timestamptz not null default now(),
+	expires_at timestamptz not null
+);
+
+create index user_tokens_user_id_idx on user_tokens (user_id);
+
+-- migrate:down
+drop table user_tokens;
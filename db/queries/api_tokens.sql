@@ -0,0 +1,32 @@
+-- name: AddAPIToken :one
+insert into api_tokens (user_id, name, token_hash, scope, expires_at)
+values (sqlc.arg('user_id'), sqlc.arg('name'), sqlc.arg('token_hash'), sqlc.arg('scope'), sqlc.narg('expires_at'))
+returning id, created_at;
+
+-- name: GetAPITokensForUser :many
+select id, name, scope, created_at, expires_at, last_used_at
+from api_tokens
+where user_id = sqlc.arg('user_id')
+order by created_at desc, id desc;
+
+-- name: GetAPITokenByHash :one
+-- Expired tokens and tokens of deactivated users are treated like unknown tokens.
+select api_tokens.id, api_tokens.user_id, api_tokens.scope
+from api_tokens
+		 inner join users on users.id = api_tokens.user_id
+where api_tokens.token_hash = sqlc.arg('token_hash')
+  and (api_tokens.expires_at is null or api_tokens.expires_at > now())
+  and users.is_activated;
+
+-- name: TouchAPIToken :exec
+-- The time of the last use is updated at most once per minute, so that not every request causes a write.
+update api_tokens
+set last_used_at = now()
+where id = sqlc.arg('id')
+  and (last_used_at is null or last_used_at < now() - interval '1 minute');
+
+-- name: DeleteAPIToken :execrows
+delete
+from api_tokens
+where id = sqlc.arg('id')
+  and user_id = sqlc.arg('user_id');
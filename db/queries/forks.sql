@@ -0,0 +1,67 @@
+-- name: GetAvailableRecipeName :one
+select candidate::text
+from (select sqlc.arg('name')::text as candidate, 1 as n
+	  union all
+	  -- Recipe names are unique, so a numeric suffix is added until a free name is found, e.g. "Pizza (2)".
+	  select sqlc.arg('name')::text || ' (' || n || ')', n
+	  from generate_series(2, 1000) n) candidates
+where not exists(select 1 from recipes where recipes.name = candidates.candidate)
+order by n
+limit 1;
+
+-- name: ForkRecipe :one
+insert into recipes (name, description, working_time, waiting_time, created_at, updated_at, created_by, source,
+					 servings, servings_description, forked_from, language)
+select sqlc.arg('name'),
+	   description,
+	   working_time,
+	   waiting_time,
+	   now(),
+	   now(),
+	   coalesce(nullif(sqlc.arg('created_by')::bigint, 0), created_by),
+	   source,
+	   servings,
+	   servings_description,
+	   id,
+	   language
+from recipes
+where id = sqlc.arg('id')
+returning id;
+
+-- name: GetStepIDsForRecipe :many
+select id
+from steps
+where recipe_id = sqlc.arg('recipe_id')
+order by sort_order, id;
+
+-- name: CopyStep :one
+insert into steps (recipe_id, sort_order, instruction, time)
+select sqlc.arg('recipe_id'), sort_order, instruction, time
+from steps
+where id = sqlc.arg('id')
+returning id;
+
+-- name: CopyStepIngredients :exec
+insert into step_ingredients (step_id, ingredients_id, unit_id, amount, note, sort_order)
+select sqlc.arg('target_step_id'), ingredients_id, unit_id, amount, note, sort_order
+from step_ingredients
+where step_id = sqlc.arg('source_step_id');
+
+-- name: GetRecipeVariants :many
+select id, name
+from recipes
+where forked_from = sqlc.arg('id')::bigint
+order by name;
+
+-- name: CopyRecipeTranslations :exec
+-- The name of a variant differs from its origin, so only the description is copied.
+insert into recipe_translations (recipe_id, locale, name, description)
+select sqlc.arg('target_recipe_id'), locale, '', description
+from recipe_translations
+where recipe_id = sqlc.arg('source_recipe_id');
+
+-- name: CopyStepTranslations :exec
+insert into step_translations (step_id, locale, instruction)
+select sqlc.arg('target_step_id'), locale, instruction
+from step_translations
+where step_id = sqlc.arg('source_step_id');
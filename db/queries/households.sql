@@ -0,0 +1,52 @@
+-- name: GetAllHouseholds :many
+select id, name
+from households
+order by name, id;
+
+-- name: GetHouseholdByID :one
+select id, name
+from households
+where id = sqlc.arg('id');
+
+-- name: AddHousehold :one
+insert into households (name)
+values (sqlc.arg('name'))
+returning id;
+
+-- name: DeleteHousehold :execrows
+delete
+from households
+where id = sqlc.arg('id');
+
+-- name: GetHouseholdMembers :many
+select users.id, users.name
+from household_users
+		 inner join users on users.id = household_users.user_id
+where household_users.household_id = sqlc.arg('household_id')
+order by users.name, users.id;
+
+-- name: IsHouseholdMember :one
+select exists(select
+			  from household_users
+			  where household_id = sqlc.arg('household_id')
+				and user_id = sqlc.arg('user_id'));
+
+-- name: AddHouseholdMember :exec
+insert into household_users (household_id, user_id)
+values (sqlc.arg('household_id'), sqlc.arg('user_id'))
+on conflict do nothing;
+
+-- name: RemoveHouseholdMember :exec
+delete
+from household_users
+where household_id = sqlc.arg('household_id')
+  and user_id = sqlc.arg('user_id');
+
+-- name: RemoveAllHouseholdMembers :exec
+delete
+from household_users
+where household_id = sqlc.arg('household_id');
+
+-- name: CountHouseholds :one
+select count(*)
+from households;
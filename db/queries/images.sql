@@ -0,0 +1,35 @@
+-- name: AddImage :one
+insert into images (recipe_id, step_id, storage_key, width, height, alt_text)
+values (sqlc.arg('recipe_id'),
+		nullif(sqlc.arg('step_id')::bigint, 0),
+		sqlc.arg('storage_key'),
+		sqlc.arg('width'),
+		sqlc.arg('height'),
+		sqlc.arg('alt_text'))
+returning id;
+
+-- name: GetImagesForRecipe :many
+select *
+from images
+where recipe_id = sqlc.arg('recipe_id')
+order by id;
+
+-- name: GetImagesForStep :many
+select *
+from images
+where step_id = sqlc.arg('step_id')
+order by id;
+
+-- name: GetRecipeCoverImages :many
+select distinct on (recipe_id) *
+from images
+-- The cover of a recipe is the first image that doesn't belong to a step.
+where step_id is null
+order by recipe_id, id;
+
+-- name: DeleteImage :one
+delete
+from images
+where id = sqlc.arg('id')
+  and recipe_id = sqlc.arg('recipe_id')
+returning storage_key, width;
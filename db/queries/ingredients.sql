@@ -9,12 +9,44 @@ from ingredients
 where id = sqlc.arg('id');
 
 -- name: AddIngredientToStep :exec
-insert into step_ingredients (step_id, ingredients_id, unit_id, amount, note)
+insert into step_ingredients (step_id, ingredients_id, unit_id, amount, note, sort_order)
 values (sqlc.arg('step_id'),
 		sqlc.arg('ingredients_id'),
 		nullif(sqlc.arg('unit_id')::bigint, 0),
 		sqlc.arg('amount'),
-		sqlc.arg('note'));
+		sqlc.arg('note'),
+		-- New ingredients are added at the end of the step.
+		(select coalesce(max(sort_order), 0) + 1
+		 from step_ingredients
+		 where step_id = sqlc.arg('step_id')));
+
+-- name: UpdateStepIngredient :execrows
+update step_ingredients
+set unit_id = nullif(sqlc.arg('unit_id')::bigint, 0),
+	amount  = sqlc.arg('amount'),
+	note    = sqlc.arg('note')
+where step_id = sqlc.arg('step_id')
+  and ingredients_id = sqlc.arg('ingredients_id');
+
+-- name: GetStepIngredients :many
+select ingredients.id,
+	   ingredients.name,
+	   step_ingredients.unit_id,
+	   units.name as unit_name,
+	   step_ingredients.amount,
+	   step_ingredients.note
+from step_ingredients
+		 inner join ingredients on ingredients.id = step_ingredients.ingredients_id
+		 left join units on units.id = step_ingredients.unit_id
+where step_ingredients.step_id = sqlc.arg('step_id')
+order by step_ingredients.sort_order, ingredients.name;
+
+-- name: SetStepIngredientSortOrder :exec
+update step_ingredients
+set sort_order = new_order.position
+from unnest(sqlc.arg('ingredient_ids')::bigint[]) with ordinality as new_order(id, position)
+where step_ingredients.ingredients_id = new_order.id
+  and step_ingredients.step_id = sqlc.arg('step_id');
 
 -- name: DeleteIngredientFromStep :exec
 delete
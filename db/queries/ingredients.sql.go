@@ -7,6 +7,7 @@ package queries
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/jackc/pgtype"
 )
@@ -26,12 +27,16 @@ func (q *Queries) AddIngredient(ctx context.Context, name string) (int64, error)
 }
 
 const addIngredientToStep = `-- name: AddIngredientToStep :exec
-insert into step_ingredients (step_id, ingredients_id, unit_id, amount, note)
+insert into step_ingredients (step_id, ingredients_id, unit_id, amount, note, sort_order)
 values ($1,
 		$2,
 		nullif($3::bigint, 0),
 		$4,
-		$5)
+		$5,
+		-- New ingredients are added at the end of the step.
+		(select coalesce(max(sort_order), 0) + 1
+		 from step_ingredients
+		 where step_id = $1))
 `
 
 type AddIngredientToStepParams struct {
@@ -108,3 +113,102 @@ func (q *Queries) GetIngredientNameByID(ctx context.Context, id int64) (string,
 	err := row.Scan(&name)
 	return name, err
 }
+
+const getStepIngredients = `-- name: GetStepIngredients :many
+select ingredients.id,
+	   ingredients.name,
+	   step_ingredients.unit_id,
+	   units.name as unit_name,
+	   step_ingredients.amount,
+	   step_ingredients.note
+from step_ingredients
+		 inner join ingredients on ingredients.id = step_ingredients.ingredients_id
+		 left join units on units.id = step_ingredients.unit_id
+where step_ingredients.step_id = $1
+order by step_ingredients.sort_order, ingredients.name
+`
+
+type GetStepIngredientsRow struct {
+	ID       int64
+	Name     string
+	UnitID   sql.NullInt64
+	UnitName sql.NullString
+	Amount   pgtype.Numeric
+	Note     string
+}
+
+func (q *Queries) GetStepIngredients(ctx context.Context, stepID int64) ([]GetStepIngredientsRow, error) {
+	rows, err := q.db.Query(ctx, getStepIngredients, stepID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetStepIngredientsRow
+	for rows.Next() {
+		var i GetStepIngredientsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.UnitID,
+			&i.UnitName,
+			&i.Amount,
+			&i.Note,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setStepIngredientSortOrder = `-- name: SetStepIngredientSortOrder :exec
+update step_ingredients
+set sort_order = new_order.position
+from unnest($1::bigint[]) with ordinality as new_order(id, position)
+where step_ingredients.ingredients_id = new_order.id
+  and step_ingredients.step_id = $2
+`
+
+type SetStepIngredientSortOrderParams struct {
+	IngredientIds []int64
+	StepID        int64
+}
+
+func (q *Queries) SetStepIngredientSortOrder(ctx context.Context, arg SetStepIngredientSortOrderParams) error {
+	_, err := q.db.Exec(ctx, setStepIngredientSortOrder, arg.IngredientIds, arg.StepID)
+	return err
+}
+
+const updateStepIngredient = `-- name: UpdateStepIngredient :execrows
+update step_ingredients
+set unit_id = nullif($1::bigint, 0),
+	amount  = $2,
+	note    = $3
+where step_id = $4
+  and ingredients_id = $5
+`
+
+type UpdateStepIngredientParams struct {
+	UnitID        int64
+	Amount        pgtype.Numeric
+	Note          string
+	StepID        int64
+	IngredientsID int64
+}
+
+func (q *Queries) UpdateStepIngredient(ctx context.Context, arg UpdateStepIngredientParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updateStepIngredient,
+		arg.UnitID,
+		arg.Amount,
+		arg.Note,
+		arg.StepID,
+		arg.IngredientsID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
@@ -11,6 +11,17 @@ import (
 	"github.com/jackc/pgtype"
 )
 
+type ApiToken struct {
+	ID         int64
+	UserID     int64
+	Name       string
+	TokenHash  []byte
+	Scope      string
+	CreatedAt  time.Time
+	ExpiresAt  sql.NullTime
+	LastUsedAt sql.NullTime
+}
+
 type Household struct {
 	ID   int64
 	Name string
@@ -21,11 +32,28 @@ type HouseholdUser struct {
 	UserID      int64
 }
 
+type Image struct {
+	ID         int64
+	RecipeID   int64
+	StepID     sql.NullInt64
+	StorageKey string
+	Width      int32
+	Height     int32
+	AltText    string
+	CreatedAt  time.Time
+}
+
 type Ingredient struct {
 	ID   int64
 	Name string
 }
 
+type IngredientTranslation struct {
+	IngredientID int64
+	Locale       string
+	Name         string
+}
+
 type Recipe struct {
 	ID                  int64
 	Name                string
@@ -38,12 +66,36 @@ type Recipe struct {
 	Source              sql.NullString
 	Servings            int32
 	ServingsDescription string
+	ForkedFrom          sql.NullInt64
+	Language            string
+}
+
+type RecipeRevision struct {
+	ID        int64
+	RecipeID  int64
+	CreatedAt time.Time
+	CreatedBy sql.NullInt64
+	Content   pgtype.JSONB
+}
+
+type RecipeTranslation struct {
+	RecipeID    int64
+	Locale      string
+	Name        string
+	Description string
 }
 
 type SchemaMigration struct {
 	Version string
 }
 
+type Session struct {
+	TokenHash []byte
+	UserID    int64
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
 type Step struct {
 	ID          int64
 	RecipeID    int64
@@ -58,6 +110,13 @@ type StepIngredient struct {
 	UnitID        sql.NullInt64
 	Amount        pgtype.Numeric
 	Note          string
+	SortOrder     int32
+}
+
+type StepTranslation struct {
+	StepID      int64
+	Locale      string
+	Instruction string
 }
 
 type Unit struct {
@@ -65,6 +124,12 @@ type Unit struct {
 	Name string
 }
 
+type UnitTranslation struct {
+	UnitID int64
+	Locale string
+	Name   string
+}
+
 type User struct {
 	ID                    int64
 	Name                  string
@@ -73,4 +138,20 @@ type User struct {
 	PasswordHashAlgorithm string
 	IsActivated           bool
 	IsSuperuser           bool
+	Locale                sql.NullString
+}
+
+type UserIdentity struct {
+	Issuer    string
+	Subject   string
+	UserID    int64
+	CreatedAt time.Time
+}
+
+type UserToken struct {
+	TokenHash []byte
+	UserID    int64
+	Purpose   string
+	CreatedAt time.Time
+	ExpiresAt time.Time
 }
@@ -14,7 +14,9 @@ select id,
 	   created_by,
 	   source,
 	   servings,
-	   servings_description
+	   servings_description,
+	   forked_from,
+	   language
 from recipes
 where id = sqlc.arg(id);
 
@@ -30,11 +32,12 @@ select steps.id,
 			   'id', ingredients.id,
 			   'stepID', steps.id,
 			   'recipeID', steps.recipe_id,
+			   'unitID', units.id,
 			   'unitName', units.name,
 			   'name', ingredients.name,
 			   'amount', step_ingredients.amount,
 			   'note', step_ingredients.note
-		   ))) as ingredients
+		   )) order by step_ingredients.sort_order, ingredients.name) as ingredients
 from steps
 		 left join step_ingredients on steps.id = step_ingredients.step_id
 		 left join ingredients on step_ingredients.ingredients_id = ingredients.id
@@ -45,7 +48,9 @@ order by steps.sort_order;
 
 
 -- name: GetTotalIngredientsForRecipe :many
-select ingredients.name,
+select ingredients.id,
+	   ingredients.name,
+	   units.id                     as unit_id,
 	   units.name                   as unit_name,
 	   sum(step_ingredients.amount) as total_amount
 from steps
@@ -53,7 +58,7 @@ from steps
 		 inner join ingredients on ingredients.id = step_ingredients.ingredients_id
 		 left join units on units.id = step_ingredients.unit_id
 where steps.recipe_id = sqlc.arg('id')
-group by ingredients.name, units.name
+group by ingredients.id, ingredients.name, units.id, units.name
 order by ingredients.name, total_amount desc;
 
 -- name: UpdateBasicRecipeInformation :exec
@@ -62,9 +67,18 @@ set name                 = sqlc.arg('name'),
 	servings             = sqlc.arg('servings'),
 	description          = sqlc.arg('description'),
 	servings_description = sqlc.arg('servings_description'),
+	working_time         = sqlc.arg('working_time'),
+	waiting_time         = sqlc.arg('waiting_time'),
+	source               = nullif(sqlc.arg('source')::text, ''),
+	language             = coalesce(nullif(sqlc.arg('language')::text, ''), language),
 	updated_at           = now()
 where id = sqlc.arg('id');
 
+-- name: DeleteRecipe :execrows
+delete
+from recipes
+where id = sqlc.arg('id');
+
 -- name: DeleteStepByID :exec
 delete
 from steps
@@ -91,7 +105,7 @@ returning steps.id;
 
 -- name: AddRecipe :one
 insert into recipes(name, description, working_time, waiting_time, created_at, updated_at, created_by, source, servings,
-					servings_description)
+					servings_description, language)
 values (sqlc.arg('name'),
 		sqlc.arg('description'),
 		sqlc.arg('working_time'),
@@ -101,5 +115,17 @@ values (sqlc.arg('name'),
 		sqlc.arg('created_by'),
 		sqlc.arg('source'),
 		sqlc.arg('servings'),
-		sqlc.arg('servings_description'))
+		sqlc.arg('servings_description'),
+		sqlc.arg('language'))
 returning id, created_at;
+
+-- name: SetStepSortOrder :exec
+update steps
+set sort_order = new_order.position
+from unnest(sqlc.arg('step_ids')::bigint[]) with ordinality as new_order(id, position)
+where steps.id = new_order.id
+  and steps.recipe_id = sqlc.arg('recipe_id');
+
+-- name: CountRecipes :one
+select count(*)
+from recipes;
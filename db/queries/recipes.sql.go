@@ -39,7 +39,7 @@ func (q *Queries) AddNewStep(ctx context.Context, arg AddNewStepParams) (int64,
 
 const addRecipe = `-- name: AddRecipe :one
 insert into recipes(name, description, working_time, waiting_time, created_at, updated_at, created_by, source, servings,
-					servings_description)
+					servings_description, language)
 values ($1,
 		$2,
 		$3,
@@ -49,7 +49,8 @@ values ($1,
 		$5,
 		$6,
 		$7,
-		$8)
+		$8,
+		$9)
 returning id, created_at
 `
 
@@ -62,6 +63,7 @@ type AddRecipeParams struct {
 	Source              sql.NullString
 	Servings            int32
 	ServingsDescription string
+	Language            string
 }
 
 type AddRecipeRow struct {
@@ -79,12 +81,39 @@ func (q *Queries) AddRecipe(ctx context.Context, arg AddRecipeParams) (AddRecipe
 		arg.Source,
 		arg.Servings,
 		arg.ServingsDescription,
+		arg.Language,
 	)
 	var i AddRecipeRow
 	err := row.Scan(&i.ID, &i.CreatedAt)
 	return i, err
 }
 
+const countRecipes = `-- name: CountRecipes :one
+select count(*)
+from recipes
+`
+
+func (q *Queries) CountRecipes(ctx context.Context) (int64, error) {
+	row := q.db.QueryRow(ctx, countRecipes)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const deleteRecipe = `-- name: DeleteRecipe :execrows
+delete
+from recipes
+where id = $1
+`
+
+func (q *Queries) DeleteRecipe(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteRecipe, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const deleteStepByID = `-- name: DeleteStepByID :exec
 delete
 from steps
@@ -138,7 +167,9 @@ select id,
 	   created_by,
 	   source,
 	   servings,
-	   servings_description
+	   servings_description,
+	   forked_from,
+	   language
 from recipes
 where id = $1
 `
@@ -158,6 +189,8 @@ func (q *Queries) GetRecipeByID(ctx context.Context, id int64) (Recipe, error) {
 		&i.Source,
 		&i.Servings,
 		&i.ServingsDescription,
+		&i.ForkedFrom,
+		&i.Language,
 	)
 	return i, err
 }
@@ -191,11 +224,12 @@ select steps.id,
 			   'id', ingredients.id,
 			   'stepID', steps.id,
 			   'recipeID', steps.recipe_id,
+			   'unitID', units.id,
 			   'unitName', units.name,
 			   'name', ingredients.name,
 			   'amount', step_ingredients.amount,
 			   'note', step_ingredients.note
-		   ))) as ingredients
+		   )) order by step_ingredients.sort_order, ingredients.name) as ingredients
 from steps
 		 left join step_ingredients on steps.id = step_ingredients.step_id
 		 left join ingredients on step_ingredients.ingredients_id = ingredients.id
@@ -238,7 +272,9 @@ func (q *Queries) GetStepsForRecipeByID(ctx context.Context, id int64) ([]GetSte
 }
 
 const getTotalIngredientsForRecipe = `-- name: GetTotalIngredientsForRecipe :many
-select ingredients.name,
+select ingredients.id,
+	   ingredients.name,
+	   units.id                     as unit_id,
 	   units.name                   as unit_name,
 	   sum(step_ingredients.amount) as total_amount
 from steps
@@ -246,12 +282,14 @@ from steps
 		 inner join ingredients on ingredients.id = step_ingredients.ingredients_id
 		 left join units on units.id = step_ingredients.unit_id
 where steps.recipe_id = $1
-group by ingredients.name, units.name
+group by ingredients.id, ingredients.name, units.id, units.name
 order by ingredients.name, total_amount desc
 `
 
 type GetTotalIngredientsForRecipeRow struct {
+	ID          int64
 	Name        string
+	UnitID      sql.NullInt64
 	UnitName    sql.NullString
 	TotalAmount int64
 }
@@ -265,7 +303,13 @@ func (q *Queries) GetTotalIngredientsForRecipe(ctx context.Context, id int64) ([
 	var items []GetTotalIngredientsForRecipeRow
 	for rows.Next() {
 		var i GetTotalIngredientsForRecipeRow
-		if err := rows.Scan(&i.Name, &i.UnitName, &i.TotalAmount); err != nil {
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.UnitID,
+			&i.UnitName,
+			&i.TotalAmount,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -276,14 +320,36 @@ func (q *Queries) GetTotalIngredientsForRecipe(ctx context.Context, id int64) ([
 	return items, nil
 }
 
+const setStepSortOrder = `-- name: SetStepSortOrder :exec
+update steps
+set sort_order = new_order.position
+from unnest($1::bigint[]) with ordinality as new_order(id, position)
+where steps.id = new_order.id
+  and steps.recipe_id = $2
+`
+
+type SetStepSortOrderParams struct {
+	StepIds  []int64
+	RecipeID int64
+}
+
+func (q *Queries) SetStepSortOrder(ctx context.Context, arg SetStepSortOrderParams) error {
+	_, err := q.db.Exec(ctx, setStepSortOrder, arg.StepIds, arg.RecipeID)
+	return err
+}
+
 const updateBasicRecipeInformation = `-- name: UpdateBasicRecipeInformation :exec
 update recipes
 set name                 = $1,
 	servings             = $2,
 	description          = $3,
 	servings_description = $4,
+	working_time         = $5,
+	waiting_time         = $6,
+	source               = nullif($7::text, ''),
+	language             = coalesce(nullif($8::text, ''), language),
 	updated_at           = now()
-where id = $5
+where id = $9
 `
 
 type UpdateBasicRecipeInformationParams struct {
@@ -291,6 +357,10 @@ type UpdateBasicRecipeInformationParams struct {
 	Servings            int32
 	Description         string
 	ServingsDescription string
+	WorkingTime         pgtype.Interval
+	WaitingTime         pgtype.Interval
+	Source              string
+	Language            string
 	ID                  int64
 }
 
@@ -300,6 +370,10 @@ func (q *Queries) UpdateBasicRecipeInformation(ctx context.Context, arg UpdateBa
 		arg.Servings,
 		arg.Description,
 		arg.ServingsDescription,
+		arg.WorkingTime,
+		arg.WaitingTime,
+		arg.Source,
+		arg.Language,
 		arg.ID,
 	)
 	return err
@@ -0,0 +1,76 @@
+-- name: AddRecipeRevision :exec
+insert into recipe_revisions (recipe_id, created_by, content)
+select recipes.id,
+	   nullif(sqlc.arg('created_by')::bigint, 0),
+	   recipe_snapshot(recipes.id)
+from recipes
+where recipes.id = sqlc.arg('recipe_id')
+  -- Changes that don't modify the content, e.g. saving a form without any changes, are not recorded.
+  and recipe_snapshot(recipes.id) is distinct from (select content
+												   from recipe_revisions
+												   where recipe_id = recipes.id
+												   order by id desc
+												   limit 1);
+
+-- name: GetRecipeRevisions :many
+select recipe_revisions.id,
+	   recipe_revisions.created_at,
+	   users.name as author_name
+from recipe_revisions
+		 left join users on users.id = recipe_revisions.created_by
+where recipe_revisions.recipe_id = sqlc.arg('recipe_id')
+order by recipe_revisions.id desc;
+
+-- name: GetRecipeRevision :one
+select recipe_revisions.id,
+	   recipe_revisions.created_at,
+	   users.name as author_name,
+	   recipe_revisions.content,
+	   (select previous.content
+		from recipe_revisions previous
+		where previous.recipe_id = recipe_revisions.recipe_id
+		  and previous.id < recipe_revisions.id
+		order by previous.id desc
+		limit 1)::jsonb as previous_content
+from recipe_revisions
+		 left join users on users.id = recipe_revisions.created_by
+where recipe_revisions.recipe_id = sqlc.arg('recipe_id')
+  and recipe_revisions.id = sqlc.arg('id');
+
+-- name: RestoreRecipe :exec
+update recipes
+set name                 = sqlc.arg('name'),
+	description          = sqlc.arg('description'),
+	working_time         = sqlc.arg('working_time'),
+	waiting_time         = sqlc.arg('waiting_time'),
+	source               = sqlc.arg('source'),
+	servings             = sqlc.arg('servings'),
+	servings_description = sqlc.arg('servings_description'),
+	updated_at           = now()
+where id = sqlc.arg('id');
+
+-- name: DeleteStepsExcept :exec
+delete
+from steps
+where recipe_id = sqlc.arg('recipe_id')
+  and not (id = any (sqlc.arg('step_ids')::bigint[]));
+
+-- name: RestoreStep :exec
+insert into steps (id, recipe_id, sort_order, instruction, time)
+values (sqlc.arg('id'),
+		sqlc.arg('recipe_id'),
+		sqlc.arg('sort_order'),
+		sqlc.arg('instruction'),
+		sqlc.arg('time'))
+-- Deleted steps are inserted again with their previous ID.
+on conflict (id) do update set sort_order  = excluded.sort_order,
+							   instruction = excluded.instruction,
+							   time        = excluded.time
+where steps.recipe_id = excluded.recipe_id;
+
+-- name: DeleteStepIngredientsForRecipe :exec
+delete
+from step_ingredients
+	using steps
+where steps.id = step_ingredients.step_id
+  and steps.recipe_id = sqlc.arg('recipe_id');
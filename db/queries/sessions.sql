@@ -0,0 +1,27 @@
+-- name: AddSession :exec
+insert into sessions (token_hash, user_id, expires_at)
+values (sqlc.arg('token_hash'), sqlc.arg('user_id'), sqlc.arg('expires_at'));
+
+-- name: GetSessionUser :one
+-- Only sessions of activated users are valid, so deactivating a user ends all their sessions.
+select users.id, users.name
+from sessions
+		 inner join users on users.id = sessions.user_id
+where sessions.token_hash = sqlc.arg('token_hash')
+  and sessions.expires_at > now()
+  and users.is_activated;
+
+-- name: DeleteSession :exec
+delete
+from sessions
+where token_hash = sqlc.arg('token_hash');
+
+-- name: DeleteExpiredSessions :exec
+delete
+from sessions
+where expires_at <= now();
+
+-- name: DeleteUserSessions :exec
+delete
+from sessions
+where user_id = sqlc.arg('user_id');
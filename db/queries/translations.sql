@@ -0,0 +1,57 @@
+-- name: GetRecipeTranslation :one
+select name, description
+from recipe_translations
+where recipe_id = sqlc.arg('recipe_id')
+  and locale = sqlc.arg('locale');
+
+-- name: GetStepTranslations :many
+select step_translations.step_id, step_translations.instruction
+from step_translations
+		 inner join steps on steps.id = step_translations.step_id
+where steps.recipe_id = sqlc.arg('recipe_id')
+  and step_translations.locale = sqlc.arg('locale');
+
+-- name: GetIngredientTranslations :many
+select ingredient_id, name
+from ingredient_translations
+where locale = sqlc.arg('locale')
+  and ingredient_id = any (sqlc.arg('ingredient_ids')::bigint[]);
+
+-- name: GetUnitTranslations :many
+select unit_id, name
+from unit_translations
+where locale = sqlc.arg('locale')
+  and unit_id = any (sqlc.arg('unit_ids')::bigint[]);
+
+-- name: GetRecipeNameTranslations :many
+select recipe_translations.recipe_id, recipe_translations.name
+from recipe_translations
+		 inner join recipes on recipes.id = recipe_translations.recipe_id
+where recipe_translations.locale = sqlc.arg('locale')
+  and recipes.language <> sqlc.arg('locale')
+  and recipe_translations.name <> '';
+
+-- name: SaveRecipeTranslation :exec
+insert into recipe_translations (recipe_id, locale, name, description)
+values (sqlc.arg('recipe_id'), sqlc.arg('locale'), sqlc.arg('name'), sqlc.arg('description'))
+on conflict (recipe_id, locale) do update set name        = excluded.name,
+											  description = excluded.description;
+
+-- name: SaveStepTranslation :execrows
+-- The step is selected with its recipe, so that only steps of the given recipe can be translated.
+insert into step_translations (step_id, locale, instruction)
+select id, sqlc.arg('locale'), sqlc.arg('instruction')
+from steps
+where id = sqlc.arg('step_id')
+  and recipe_id = sqlc.arg('recipe_id')
+on conflict (step_id, locale) do update set instruction = excluded.instruction;
+
+-- name: SaveIngredientTranslation :exec
+insert into ingredient_translations (ingredient_id, locale, name)
+values (sqlc.arg('ingredient_id'), sqlc.arg('locale'), sqlc.arg('name'))
+on conflict (ingredient_id, locale) do update set name = excluded.name;
+
+-- name: SaveUnitTranslation :exec
+insert into unit_translations (unit_id, locale, name)
+values (sqlc.arg('unit_id'), sqlc.arg('locale'), sqlc.arg('name'))
+on conflict (unit_id, locale) do update set name = excluded.name;
@@ -0,0 +1,9 @@
+-- name: GetIdentityUserID :one
+select user_id
+from user_identities
+where issuer = sqlc.arg('issuer')
+  and subject = sqlc.arg('subject');
+
+-- name: AddUserIdentity :exec
+insert into user_identities (issuer, subject, user_id)
+values (sqlc.arg('issuer'), sqlc.arg('subject'), sqlc.arg('user_id'));
@@ -0,0 +1,23 @@
+-- name: AddUserToken :exec
+insert into user_tokens (token_hash, user_id, purpose, expires_at)
+values (sqlc.arg('token_hash'), sqlc.arg('user_id'), sqlc.arg('purpose'), sqlc.arg('expires_at'));
+
+-- name: ConsumeUserToken :one
+-- Tokens can only be used once, so they are deleted when they are used.
+delete
+from user_tokens
+where token_hash = sqlc.arg('token_hash')
+  and purpose = sqlc.arg('purpose')
+  and expires_at > now()
+returning user_id;
+
+-- name: DeleteUserTokens :exec
+delete
+from user_tokens
+where user_id = sqlc.arg('user_id')
+  and purpose = sqlc.arg('purpose');
+
+-- name: DeleteExpiredUserTokens :exec
+delete
+from user_tokens
+where expires_at <= now();
@@ -3,3 +3,57 @@ insert into users(name, email, password_hash, password_hash_algorithm)
 values ('demo user', 'demo@mahlzeit.app', '', 'argon2')
 on conflict (email) do update set email = excluded.email
 returning id;
+
+-- name: GetUserLocale :one
+select locale
+from users
+where id = sqlc.arg('id');
+
+-- name: SetUserLocale :exec
+update users
+set locale = sqlc.arg('locale')
+where id = sqlc.arg('id');
+
+-- name: AddUser :one
+insert into users(name, email, password_hash, password_hash_algorithm, is_activated)
+values (sqlc.arg('name'), sqlc.arg('email'), sqlc.arg('password_hash'), sqlc.arg('password_hash_algorithm'),
+		sqlc.arg('is_activated'))
+returning id;
+
+-- name: GetUserByEmail :one
+select id, name, password_hash, password_hash_algorithm, is_activated
+from users
+where email = sqlc.arg('email');
+
+-- name: UpdateUserFromIdentity :one
+-- The identity provider can only activate users, but never deactivates them. If is_superuser is null, it's kept.
+update users
+set is_activated = is_activated or sqlc.arg('activate'),
+	is_superuser = coalesce(sqlc.narg('is_superuser'), is_superuser)
+where id = sqlc.arg('id')
+returning id, name, is_activated;
+
+-- name: ActivateUser :exec
+update users
+set is_activated = true
+where id = sqlc.arg('id');
+
+-- name: SetUserPassword :exec
+update users
+set password_hash           = sqlc.arg('password_hash'),
+	password_hash_algorithm = sqlc.arg('password_hash_algorithm')
+where id = sqlc.arg('id');
+
+-- name: IsUserSuperuser :one
+select is_superuser
+from users
+where id = sqlc.arg('id');
+
+-- name: SetUserSuperuser :execrows
+update users
+set is_superuser = sqlc.arg('is_superuser')
+where id = sqlc.arg('id');
+
+-- name: CountUsers :one
+select count(*)
+from users;
@@ -7,8 +7,20 @@ package queries
 
 import (
 	"context"
+	"database/sql"
 )
 
+const activateUser = `-- name: ActivateUser :exec
+update users
+set is_activated = true
+where id = $1
+`
+
+func (q *Queries) ActivateUser(ctx context.Context, id int64) error {
+	_, err := q.db.Exec(ctx, activateUser, id)
+	return err
+}
+
 const addDemoUser = `-- name: AddDemoUser :one
 insert into users(name, email, password_hash, password_hash_algorithm)
 values ('demo user', 'demo@mahlzeit.app', '', 'argon2')
@@ -22,3 +34,177 @@ func (q *Queries) AddDemoUser(ctx context.Context) (int64, error) {
 	err := row.Scan(&id)
 	return id, err
 }
+
+const addUser = `-- name: AddUser :one
+insert into users(name, email, password_hash, password_hash_algorithm, is_activated)
+values ($1, $2, $3, $4,
+		$5)
+returning id
+`
+
+type AddUserParams struct {
+	Name                  string
+	Email                 string
+	PasswordHash          string
+	PasswordHashAlgorithm string
+	IsActivated           bool
+}
+
+func (q *Queries) AddUser(ctx context.Context, arg AddUserParams) (int64, error) {
+	row := q.db.QueryRow(ctx, addUser,
+		arg.Name,
+		arg.Email,
+		arg.PasswordHash,
+		arg.PasswordHashAlgorithm,
+		arg.IsActivated,
+	)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const countUsers = `-- name: CountUsers :one
+select count(*)
+from users
+`
+
+func (q *Queries) CountUsers(ctx context.Context) (int64, error) {
+	row := q.db.QueryRow(ctx, countUsers)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const getUserByEmail = `-- name: GetUserByEmail :one
+select id, name, password_hash, password_hash_algorithm, is_activated
+from users
+where email = $1
+`
+
+type GetUserByEmailRow struct {
+	ID                    int64
+	Name                  string
+	PasswordHash          string
+	PasswordHashAlgorithm string
+	IsActivated           bool
+}
+
+func (q *Queries) GetUserByEmail(ctx context.Context, email string) (GetUserByEmailRow, error) {
+	row := q.db.QueryRow(ctx, getUserByEmail, email)
+	var i GetUserByEmailRow
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.PasswordHash,
+		&i.PasswordHashAlgorithm,
+		&i.IsActivated,
+	)
+	return i, err
+}
+
+const getUserLocale = `-- name: GetUserLocale :one
+select locale
+from users
+where id = $1
+`
+
+func (q *Queries) GetUserLocale(ctx context.Context, id int64) (sql.NullString, error) {
+	row := q.db.QueryRow(ctx, getUserLocale, id)
+	var locale sql.NullString
+	err := row.Scan(&locale)
+	return locale, err
+}
+
+const isUserSuperuser = `-- name: IsUserSuperuser :one
+select is_superuser
+from users
+where id = $1
+`
+
+func (q *Queries) IsUserSuperuser(ctx context.Context, id int64) (bool, error) {
+	row := q.db.QueryRow(ctx, isUserSuperuser, id)
+	var is_superuser bool
+	err := row.Scan(&is_superuser)
+	return is_superuser, err
+}
+
+const setUserLocale = `-- name: SetUserLocale :exec
+update users
+set locale = $1
+where id = $2
+`
+
+type SetUserLocaleParams struct {
+	Locale sql.NullString
+	ID     int64
+}
+
+func (q *Queries) SetUserLocale(ctx context.Context, arg SetUserLocaleParams) error {
+	_, err := q.db.Exec(ctx, setUserLocale, arg.Locale, arg.ID)
+	return err
+}
+
+const setUserPassword = `-- name: SetUserPassword :exec
+update users
+set password_hash           = $1,
+	password_hash_algorithm = $2
+where id = $3
+`
+
+type SetUserPasswordParams struct {
+	PasswordHash          string
+	PasswordHashAlgorithm string
+	ID                    int64
+}
+
+func (q *Queries) SetUserPassword(ctx context.Context, arg SetUserPasswordParams) error {
+	_, err := q.db.Exec(ctx, setUserPassword, arg.PasswordHash, arg.PasswordHashAlgorithm, arg.ID)
+	return err
+}
+
+const setUserSuperuser = `-- name: SetUserSuperuser :execrows
+update users
+set is_superuser = $1
+where id = $2
+`
+
+type SetUserSuperuserParams struct {
+	IsSuperuser bool
+	ID          int64
+}
+
+func (q *Queries) SetUserSuperuser(ctx context.Context, arg SetUserSuperuserParams) (int64, error) {
+	result, err := q.db.Exec(ctx, setUserSuperuser, arg.IsSuperuser, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const updateUserFromIdentity = `-- name: UpdateUserFromIdentity :one
+update users
+set is_activated = is_activated or $1,
+	is_superuser = coalesce($2, is_superuser)
+where id = $3
+returning id, name, is_activated
+`
+
+type UpdateUserFromIdentityParams struct {
+	Activate    bool
+	IsSuperuser sql.NullBool
+	ID          int64
+}
+
+type UpdateUserFromIdentityRow struct {
+	ID          int64
+	Name        string
+	IsActivated bool
+}
+
+// The identity provider can only activate users, but never deactivates them. If is_superuser is null, it's kept.
+func (q *Queries) UpdateUserFromIdentity(ctx context.Context, arg UpdateUserFromIdentityParams) (UpdateUserFromIdentityRow, error) {
+	row := q.db.QueryRow(ctx, updateUserFromIdentity, arg.Activate, arg.IsSuperuser, arg.ID)
+	var i UpdateUserFromIdentityRow
+	err := row.Scan(&i.ID, &i.Name, &i.IsActivated)
+	return i, err
+}
@@ -9,10 +9,81 @@ SET xmloption = content;
 SET client_min_messages = warning;
 SET row_security = off;
 
+--
+-- Name: recipe_snapshot(bigint); Type: FUNCTION; Schema: public; Owner: -
+--
+
+CREATE FUNCTION public.recipe_snapshot(bigint) RETURNS jsonb
+    LANGUAGE sql STABLE
+    AS $_$
+select jsonb_build_object(
+			   'name', recipes.name,
+			   'description', recipes.description,
+			   'workingTime', extract(epoch from recipes.working_time)::bigint,
+			   'waitingTime', extract(epoch from recipes.waiting_time)::bigint,
+			   'source', recipes.source,
+			   'servings', recipes.servings,
+			   'servingsDescription', recipes.servings_description,
+			   'steps', coalesce((select jsonb_agg(jsonb_build_object(
+					   'id', steps.id,
+					   'instruction', steps.instruction,
+					   'time', extract(epoch from steps.time)::bigint,
+					   'ingredients', coalesce((select jsonb_agg(jsonb_build_object(
+							   'id', ingredients.id,
+							   'name', ingredients.name,
+							   'unitID', step_ingredients.unit_id,
+							   'unitName', units.name,
+							   'amount', step_ingredients.amount,
+							   'note', step_ingredients.note
+						   ) order by step_ingredients.sort_order, ingredients.name)
+												from step_ingredients
+														 inner join ingredients on ingredients.id = step_ingredients.ingredients_id
+														 left join units on units.id = step_ingredients.unit_id
+												where step_ingredients.step_id = steps.id), '[]')
+				   ) order by steps.sort_order, steps.id)
+								  from steps
+								  where steps.recipe_id = recipes.id), '[]')
+		   )
+from recipes
+where recipes.id = $1;
+$_$;
+
+
 SET default_tablespace = '';
 
 SET default_table_access_method = heap;
 
+--
+-- Name: api_tokens; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.api_tokens (
+    id bigint NOT NULL,
+    user_id bigint NOT NULL,
+    name text NOT NULL,
+    token_hash bytea NOT NULL,
+    scope text NOT NULL,
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    expires_at timestamp with time zone,
+    last_used_at timestamp with time zone,
+    CONSTRAINT api_tokens_scope_check CHECK ((scope = ANY (ARRAY['read'::text, 'write'::text])))
+);
+
+
+--
+-- Name: api_tokens_id_seq; Type: SEQUENCE; Schema: public; Owner: -
+--
+
+ALTER TABLE public.api_tokens ALTER COLUMN id ADD GENERATED BY DEFAULT AS IDENTITY (
+    SEQUENCE NAME public.api_tokens_id_seq
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1
+);
+
+
 --
 -- Name: household_users; Type: TABLE; Schema: public; Owner: -
 --
@@ -47,6 +118,47 @@ ALTER TABLE public.households ALTER COLUMN id ADD GENERATED BY DEFAULT AS IDENTI
 );
 
 
+--
+-- Name: images; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.images (
+    id bigint NOT NULL,
+    recipe_id bigint NOT NULL,
+    step_id bigint,
+    storage_key text NOT NULL,
+    width integer NOT NULL,
+    height integer NOT NULL,
+    alt_text text DEFAULT ''::text NOT NULL,
+    created_at timestamp with time zone DEFAULT now() NOT NULL
+);
+
+
+--
+-- Name: images_id_seq; Type: SEQUENCE; Schema: public; Owner: -
+--
+
+ALTER TABLE public.images ALTER COLUMN id ADD GENERATED BY DEFAULT AS IDENTITY (
+    SEQUENCE NAME public.images_id_seq
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1
+);
+
+
+--
+-- Name: ingredient_translations; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.ingredient_translations (
+    ingredient_id bigint NOT NULL,
+    locale text NOT NULL,
+    name text NOT NULL
+);
+
+
 --
 -- Name: ingredients; Type: TABLE; Schema: public; Owner: -
 --
@@ -71,6 +183,45 @@ ALTER TABLE public.ingredients ALTER COLUMN id ADD GENERATED BY DEFAULT AS IDENT
 );
 
 
+--
+-- Name: recipe_revisions; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.recipe_revisions (
+    id bigint NOT NULL,
+    recipe_id bigint NOT NULL,
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    created_by bigint,
+    content jsonb NOT NULL
+);
+
+
+--
+-- Name: recipe_revisions_id_seq; Type: SEQUENCE; Schema: public; Owner: -
+--
+
+ALTER TABLE public.recipe_revisions ALTER COLUMN id ADD GENERATED BY DEFAULT AS IDENTITY (
+    SEQUENCE NAME public.recipe_revisions_id_seq
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1
+);
+
+
+--
+-- Name: recipe_translations; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.recipe_translations (
+    recipe_id bigint NOT NULL,
+    locale text NOT NULL,
+    name text DEFAULT ''::text NOT NULL,
+    description text DEFAULT ''::text NOT NULL
+);
+
+
 --
 -- Name: recipes; Type: TABLE; Schema: public; Owner: -
 --
@@ -86,7 +237,9 @@ CREATE TABLE public.recipes (
     created_by bigint NOT NULL,
     source text,
     servings integer DEFAULT 1 NOT NULL,
-    servings_description text DEFAULT ''::text NOT NULL
+    servings_description text DEFAULT ''::text NOT NULL,
+    forked_from bigint,
+    language text DEFAULT 'de'::text NOT NULL
 );
 
 
@@ -113,6 +266,18 @@ CREATE TABLE public.schema_migrations (
 );
 
 
+--
+-- Name: sessions; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.sessions (
+    token_hash bytea NOT NULL,
+    user_id bigint NOT NULL,
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    expires_at timestamp with time zone NOT NULL
+);
+
+
 --
 -- Name: step_ingredients; Type: TABLE; Schema: public; Owner: -
 --
@@ -122,7 +287,19 @@ CREATE TABLE public.step_ingredients (
     ingredients_id bigint NOT NULL,
     unit_id bigint,
     amount numeric DEFAULT 0 NOT NULL,
-    note text DEFAULT ''::text NOT NULL
+    note text DEFAULT ''::text NOT NULL,
+    sort_order integer DEFAULT 0 NOT NULL
+);
+
+
+--
+-- Name: step_translations; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.step_translations (
+    step_id bigint NOT NULL,
+    locale text NOT NULL,
+    instruction text DEFAULT ''::text NOT NULL
 );
 
 
@@ -155,6 +332,17 @@ ALTER TABLE public.steps ALTER COLUMN id ADD GENERATED BY DEFAULT AS IDENTITY (
 );
 
 
+--
+-- Name: unit_translations; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.unit_translations (
+    unit_id bigint NOT NULL,
+    locale text NOT NULL,
+    name text NOT NULL
+);
+
+
 --
 -- Name: units; Type: TABLE; Schema: public; Owner: -
 --
@@ -179,6 +367,32 @@ ALTER TABLE public.units ALTER COLUMN id ADD GENERATED BY DEFAULT AS IDENTITY (
 );
 
 
+--
+-- Name: user_identities; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.user_identities (
+    issuer text NOT NULL,
+    subject text NOT NULL,
+    user_id bigint NOT NULL,
+    created_at timestamp with time zone DEFAULT now() NOT NULL
+);
+
+
+--
+-- Name: user_tokens; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.user_tokens (
+    token_hash bytea NOT NULL,
+    user_id bigint NOT NULL,
+    purpose text NOT NULL,
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    expires_at timestamp with time zone NOT NULL,
+    CONSTRAINT user_tokens_purpose_check CHECK ((purpose = ANY (ARRAY['verify-email'::text, 'reset-password'::text])))
+);
+
+
 --
 -- Name: users; Type: TABLE; Schema: public; Owner: -
 --
@@ -190,7 +404,8 @@ CREATE TABLE public.users (
     password_hash text NOT NULL,
     password_hash_algorithm text NOT NULL,
     is_activated boolean DEFAULT false NOT NULL,
-    is_superuser boolean DEFAULT false NOT NULL
+    is_superuser boolean DEFAULT false NOT NULL,
+    locale text
 );
 
 
@@ -208,6 +423,22 @@ ALTER TABLE public.users ALTER COLUMN id ADD GENERATED BY DEFAULT AS IDENTITY (
 );
 
 
+--
+-- Name: api_tokens api_tokens_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.api_tokens
+    ADD CONSTRAINT api_tokens_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: api_tokens api_tokens_token_hash_key; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.api_tokens
+    ADD CONSTRAINT api_tokens_token_hash_key UNIQUE (token_hash);
+
+
 --
 -- Name: household_users household_users_pkey; Type: CONSTRAINT; Schema: public; Owner: -
 --
@@ -224,6 +455,30 @@ ALTER TABLE ONLY public.households
     ADD CONSTRAINT households_pkey PRIMARY KEY (id);
 
 
+--
+-- Name: images images_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.images
+    ADD CONSTRAINT images_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: images images_storage_key_key; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.images
+    ADD CONSTRAINT images_storage_key_key UNIQUE (storage_key);
+
+
+--
+-- Name: ingredient_translations ingredient_translations_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.ingredient_translations
+    ADD CONSTRAINT ingredient_translations_pkey PRIMARY KEY (ingredient_id, locale);
+
+
 --
 -- Name: ingredients ingredients_name_key; Type: CONSTRAINT; Schema: public; Owner: -
 --
@@ -240,6 +495,22 @@ ALTER TABLE ONLY public.ingredients
     ADD CONSTRAINT ingredients_pkey PRIMARY KEY (id);
 
 
+--
+-- Name: recipe_revisions recipe_revisions_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.recipe_revisions
+    ADD CONSTRAINT recipe_revisions_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: recipe_translations recipe_translations_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.recipe_translations
+    ADD CONSTRAINT recipe_translations_pkey PRIMARY KEY (recipe_id, locale);
+
+
 --
 -- Name: recipes recipes_name_key; Type: CONSTRAINT; Schema: public; Owner: -
 --
@@ -264,6 +535,14 @@ ALTER TABLE ONLY public.schema_migrations
     ADD CONSTRAINT schema_migrations_pkey PRIMARY KEY (version);
 
 
+--
+-- Name: sessions sessions_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.sessions
+    ADD CONSTRAINT sessions_pkey PRIMARY KEY (token_hash);
+
+
 --
 -- Name: step_ingredients step_ingredient_uniqueness; Type: CONSTRAINT; Schema: public; Owner: -
 --
@@ -272,6 +551,14 @@ ALTER TABLE ONLY public.step_ingredients
     ADD CONSTRAINT step_ingredient_uniqueness UNIQUE (ingredients_id, step_id);
 
 
+--
+-- Name: step_translations step_translations_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.step_translations
+    ADD CONSTRAINT step_translations_pkey PRIMARY KEY (step_id, locale);
+
+
 --
 -- Name: steps steps_pkey; Type: CONSTRAINT; Schema: public; Owner: -
 --
@@ -280,6 +567,14 @@ ALTER TABLE ONLY public.steps
     ADD CONSTRAINT steps_pkey PRIMARY KEY (id);
 
 
+--
+-- Name: unit_translations unit_translations_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.unit_translations
+    ADD CONSTRAINT unit_translations_pkey PRIMARY KEY (unit_id, locale);
+
+
 --
 -- Name: units units_name_key; Type: CONSTRAINT; Schema: public; Owner: -
 --
@@ -296,6 +591,22 @@ ALTER TABLE ONLY public.units
     ADD CONSTRAINT units_pkey PRIMARY KEY (id);
 
 
+--
+-- Name: user_identities user_identities_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.user_identities
+    ADD CONSTRAINT user_identities_pkey PRIMARY KEY (issuer, subject);
+
+
+--
+-- Name: user_tokens user_tokens_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.user_tokens
+    ADD CONSTRAINT user_tokens_pkey PRIMARY KEY (token_hash);
+
+
 --
 -- Name: users users_email_key; Type: CONSTRAINT; Schema: public; Owner: -
 --
@@ -312,6 +623,63 @@ ALTER TABLE ONLY public.users
     ADD CONSTRAINT users_pkey PRIMARY KEY (id);
 
 
+--
+-- Name: api_tokens_user_id_idx; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX api_tokens_user_id_idx ON public.api_tokens USING btree (user_id);
+
+
+--
+-- Name: images_recipe_id_idx; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX images_recipe_id_idx ON public.images USING btree (recipe_id);
+
+
+--
+-- Name: recipe_revisions_recipe_id_idx; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX recipe_revisions_recipe_id_idx ON public.recipe_revisions USING btree (recipe_id);
+
+
+--
+-- Name: recipes_forked_from_idx; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX recipes_forked_from_idx ON public.recipes USING btree (forked_from);
+
+
+--
+-- Name: sessions_user_id_idx; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX sessions_user_id_idx ON public.sessions USING btree (user_id);
+
+
+--
+-- Name: user_identities_user_id_idx; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX user_identities_user_id_idx ON public.user_identities USING btree (user_id);
+
+
+--
+-- Name: user_tokens_user_id_idx; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX user_tokens_user_id_idx ON public.user_tokens USING btree (user_id);
+
+
+--
+-- Name: api_tokens api_tokens_user_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.api_tokens
+    ADD CONSTRAINT api_tokens_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE;
+
+
 --
 -- Name: household_users household_users_household_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
 --
@@ -328,6 +696,54 @@ ALTER TABLE ONLY public.household_users
     ADD CONSTRAINT household_users_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id);
 
 
+--
+-- Name: images images_recipe_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.images
+    ADD CONSTRAINT images_recipe_id_fkey FOREIGN KEY (recipe_id) REFERENCES public.recipes(id) ON DELETE CASCADE;
+
+
+--
+-- Name: images images_step_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.images
+    ADD CONSTRAINT images_step_id_fkey FOREIGN KEY (step_id) REFERENCES public.steps(id) ON DELETE CASCADE;
+
+
+--
+-- Name: ingredient_translations ingredient_translations_ingredient_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.ingredient_translations
+    ADD CONSTRAINT ingredient_translations_ingredient_id_fkey FOREIGN KEY (ingredient_id) REFERENCES public.ingredients(id) ON DELETE CASCADE;
+
+
+--
+-- Name: recipe_revisions recipe_revisions_created_by_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.recipe_revisions
+    ADD CONSTRAINT recipe_revisions_created_by_fkey FOREIGN KEY (created_by) REFERENCES public.users(id) ON DELETE SET NULL;
+
+
+--
+-- Name: recipe_revisions recipe_revisions_recipe_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.recipe_revisions
+    ADD CONSTRAINT recipe_revisions_recipe_id_fkey FOREIGN KEY (recipe_id) REFERENCES public.recipes(id) ON DELETE CASCADE;
+
+
+--
+-- Name: recipe_translations recipe_translations_recipe_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.recipe_translations
+    ADD CONSTRAINT recipe_translations_recipe_id_fkey FOREIGN KEY (recipe_id) REFERENCES public.recipes(id) ON DELETE CASCADE;
+
+
 --
 -- Name: recipes recipes_created_by_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
 --
@@ -336,6 +752,22 @@ ALTER TABLE ONLY public.recipes
     ADD CONSTRAINT recipes_created_by_fkey FOREIGN KEY (created_by) REFERENCES public.users(id) ON DELETE CASCADE;
 
 
+--
+-- Name: recipes recipes_forked_from_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.recipes
+    ADD CONSTRAINT recipes_forked_from_fkey FOREIGN KEY (forked_from) REFERENCES public.recipes(id) ON DELETE SET NULL;
+
+
+--
+-- Name: sessions sessions_user_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.sessions
+    ADD CONSTRAINT sessions_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE;
+
+
 --
 -- Name: step_ingredients step_ingredients_ingredients_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
 --
@@ -360,6 +792,14 @@ ALTER TABLE ONLY public.step_ingredients
     ADD CONSTRAINT step_ingredients_unit_id_fkey FOREIGN KEY (unit_id) REFERENCES public.units(id) ON DELETE RESTRICT;
 
 
+--
+-- Name: step_translations step_translations_step_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.step_translations
+    ADD CONSTRAINT step_translations_step_id_fkey FOREIGN KEY (step_id) REFERENCES public.steps(id) ON DELETE CASCADE;
+
+
 --
 -- Name: steps steps_recipe_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
 --
@@ -368,6 +808,30 @@ ALTER TABLE ONLY public.steps
     ADD CONSTRAINT steps_recipe_id_fkey FOREIGN KEY (recipe_id) REFERENCES public.recipes(id) ON DELETE CASCADE;
 
 
+--
+-- Name: unit_translations unit_translations_unit_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.unit_translations
+    ADD CONSTRAINT unit_translations_unit_id_fkey FOREIGN KEY (unit_id) REFERENCES public.units(id) ON DELETE CASCADE;
+
+
+--
+-- Name: user_identities user_identities_user_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.user_identities
+    ADD CONSTRAINT user_identities_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE;
+
+
+--
+-- Name: user_tokens user_tokens_user_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.user_tokens
+    ADD CONSTRAINT user_tokens_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE;
+
+
 --
 -- PostgreSQL database dump complete
 --
@@ -382,4 +846,13 @@ INSERT INTO public.schema_migrations (version) VALUES
     ('20230215181821'),
     ('20230310215544'),
     ('20230320210429'),
-    ('20230320221857');
+    ('20230320221857'),
+    ('20261019090000'),
+    ('20261019091000'),
+    ('20261019092000'),
+    ('20261019093000'),
+    ('20261019094000'),
+    ('20261019095000'),
+    ('20261019096000'),
+    ('20261019097000'),
+    ('20261019098000');
@@ -0,0 +1,166 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"codeberg.org/mahlzeit/mahlzeit/db/queries"
+	"codeberg.org/mahlzeit/mahlzeit/internal/mail"
+	"codeberg.org/mahlzeit/mahlzeit/internal/password"
+	"github.com/carlmjohnson/resperr"
+	"github.com/jackc/pgx/v4"
+)
+
+// EmailVerificationDuration is the time in which new users have to verify their email address.
+const EmailVerificationDuration = 7 * 24 * time.Hour
+
+// PasswordResetDuration is the time in which the link to reset a password can be used.
+const PasswordResetDuration = time.Hour
+
+// The purposes of the tokens that are sent by email. They are also the names of the email templates
+// and the paths of the links in the emails.
+const (
+	purposeVerifyEmail   = "verify-email"
+	purposeResetPassword = "reset-password"
+)
+
+// userMail is the data of the email templates.
+type userMail struct {
+	Name    string
+	Link    string
+	Expires time.Time
+}
+
+// Register adds a new user who isn't activated yet. An email with a link to verify the email address
+// is sent to them, see [Application.VerifyEmail].
+func (app *Application) Register(ctx context.Context, params AddUserParams) (User, error) {
+	params.Activated = false
+	user, err := app.AddUser(ctx, params)
+	if err != nil {
+		return User{}, err
+	}
+
+	if err := app.sendUserToken(ctx, user, params.Email, purposeVerifyEmail, EmailVerificationDuration); err != nil {
+		return User{}, err
+	}
+	return user, nil
+}
+
+// VerifyEmail activates the user of a token that was sent by [Application.Register].
+func (app *Application) VerifyEmail(ctx context.Context, token string) error {
+	return app.inTx(ctx, func(q *queries.Queries) error {
+		userID, err := consumeUserToken(ctx, q, token, purposeVerifyEmail)
+		if err != nil {
+			return err
+		}
+
+		if err := q.ActivateUser(ctx, userID); err != nil {
+			return fmt.Errorf("activating user %d: %w", userID, err)
+		}
+		return nil
+	})
+}
+
+// RequestPasswordReset sends an email with a link to reset the password, see [Application.ResetPassword].
+// To not reveal which email addresses are registered, unknown addresses are ignored without an error.
+func (app *Application) RequestPasswordReset(ctx context.Context, email string) error {
+	u, err := app.Queries.GetUserByEmail(ctx, normalizeEmail(email))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("querying user by email: %w", err)
+	}
+
+	return app.sendUserToken(ctx, User{ID: int(u.ID), Name: u.Name}, email, purposeResetPassword, PasswordResetDuration)
+}
+
+// ResetPassword sets a new password for the user of a token that was sent by [Application.RequestPasswordReset].
+// All sessions of the user are ended, in case the old password was compromised.
+func (app *Application) ResetPassword(ctx context.Context, token, pw string) error {
+	hash, err := hashPassword(pw)
+	if err != nil {
+		return err
+	}
+
+	return app.inTx(ctx, func(q *queries.Queries) error {
+		userID, err := consumeUserToken(ctx, q, token, purposeResetPassword)
+		if err != nil {
+			return err
+		}
+
+		if err := q.SetUserPassword(ctx, queries.SetUserPasswordParams{
+			PasswordHash:          hash,
+			PasswordHashAlgorithm: password.Algorithm,
+			ID:                    userID,
+		}); err != nil {
+			return fmt.Errorf("setting password of user %d: %w", userID, err)
+		}
+		if err := q.DeleteUserTokens(ctx, queries.DeleteUserTokensParams{UserID: userID, Purpose: purposeResetPassword}); err != nil {
+			return fmt.Errorf("deleting password reset tokens of user %d: %w", userID, err)
+		}
+		if err := q.DeleteUserSessions(ctx, userID); err != nil {
+			return fmt.Errorf("deleting sessions of user %d: %w", userID, err)
+		}
+		return nil
+	})
+}
+
+// sendUserToken stores a new token for the purpose and sends the link with it to the user.
+func (app *Application) sendUserToken(ctx context.Context, user User, email, purpose string, validFor time.Duration) error {
+	token, err := newToken()
+	if err != nil {
+		return err
+	}
+	expires := time.Now().Add(validFor)
+
+	err = app.inTx(ctx, func(q *queries.Queries) error {
+		// Like sessions, expired tokens are removed whenever a new one is created.
+		if err := q.DeleteExpiredUserTokens(ctx); err != nil {
+			return fmt.Errorf("deleting expired tokens: %w", err)
+		}
+
+		if err := q.AddUserToken(ctx, queries.AddUserTokenParams{
+			TokenHash: hashToken(token),
+			UserID:    int64(user.ID),
+			Purpose:   purpose,
+			ExpiresAt: expires,
+		}); err != nil {
+			return fmt.Errorf("adding %s token for user %d: %w", purpose, user.ID, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	subject, body, err := app.Templates.RenderMail(ctx, purpose+".tmpl", userMail{
+		Name:    user.Name,
+		Link:    app.BaseURL + "/" + purpose + "?token=" + url.QueryEscape(token),
+		Expires: expires,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = app.Mail.Send(ctx, mail.Message{To: normalizeEmail(email), Subject: subject, Body: body})
+	if err != nil {
+		return fmt.Errorf("sending %s email to user %d: %w", purpose, user.ID, err)
+	}
+	return nil
+}
+
+// consumeUserToken returns the user of the token and invalidates it.
+func consumeUserToken(ctx context.Context, q *queries.Queries, token, purpose string) (int64, error) {
+	userID, err := q.ConsumeUserToken(ctx, queries.ConsumeUserTokenParams{TokenHash: hashToken(token), Purpose: purpose})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, resperr.WithUserMessage(err, "the link is invalid or has expired")
+	}
+	if err != nil {
+		return 0, fmt.Errorf("consuming %s token: %w", purpose, err)
+	}
+	return userID, nil
+}
@@ -0,0 +1,94 @@
+package app
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+	"testing"
+
+	"codeberg.org/mahlzeit/mahlzeit/internal/i18n"
+	"codeberg.org/mahlzeit/mahlzeit/internal/testhelper"
+	"github.com/alecthomas/assert/v2"
+	"github.com/carlmjohnson/resperr"
+)
+
+var linkPattern = regexp.MustCompile(`https://mahlzeit\.test/\S+`)
+
+// tokenFromMail returns the token of the link in the email and ensures that it points to path.
+func tokenFromMail(t *testing.T, body, path string) string {
+	t.Helper()
+
+	link, err := url.Parse(linkPattern.FindString(body))
+	assert.NoError(t, err)
+	assert.Equal(t, path, link.Path)
+	return link.Query().Get("token")
+}
+
+func TestApplication_Register(t *testing.T) {
+	t.Parallel()
+	app, ctx := newApp(t), testhelper.Context(t)
+	ctx = i18n.WithLocalizer(ctx, i18n.New("en"))
+
+	email := testhelper.RandomString(10) + "@example.com"
+	_, err := app.Register(ctx, AddUserParams{Name: "Alice", Email: email, Password: "password"})
+	assert.NoError(t, err)
+
+	// Until the email address is verified, the user can't log in.
+	_, err = app.Authenticate(ctx, email, "password")
+	assert.Equal(t, http.StatusForbidden, resperr.StatusCode(err))
+
+	msg := app.mail.LastTo(t, email)
+	assert.Equal(t, "Verify your email address", msg.Subject)
+	assert.Contains(t, msg.Body, "Hello Alice,")
+	token := tokenFromMail(t, msg.Body, "/verify-email")
+
+	assert.Equal(t, http.StatusBadRequest, resperr.StatusCode(app.VerifyEmail(ctx, "invalid")))
+	assert.NoError(t, app.VerifyEmail(ctx, token))
+	_, err = app.Authenticate(ctx, email, "password")
+	assert.NoError(t, err)
+
+	// The token can only be used once.
+	assert.Equal(t, http.StatusBadRequest, resperr.StatusCode(app.VerifyEmail(ctx, token)))
+}
+
+func TestApplication_ResetPassword(t *testing.T) {
+	t.Parallel()
+	app, ctx := newApp(t), testhelper.Context(t)
+	ctx = i18n.WithLocalizer(ctx, i18n.New("de"))
+
+	user, email := app.AddTestUser(ctx)
+	session, _, err := app.CreateSession(ctx, user.ID)
+	assert.NoError(t, err)
+
+	t.Run("unknown email address", func(t *testing.T) {
+		assert.NoError(t, app.RequestPasswordReset(ctx, "unknown-"+email))
+	})
+
+	assert.NoError(t, app.RequestPasswordReset(ctx, email))
+	msg := app.mail.LastTo(t, email)
+	assert.Equal(t, "Setze dein Passwort zurück", msg.Subject)
+	token := tokenFromMail(t, msg.Body, "/reset-password")
+
+	t.Run("password too short", func(t *testing.T) {
+		err := app.ResetPassword(ctx, token, "short")
+		assert.Equal(t, http.StatusBadRequest, resperr.StatusCode(err))
+	})
+
+	t.Run("invalid token", func(t *testing.T) {
+		err := app.ResetPassword(ctx, "invalid", "new password")
+		assert.Equal(t, http.StatusBadRequest, resperr.StatusCode(err))
+	})
+
+	assert.NoError(t, app.ResetPassword(ctx, token, "new password"))
+
+	_, err = app.Authenticate(ctx, email, "password")
+	assert.Equal(t, http.StatusUnauthorized, resperr.StatusCode(err))
+	_, err = app.Authenticate(ctx, email, "new password")
+	assert.NoError(t, err)
+
+	// Existing sessions are ended and the token can't be used again.
+	_, err = app.GetSessionUser(ctx, session)
+	assert.Equal(t, http.StatusUnauthorized, resperr.StatusCode(err))
+	err = app.ResetPassword(ctx, token, "another password")
+	assert.Equal(t, http.StatusBadRequest, resperr.StatusCode(err))
+}
@@ -0,0 +1,160 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"codeberg.org/mahlzeit/mahlzeit/db/queries"
+	"github.com/carlmjohnson/resperr"
+	"github.com/jackc/pgx/v4"
+)
+
+// APITokenPrefix is the prefix of all API tokens. It makes them recognizable, e.g. for secret scanners.
+const APITokenPrefix = "mzt_"
+
+// TokenScope determines which requests an API token may perform.
+type TokenScope string
+
+const (
+	ScopeRead  TokenScope = "read"  // only requests that don't change anything
+	ScopeWrite TokenScope = "write" // all requests
+)
+
+// APITokenScopes contains all valid scopes.
+var APITokenScopes = []TokenScope{ScopeRead, ScopeWrite}
+
+// APIToken is a personal token of a user for the API. The token itself is only known once it's created.
+type APIToken struct {
+	ID         int
+	Name       string
+	Scope      TokenScope
+	CreatedAt  time.Time
+	ExpiresAt  time.Time // zero if the token never expires
+	LastUsedAt time.Time // zero if the token was never used
+}
+
+// Expired reports whether the token can't be used anymore.
+func (t APIToken) Expired() bool {
+	return !t.ExpiresAt.IsZero() && !t.ExpiresAt.After(time.Now())
+}
+
+type CreateAPITokenParams struct {
+	Name      string
+	Scope     TokenScope
+	ExpiresAt time.Time // optional
+}
+
+// CreateAPIToken creates a new API token for the current user, see [WithUserID]. The returned string is the
+// token itself, which can't be retrieved later.
+func (app *Application) CreateAPIToken(ctx context.Context, params CreateAPITokenParams) (string, APIToken, error) {
+	userID := UserIDFromContext(ctx)
+	if userID == 0 {
+		return "", APIToken{}, resperr.WithCodeAndMessage(nil, http.StatusUnauthorized, "API tokens can only be created by users")
+	}
+
+	name := strings.TrimSpace(params.Name)
+	if name == "" {
+		return "", APIToken{}, resperr.WithUserMessage(nil, "the name of an API token must not be empty")
+	}
+	if params.Scope != ScopeRead && params.Scope != ScopeWrite {
+		return "", APIToken{}, resperr.WithUserMessagef(nil, "unknown scope %q", params.Scope)
+	}
+	if !params.ExpiresAt.IsZero() && params.ExpiresAt.Before(time.Now()) {
+		return "", APIToken{}, resperr.WithUserMessage(nil, "the expiry date must be in the future")
+	}
+
+	random, err := newToken()
+	if err != nil {
+		return "", APIToken{}, err
+	}
+	token := APITokenPrefix + random
+
+	row, err := app.Queries.AddAPIToken(ctx, queries.AddAPITokenParams{
+		UserID:    int64(userID),
+		Name:      name,
+		TokenHash: hashToken(token),
+		Scope:     string(params.Scope),
+		ExpiresAt: sql.NullTime{Time: params.ExpiresAt, Valid: !params.ExpiresAt.IsZero()},
+	})
+	if err != nil {
+		return "", APIToken{}, fmt.Errorf("adding API token for user %d: %w", userID, err)
+	}
+
+	return token, APIToken{
+		ID:        int(row.ID),
+		Name:      name,
+		Scope:     params.Scope,
+		CreatedAt: row.CreatedAt,
+		ExpiresAt: params.ExpiresAt,
+	}, nil
+}
+
+// GetAPITokens returns all API tokens of the current user, see [WithUserID], starting with the newest one.
+func (app *Application) GetAPITokens(ctx context.Context) ([]APIToken, error) {
+	userID := UserIDFromContext(ctx)
+	if userID == 0 {
+		return nil, resperr.WithCodeAndMessage(nil, http.StatusUnauthorized, "only users have API tokens")
+	}
+
+	tokens, err := app.Queries.GetAPITokensForUser(ctx, int64(userID))
+	if err != nil {
+		return nil, fmt.Errorf("querying API tokens of user %d: %w", userID, err)
+	}
+
+	var res []APIToken
+	for _, t := range tokens {
+		res = append(res, APIToken{
+			ID:         int(t.ID),
+			Name:       t.Name,
+			Scope:      TokenScope(t.Scope),
+			CreatedAt:  t.CreatedAt,
+			ExpiresAt:  t.ExpiresAt.Time,
+			LastUsedAt: t.LastUsedAt.Time,
+		})
+	}
+	return res, nil
+}
+
+// DeleteAPIToken revokes an API token of the current user, see [WithUserID].
+// If the user has no token with the ID, a not found error is returned.
+func (app *Application) DeleteAPIToken(ctx context.Context, id int) error {
+	userID := UserIDFromContext(ctx)
+	n, err := app.Queries.DeleteAPIToken(ctx, queries.DeleteAPITokenParams{
+		ID:     int64(id),
+		UserID: int64(userID),
+	})
+	if err != nil {
+		return fmt.Errorf("deleting API token %d: %w", id, err)
+	}
+	if n == 0 {
+		return resperr.WithCodeAndMessage(nil, http.StatusNotFound, "API token does not exist")
+	}
+	return nil
+}
+
+// AuthenticateAPIToken returns the user and scope of an API token and records its use.
+// Unknown and expired tokens result in an unauthorized error.
+func (app *Application) AuthenticateAPIToken(ctx context.Context, token string) (userID int, scope TokenScope, err error) {
+	if !strings.HasPrefix(token, APITokenPrefix) {
+		return 0, "", resperr.WithCodeAndMessage(nil, http.StatusUnauthorized, "invalid API token")
+	}
+
+	t, err := app.Queries.GetAPITokenByHash(ctx, hashToken(token))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, "", resperr.WithCodeAndMessage(err, http.StatusUnauthorized, "invalid or expired API token")
+		}
+		return 0, "", fmt.Errorf("querying API token: %w", err)
+	}
+
+	if err := app.Queries.TouchAPIToken(ctx, t.ID); err != nil {
+		return 0, "", fmt.Errorf("recording use of API token %d: %w", t.ID, err)
+	}
+
+	return int(t.UserID), TokenScope(t.Scope), nil
+}
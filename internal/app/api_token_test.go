@@ -0,0 +1,66 @@
+package app
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"codeberg.org/mahlzeit/mahlzeit/internal/testhelper"
+	"github.com/alecthomas/assert/v2"
+	"github.com/carlmjohnson/resperr"
+)
+
+func TestApplication_APITokens(t *testing.T) {
+	t.Parallel()
+	app := newApp(t)
+
+	user, _ := app.AddTestUser(testhelper.Context(t))
+	ctx := WithUserID(testhelper.Context(t), user.ID)
+
+	token, created, err := app.CreateAPIToken(ctx, CreateAPITokenParams{Name: "script", Scope: ScopeRead})
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(token, APITokenPrefix))
+
+	t.Run("authenticate", func(t *testing.T) {
+		userID, scope, err := app.AuthenticateAPIToken(ctx, token)
+		assert.NoError(t, err)
+		assert.Equal(t, user.ID, userID)
+		assert.Equal(t, ScopeRead, scope)
+
+		tokens, err := app.GetAPITokens(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(tokens))
+		assert.Equal(t, created.ID, tokens[0].ID)
+		assert.False(t, tokens[0].LastUsedAt.IsZero(), "the use should be recorded")
+	})
+
+	t.Run("unknown token", func(t *testing.T) {
+		_, _, err := app.AuthenticateAPIToken(ctx, APITokenPrefix+"unknown")
+		assert.Equal(t, http.StatusUnauthorized, resperr.StatusCode(err))
+	})
+
+	t.Run("invalid parameters", func(t *testing.T) {
+		_, _, err := app.CreateAPIToken(ctx, CreateAPITokenParams{Name: "script", Scope: "admin"})
+		assert.Equal(t, http.StatusBadRequest, resperr.StatusCode(err))
+
+		_, _, err = app.CreateAPIToken(ctx, CreateAPITokenParams{
+			Name:      "script",
+			Scope:     ScopeWrite,
+			ExpiresAt: time.Now().Add(-time.Hour),
+		})
+		assert.Equal(t, http.StatusBadRequest, resperr.StatusCode(err))
+	})
+
+	t.Run("tokens of other users can't be deleted", func(t *testing.T) {
+		other, _ := app.AddTestUser(ctx)
+		err := app.DeleteAPIToken(WithUserID(ctx, other.ID), created.ID)
+		assert.Equal(t, http.StatusNotFound, resperr.StatusCode(err))
+	})
+
+	t.Run("deleted tokens are invalid", func(t *testing.T) {
+		assert.NoError(t, app.DeleteAPIToken(ctx, created.ID))
+		_, _, err := app.AuthenticateAPIToken(ctx, token)
+		assert.Equal(t, http.StatusUnauthorized, resperr.StatusCode(err))
+	})
+}
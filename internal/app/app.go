@@ -1,8 +1,17 @@
 package app
 
 import (
+	"context"
+
 	"codeberg.org/mahlzeit/mahlzeit/db/queries"
+	"codeberg.org/mahlzeit/mahlzeit/internal/blob"
+	"codeberg.org/mahlzeit/mahlzeit/internal/mail"
+	"codeberg.org/mahlzeit/mahlzeit/internal/metrics"
+	"codeberg.org/mahlzeit/mahlzeit/internal/oidc"
 	"codeberg.org/mahlzeit/mahlzeit/internal/templates"
+	"codeberg.org/mahlzeit/mahlzeit/internal/zaphelper"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
 	"go.uber.org/zap"
 )
 
@@ -11,15 +20,23 @@ import (
 type Application struct {
 	Templates templates.Templates
 	Queries   *queries.Queries
+	DB        *pgxpool.Pool // only required for transactions, all other queries should use Queries
 	Logger    *zap.Logger
+	LogLevels *zaphelper.Levels // nil if the levels of Logger can't be changed at runtime
+	Blobs     blob.Storage
+	OIDC      *oidc.Provider // nil if the login with an identity provider is disabled
+	Mail      mail.Sender
+	Metrics   *metrics.Metrics // nil if the metrics are disabled
+
+	// BaseURL is the public URL of the application without a trailing slash, e.g. "https://mahlzeit.example.com".
+	// It's used for links in emails.
+	BaseURL string
 }
 
-type Configuration struct {
-	Database struct {
-		ConnectionString string `toml:"connection-string"`
-	} `toml:"database"`
-	Web struct {
-		Endpoint    string `toml:"endpoint"`
-		TemplateDir string `toml:"template-dir"`
-	}
+// inTx executes fn inside a database transaction. If fn returns an error, the transaction is rolled back,
+// otherwise it's committed.
+func (app *Application) inTx(ctx context.Context, fn func(q *queries.Queries) error) error {
+	return app.DB.BeginFunc(ctx, func(tx pgx.Tx) error {
+		return fn(app.Queries.WithTx(tx))
+	})
 }
@@ -2,21 +2,26 @@ package app
 
 import (
 	"context"
+	"sync"
 	"testing"
 	"time"
 
 	"codeberg.org/mahlzeit/mahlzeit/db/queries"
+	"codeberg.org/mahlzeit/mahlzeit/internal/blob"
+	"codeberg.org/mahlzeit/mahlzeit/internal/i18n"
+	"codeberg.org/mahlzeit/mahlzeit/internal/mail"
 	"codeberg.org/mahlzeit/mahlzeit/internal/pghelper"
+	"codeberg.org/mahlzeit/mahlzeit/internal/templates"
 	"codeberg.org/mahlzeit/mahlzeit/internal/testhelper"
+	"codeberg.org/mahlzeit/mahlzeit/web"
 	"github.com/alecthomas/assert/v2"
-	"github.com/jackc/pgx/v4/pgxpool"
 	"go.uber.org/zap/zaptest"
 )
 
 type testApplication struct {
-	t *testing.T
+	t    *testing.T
+	mail *testMailer
 	*Application
-	DB *pgxpool.Pool
 }
 
 // newApp returns a new application that logs to t and has a database attached to it.
@@ -24,16 +29,53 @@ func newApp(t *testing.T) testApplication {
 	t.Helper()
 
 	db := testhelper.Database(t)
+	blobs, err := blob.NewFilesystem(t.TempDir())
+	assert.NoError(t, err)
+
+	mailer := &testMailer{}
 	return testApplication{
-		t: t,
+		t:    t,
+		mail: mailer,
 		Application: &Application{
-			Queries: queries.New(db),
-			Logger:  zaptest.NewLogger(t),
+			Templates: templates.NewTemplates(web.Templates(), nil),
+			Queries:   queries.New(db),
+			DB:        db,
+			Logger:    zaptest.NewLogger(t),
+			Blobs:     blobs,
+			Mail:      mailer,
+			BaseURL:   "https://mahlzeit.test",
 		},
-		DB: db,
 	}
 }
 
+// testMailer records the emails instead of sending them.
+type testMailer struct {
+	mu       sync.Mutex
+	messages []mail.Message
+}
+
+func (m *testMailer) Send(_ context.Context, msg mail.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = append(m.messages, msg)
+	return nil
+}
+
+// LastTo returns the last email that was sent to the address.
+func (m *testMailer) LastTo(t *testing.T, to string) mail.Message {
+	t.Helper()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := len(m.messages) - 1; i >= 0; i-- {
+		if m.messages[i].To == to {
+			return m.messages[i]
+		}
+	}
+	t.Fatalf("no email was sent to %s", to)
+	return mail.Message{}
+}
+
 // AddEmptyRecipe adds a new empty recipe to the database.
 func (app *testApplication) AddEmptyRecipe(ctx context.Context) Recipe {
 	app.t.Helper()
@@ -48,6 +90,7 @@ func (app *testApplication) AddEmptyRecipe(ctx context.Context) Recipe {
 		WaitingTime: pghelper.Interval(time.Minute * 10),
 		WorkingTime: pghelper.Interval(time.Minute * 10),
 		CreatedBy:   userID,
+		Language:    i18n.DefaultLocale,
 	}
 	recipe, err := app.Queries.AddRecipe(ctx, params)
 	assert.NoError(app.t, err)
@@ -55,6 +98,8 @@ func (app *testApplication) AddEmptyRecipe(ctx context.Context) Recipe {
 	return Recipe{
 		ID:           int(recipe.ID),
 		Name:         params.Name,
+		Description:  params.Description,
+		Language:     params.Language,
 		CreatedAt:    time.Now(),
 		UpdatedAt:    time.Now(),
 		BaseServings: 2,
@@ -74,3 +119,19 @@ func (app *testApplication) AddTestStep(ctx context.Context, recipeID int) Step
 
 	return *step
 }
+
+// AddTestUser adds a new activated user with the password "password".
+func (app *testApplication) AddTestUser(ctx context.Context) (User, string) {
+	app.t.Helper()
+
+	email := testhelper.RandomString(10) + "@example.com"
+	user, err := app.AddUser(ctx, AddUserParams{
+		Name:      app.t.Name(),
+		Email:     email,
+		Password:  "password",
+		Activated: true,
+	})
+	assert.NoError(app.t, err)
+
+	return user, email
+}
@@ -0,0 +1,179 @@
+package app
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"codeberg.org/mahlzeit/mahlzeit/db/queries"
+	"codeberg.org/mahlzeit/mahlzeit/internal/password"
+	"github.com/carlmjohnson/resperr"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+)
+
+// SessionDuration is the time after which users have to log in again.
+const SessionDuration = 30 * 24 * time.Hour
+
+// MinPasswordLength is the minimum number of characters of a password.
+const MinPasswordLength = 8
+
+type AddUserParams struct {
+	Name      string
+	Email     string
+	Password  string
+	Activated bool // only activated users can log in
+}
+
+// AddUser adds a new user with a password. The email address must be unique.
+func (app *Application) AddUser(ctx context.Context, params AddUserParams) (User, error) {
+	name := strings.TrimSpace(params.Name)
+	email := normalizeEmail(params.Email)
+	if name == "" {
+		return User{}, resperr.WithUserMessage(nil, "the name of a user must not be empty")
+	}
+	if !strings.Contains(email, "@") {
+		return User{}, resperr.WithUserMessagef(nil, "invalid email address %q", params.Email)
+	}
+	hash, err := hashPassword(params.Password)
+	if err != nil {
+		return User{}, err
+	}
+
+	id, err := app.Queries.AddUser(ctx, queries.AddUserParams{
+		Name:                  name,
+		Email:                 email,
+		PasswordHash:          hash,
+		PasswordHashAlgorithm: password.Algorithm,
+		IsActivated:           params.Activated,
+	})
+	if err != nil {
+		var pgerr *pgconn.PgError
+		if errors.As(err, &pgerr) && pgerr.ConstraintName == "users_email_key" {
+			return User{}, resperr.WithCodeAndMessage(err, http.StatusConflict, "another user with the same email address exists already")
+		}
+		return User{}, fmt.Errorf("adding user: %w", err)
+	}
+
+	return User{ID: int(id), Name: name}, nil
+}
+
+// Authenticate returns the user with the given email address and password. If they don't match, an
+// unauthorized error is returned, which doesn't reveal whether the user exists. Users who are not
+// activated yet get a forbidden error.
+func (app *Application) Authenticate(ctx context.Context, email, pw string) (User, error) {
+	invalid := resperr.WithCodeAndMessage(nil, http.StatusUnauthorized, "invalid email address or password")
+
+	u, err := app.Queries.GetUserByEmail(ctx, normalizeEmail(email))
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return User{}, fmt.Errorf("querying user by email: %w", err)
+	}
+	if errors.Is(err, pgx.ErrNoRows) || u.PasswordHashAlgorithm != password.Algorithm {
+		// Users without a password, e.g. the demo user, can't log in with one.
+		return User{}, invalid
+	}
+
+	ok, err := password.Verify(pw, u.PasswordHash)
+	if err != nil {
+		return User{}, fmt.Errorf("verifying password of user %d: %w", u.ID, err)
+	}
+	if !ok {
+		return User{}, invalid
+	}
+	if !u.IsActivated {
+		return User{}, resperr.WithCodeAndMessage(nil, http.StatusForbidden, "the account is not activated yet")
+	}
+
+	return User{ID: int(u.ID), Name: u.Name}, nil
+}
+
+// CreateSession starts a new session for a user. The returned token identifies the session, only its hash is stored.
+func (app *Application) CreateSession(ctx context.Context, userID int) (token string, expires time.Time, err error) {
+	token, err = newToken()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	expires = time.Now().Add(SessionDuration)
+
+	err = app.inTx(ctx, func(q *queries.Queries) error {
+		// There is no background job yet, so expired sessions are removed whenever a new one is created.
+		if err := q.DeleteExpiredSessions(ctx); err != nil {
+			return fmt.Errorf("deleting expired sessions: %w", err)
+		}
+
+		if err := q.AddSession(ctx, queries.AddSessionParams{
+			TokenHash: hashToken(token),
+			UserID:    int64(userID),
+			ExpiresAt: expires,
+		}); err != nil {
+			return fmt.Errorf("adding session for user %d: %w", userID, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return token, expires, nil
+}
+
+// GetSessionUser returns the user of a session. If the session does not exist or is expired,
+// an unauthorized error is returned.
+func (app *Application) GetSessionUser(ctx context.Context, token string) (User, error) {
+	u, err := app.Queries.GetSessionUser(ctx, hashToken(token))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return User{}, resperr.WithCodeAndMessage(err, http.StatusUnauthorized, "the session is expired")
+		}
+		return User{}, fmt.Errorf("querying user of session: %w", err)
+	}
+
+	return User{ID: int(u.ID), Name: u.Name}, nil
+}
+
+// DeleteSession ends a session. This action is idempotent, if the session does not exist, no error is returned.
+func (app *Application) DeleteSession(ctx context.Context, token string) error {
+	if err := app.Queries.DeleteSession(ctx, hashToken(token)); err != nil {
+		return fmt.Errorf("deleting session: %w", err)
+	}
+	return nil
+}
+
+// hashPassword returns the hash of a new password. Passwords that are too short are rejected.
+func hashPassword(pw string) (string, error) {
+	if len([]rune(pw)) < MinPasswordLength {
+		return "", resperr.WithUserMessagef(nil, "the password must have at least %d characters", MinPasswordLength)
+	}
+
+	hash, err := password.Hash(pw)
+	if err != nil {
+		return "", fmt.Errorf("hashing password: %w", err)
+	}
+	return hash, nil
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// newToken returns a random token with 256 bits of entropy.
+func newToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generating token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken returns the hash that is stored instead of the token itself. Unlike passwords, tokens
+// are random enough that a fast hash without a salt is sufficient.
+func hashToken(token string) []byte {
+	h := sha256.Sum256([]byte(token))
+	return h[:]
+}
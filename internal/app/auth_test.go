@@ -0,0 +1,67 @@
+package app
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"codeberg.org/mahlzeit/mahlzeit/internal/testhelper"
+	"github.com/alecthomas/assert/v2"
+	"github.com/carlmjohnson/resperr"
+)
+
+func TestApplication_Authenticate(t *testing.T) {
+	t.Parallel()
+	app, ctx := newApp(t), testhelper.Context(t)
+
+	user, email := app.AddTestUser(ctx)
+	_, err := app.AddUser(ctx, AddUserParams{
+		Name:     t.Name(),
+		Email:    "inactive-" + email,
+		Password: "password",
+	})
+	assert.NoError(t, err)
+
+	t.Run("valid credentials", func(t *testing.T) {
+		res, err := app.Authenticate(ctx, "  "+strings.ToUpper(email), "password")
+		assert.NoError(t, err)
+		assert.Equal(t, user, res)
+	})
+
+	t.Run("wrong password", func(t *testing.T) {
+		_, err := app.Authenticate(ctx, email, "wrong password")
+		assert.Equal(t, http.StatusUnauthorized, resperr.StatusCode(err))
+	})
+
+	t.Run("user is not activated", func(t *testing.T) {
+		_, err := app.Authenticate(ctx, "inactive-"+email, "password")
+		assert.Equal(t, http.StatusForbidden, resperr.StatusCode(err))
+	})
+
+	t.Run("unknown user", func(t *testing.T) {
+		_, err := app.Authenticate(ctx, "unknown@example.com", "password")
+		assert.Equal(t, http.StatusUnauthorized, resperr.StatusCode(err))
+	})
+
+	t.Run("duplicate email address", func(t *testing.T) {
+		_, err := app.AddUser(ctx, AddUserParams{Name: "other", Email: email, Password: "password"})
+		assert.Equal(t, http.StatusConflict, resperr.StatusCode(err))
+	})
+}
+
+func TestApplication_Sessions(t *testing.T) {
+	t.Parallel()
+	app, ctx := newApp(t), testhelper.Context(t)
+
+	user, _ := app.AddTestUser(ctx)
+	token, _, err := app.CreateSession(ctx, user.ID)
+	assert.NoError(t, err)
+
+	res, err := app.GetSessionUser(ctx, token)
+	assert.NoError(t, err)
+	assert.Equal(t, user, res)
+
+	assert.NoError(t, app.DeleteSession(ctx, token))
+	_, err = app.GetSessionUser(ctx, token)
+	assert.Equal(t, http.StatusUnauthorized, resperr.StatusCode(err))
+}
@@ -0,0 +1,173 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"time"
+
+	"codeberg.org/mahlzeit/mahlzeit/internal/blob"
+	"codeberg.org/mahlzeit/mahlzeit/internal/mail"
+	"codeberg.org/mahlzeit/mahlzeit/internal/oidc"
+	"codeberg.org/mahlzeit/mahlzeit/internal/tracing"
+	"codeberg.org/mahlzeit/mahlzeit/internal/zaphelper"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"go.uber.org/zap/zapcore"
+)
+
+// Configuration is loaded from the config.toml and the environment, see the package config.
+type Configuration struct {
+	Database struct {
+		ConnectionString string `toml:"connection-string" secret:"dsn"`
+		// AutoMigrate applies the pending migrations at startup. Multiple instances wait for each other.
+		AutoMigrate bool `toml:"auto-migrate"`
+	} `toml:"database"`
+	Web struct {
+		Endpoint string `toml:"endpoint"`
+		// Mode is either "production" (default) or "development". During the development, the assets are
+		// compiled by the development server of esbuild on every change, and the pages are reloaded.
+		Mode string `toml:"mode"`
+		// The templates and compiled assets are embedded. During the development, they can be loaded from
+		// these directories instead, so that changes are visible without a rebuild.
+		TemplateDir string `toml:"template-dir"`
+		AssetDir    string `toml:"asset-dir"`
+		BaseURL     string `toml:"base-url"`
+		// ShutdownTimeout limits how long the server waits for running requests to finish after SIGTERM or SIGINT.
+		ShutdownTimeout time.Duration `toml:"shutdown-timeout"`
+	} `toml:"web"`
+	Storage struct {
+		// Driver is either "filesystem" (default) or "s3".
+		Driver    string        `toml:"driver"`
+		Directory string        `toml:"directory"` // only used by the filesystem driver
+		S3        blob.S3Config `toml:"s3"`
+	} `toml:"storage"`
+	OIDC    oidc.Config      `toml:"oidc"`
+	Mail    mail.Config      `toml:"mail"`
+	Log     zaphelper.Config `toml:"log"`
+	Tracing tracing.Config   `toml:"tracing"`
+	Metrics struct {
+		// Endpoint is the address of the separate listener of /metrics, e.g. "127.0.0.1:9090", so that the
+		// metrics aren't public. The metrics are disabled if it's empty.
+		Endpoint string `toml:"endpoint"`
+	} `toml:"metrics"`
+}
+
+// DefaultConfiguration returns the configuration that is used for all values that aren't configured.
+func DefaultConfiguration() Configuration {
+	var cfg Configuration
+	cfg.Web.Endpoint = ":4000"
+	cfg.Web.Mode = "production"
+	// Container orchestrators usually kill the process 30 seconds after SIGTERM.
+	cfg.Web.ShutdownTimeout = 25 * time.Second
+	cfg.Storage.Driver = "filesystem"
+	cfg.Storage.Directory = "./data/blobs"
+	cfg.Mail.Driver = "log"
+	cfg.Log.Format = "console"
+	cfg.Log.Level = "info"
+	cfg.Tracing.SampleRatio = 1
+	return cfg
+}
+
+// Validate returns all invalid values of the configuration, which would otherwise only be detected once they're
+// used. It doesn't connect to any services, like the database.
+func (c Configuration) Validate() error {
+	var errs []error
+	if _, err := pgxpool.ParseConfig(c.Database.ConnectionString); err != nil {
+		errs = append(errs, fmt.Errorf("database.connection-string: %w", err))
+	}
+
+	if err := validateEndpoint(c.Web.Endpoint); err != nil {
+		errs = append(errs, fmt.Errorf("web.endpoint: %w", err))
+	}
+	switch c.Web.Mode {
+	case "", "production", "development":
+	default:
+		errs = append(errs, fmt.Errorf("web.mode: unknown mode %q, expected production or development", c.Web.Mode))
+	}
+	if c.Web.ShutdownTimeout < 0 {
+		errs = append(errs, fmt.Errorf("web.shutdown-timeout: must not be negative, got %s", c.Web.ShutdownTimeout))
+	}
+	if c.Web.BaseURL != "" {
+		if u, err := url.Parse(c.Web.BaseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("web.base-url: invalid URL %q, expected e.g. https://mahlzeit.example.com", c.Web.BaseURL))
+		}
+	}
+
+	switch c.Storage.Driver {
+	case "", "filesystem":
+	case "s3":
+		if _, err := blob.NewS3(c.Storage.S3); err != nil {
+			errs = append(errs, fmt.Errorf("storage.s3: %w", err))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("storage.driver: unknown driver %q, expected filesystem or s3", c.Storage.Driver))
+	}
+
+	switch c.Mail.Driver {
+	case "", "log":
+	case "smtp":
+		if _, err := mail.NewSMTP(c.Mail.SMTP); err != nil {
+			errs = append(errs, fmt.Errorf("mail.smtp: %w", err))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("mail.driver: unknown driver %q, expected log or smtp", c.Mail.Driver))
+	}
+
+	switch c.Log.Format {
+	case "", "console", "json":
+	default:
+		errs = append(errs, fmt.Errorf("log.format: unknown format %q, expected console or json", c.Log.Format))
+	}
+	if _, err := zapcore.ParseLevel(c.Log.Level); err != nil {
+		errs = append(errs, fmt.Errorf("log.level: %w", err))
+	}
+	for name, level := range c.Log.Levels {
+		if _, err := zapcore.ParseLevel(level); err != nil {
+			errs = append(errs, fmt.Errorf("log.levels.%s: %w", name, err))
+		}
+	}
+	if c.Log.MaxSize < 0 || c.Log.MaxBackups < 0 || c.Log.MaxAge < 0 {
+		errs = append(errs, errors.New("log: max-size, max-backups and max-age must not be negative"))
+	}
+
+	switch c.Tracing.Exporter {
+	case "", "none", "otlp":
+	default:
+		errs = append(errs, fmt.Errorf("tracing.exporter: unknown exporter %q, expected none or otlp", c.Tracing.Exporter))
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		errs = append(errs, fmt.Errorf("tracing.sample-ratio: invalid ratio %v, expected a number from 0 to 1", c.Tracing.SampleRatio))
+	}
+
+	if c.Metrics.Endpoint != "" {
+		if err := validateEndpoint(c.Metrics.Endpoint); err != nil {
+			errs = append(errs, fmt.Errorf("metrics.endpoint: %w", err))
+		} else if c.Metrics.Endpoint == c.Web.Endpoint {
+			errs = append(errs, errors.New("metrics.endpoint: must differ from web.endpoint"))
+		}
+	}
+
+	if c.OIDC.Enabled() {
+		if c.OIDC.ClientID == "" {
+			errs = append(errs, errors.New("oidc.client-id: required if the issuer is set"))
+		}
+		if c.OIDC.RedirectURL == "" {
+			errs = append(errs, errors.New("oidc.redirect-url: required if the issuer is set"))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// validateEndpoint ensures that the endpoint is an address to listen on, e.g. ":4000" or "127.0.0.1:4000".
+func validateEndpoint(endpoint string) error {
+	_, port, err := net.SplitHostPort(endpoint)
+	if err != nil {
+		return fmt.Errorf("invalid address %q, expected e.g. \":4000\"", endpoint)
+	}
+	if n, err := strconv.Atoi(port); err != nil || n < 0 || n > 65535 {
+		return fmt.Errorf("invalid port %q in address %q", port, endpoint)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alecthomas/assert/v2"
+)
+
+func TestConfiguration_Validate(t *testing.T) {
+	t.Parallel()
+
+	valid := func() Configuration {
+		cfg := DefaultConfiguration()
+		cfg.Database.ConnectionString = "postgres://localhost/mahlzeit"
+		return cfg
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(cfg *Configuration)
+		wantErr []string
+	}{
+		{name: "defaults", modify: func(cfg *Configuration) {}},
+		{
+			name:    "invalid connection string",
+			modify:  func(cfg *Configuration) { cfg.Database.ConnectionString = "postgres://localhost:port" },
+			wantErr: []string{"database.connection-string:"},
+		},
+		{
+			name:    "endpoint without port",
+			modify:  func(cfg *Configuration) { cfg.Web.Endpoint = "localhost" },
+			wantErr: []string{`web.endpoint: invalid address "localhost"`},
+		},
+		{
+			name:    "invalid port",
+			modify:  func(cfg *Configuration) { cfg.Web.Endpoint = ":70000" },
+			wantErr: []string{`web.endpoint: invalid port "70000"`},
+		},
+		{
+			name:    "negative shutdown timeout",
+			modify:  func(cfg *Configuration) { cfg.Web.ShutdownTimeout = -time.Second },
+			wantErr: []string{"web.shutdown-timeout: must not be negative"},
+		},
+		{
+			name:    "base URL without scheme",
+			modify:  func(cfg *Configuration) { cfg.Web.BaseURL = "mahlzeit.example.com" },
+			wantErr: []string{"web.base-url: invalid URL"},
+		},
+		{
+			name: "incomplete S3 and SMTP",
+			modify: func(cfg *Configuration) {
+				cfg.Storage.Driver = "s3"
+				cfg.Mail.Driver = "smtp"
+			},
+			wantErr: []string{"storage.s3:", "mail.smtp:"},
+		},
+		{
+			name: "invalid logging",
+			modify: func(cfg *Configuration) {
+				cfg.Log.Format = "logfmt"
+				cfg.Log.Level = "verbose"
+				cfg.Log.Levels = map[string]string{"http": "warn", "mail": "loud"}
+				cfg.Log.MaxAge = -1
+			},
+			wantErr: []string{
+				`log.format: unknown format "logfmt"`,
+				`log.level: unrecognized level: "verbose"`,
+				`log.levels.mail: unrecognized level: "loud"`,
+				"log: max-size, max-backups and max-age must not be negative",
+			},
+		},
+		{
+			name: "invalid tracing",
+			modify: func(cfg *Configuration) {
+				cfg.Tracing.Exporter = "jaeger"
+				cfg.Tracing.SampleRatio = 2
+			},
+			wantErr: []string{`tracing.exporter: unknown exporter "jaeger"`, "tracing.sample-ratio: invalid ratio 2"},
+		},
+		{
+			name:    "metrics on the web endpoint",
+			modify:  func(cfg *Configuration) { cfg.Metrics.Endpoint = cfg.Web.Endpoint },
+			wantErr: []string{"metrics.endpoint: must differ from web.endpoint"},
+		},
+		{
+			name:    "OIDC without client",
+			modify:  func(cfg *Configuration) { cfg.OIDC.Issuer = "https://accounts.example.com" },
+			wantErr: []string{"oidc.client-id: required", "oidc.redirect-url: required"},
+		},
+		{
+			name: "all unknown drivers",
+			modify: func(cfg *Configuration) {
+				cfg.Web.Mode = "staging"
+				cfg.Storage.Driver = "floppy"
+				cfg.Mail.Driver = "pigeon"
+			},
+			wantErr: []string{`web.mode: unknown mode "staging"`, `storage.driver: unknown driver "floppy"`, `mail.driver: unknown driver "pigeon"`},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := valid()
+			tt.modify(&cfg)
+			err := cfg.Validate()
+			if len(tt.wantErr) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			assert.Error(t, err)
+			for _, want := range tt.wantErr {
+				assert.Contains(t, err.Error(), want)
+			}
+		})
+	}
+}
@@ -0,0 +1,227 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"codeberg.org/mahlzeit/mahlzeit/db/queries"
+	"codeberg.org/mahlzeit/mahlzeit/internal/i18n"
+	"codeberg.org/mahlzeit/mahlzeit/internal/pghelper"
+	"github.com/carlmjohnson/resperr"
+	"github.com/jackc/pgconn"
+)
+
+// ExportedRecipe is the portable representation of a recipe, e.g. to move recipes between instances. Ingredients
+// and units are referenced by their names instead of their IDs. Images, revisions and translations are not part
+// of it.
+type ExportedRecipe struct {
+	Name                string         `json:"name"`
+	Description         string         `json:"description,omitempty"`
+	WorkingTime         exportDuration `json:"workingTime,omitempty"`
+	WaitingTime         exportDuration `json:"waitingTime,omitempty"`
+	Source              string         `json:"source,omitempty"`
+	Servings            int            `json:"servings"`
+	ServingsDescription string         `json:"servingsDescription,omitempty"`
+	Language            string         `json:"language,omitempty"`
+	Steps               []ExportedStep `json:"steps"`
+}
+
+type ExportedStep struct {
+	Instruction string               `json:"instruction"`
+	Time        exportDuration       `json:"time,omitempty"`
+	Ingredients []ExportedIngredient `json:"ingredients,omitempty"`
+}
+
+type ExportedIngredient struct {
+	Name   string  `json:"name"`
+	Unit   string  `json:"unit,omitempty"`
+	Amount float64 `json:"amount"`
+	Note   string  `json:"note,omitempty"`
+}
+
+// exportDuration is encoded as text like "1h30m", which is easier to read and write than nanoseconds.
+type exportDuration time.Duration
+
+func (d exportDuration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+func (d *exportDuration) UnmarshalText(b []byte) error {
+	v, err := time.ParseDuration(string(b))
+	if err != nil {
+		return err
+	}
+	*d = exportDuration(v)
+	return nil
+}
+
+// ImportResult lists the recipes of an import.
+type ImportResult struct {
+	Imported []string
+	Skipped  []string // recipes with a name that exists already
+}
+
+// ExportRecipes returns all recipes in their portable representation.
+func (app *Application) ExportRecipes(ctx context.Context) ([]ExportedRecipe, error) {
+	entries, err := app.GetAllRecipes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]ExportedRecipe, 0, len(entries))
+	for _, e := range entries {
+		r, err := app.GetSingleRecipe(ctx, e.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		exported := ExportedRecipe{
+			Name:                r.Name,
+			Description:         r.Description,
+			WorkingTime:         exportDuration(r.WorkingTime),
+			WaitingTime:         exportDuration(r.WaitingTime),
+			Source:              r.Source,
+			Servings:            r.BaseServings,
+			ServingsDescription: r.ServingsDescription,
+			Language:            r.Language,
+			Steps:               make([]ExportedStep, 0, len(r.Steps)),
+		}
+		for _, s := range r.Steps {
+			step := ExportedStep{Instruction: s.Instruction, Time: exportDuration(s.Time)}
+			for _, i := range s.Ingredients {
+				step.Ingredients = append(step.Ingredients, ExportedIngredient{
+					Name:   i.Name,
+					Unit:   i.UnitName,
+					Amount: i.Amount,
+					Note:   i.Note,
+				})
+			}
+			exported.Steps = append(exported.Steps, step)
+		}
+		res = append(res, exported)
+	}
+	return res, nil
+}
+
+// ImportRecipes adds the recipes, which are created by the current user, see [WithUserID]. Missing ingredients
+// and units are added. Recipes with a name that exists already are skipped, so that an import can be repeated.
+// Each recipe is imported on its own, so the recipes before an invalid one are kept.
+func (app *Application) ImportRecipes(ctx context.Context, recipes []ExportedRecipe) (ImportResult, error) {
+	userID := UserIDFromContext(ctx)
+	if userID == 0 {
+		return ImportResult{}, resperr.WithCodeAndMessage(nil, http.StatusUnauthorized, "recipes can only be imported by users")
+	}
+
+	var res ImportResult
+	for _, r := range recipes {
+		err := app.importRecipe(ctx, userID, r)
+		var pgerr *pgconn.PgError
+		if errors.As(err, &pgerr) && pgerr.ConstraintName == "recipes_name_key" {
+			res.Skipped = append(res.Skipped, r.Name)
+			continue
+		}
+		if err != nil {
+			return res, fmt.Errorf("importing recipe %q: %w", r.Name, err)
+		}
+		res.Imported = append(res.Imported, r.Name)
+	}
+	return res, nil
+}
+
+// importRecipe adds a single recipe with all of its steps within a transaction.
+func (app *Application) importRecipe(ctx context.Context, userID int, r ExportedRecipe) error {
+	recipe := &Recipe{
+		Name:        strings.TrimSpace(r.Name),
+		Servings:    r.Servings,
+		WorkingTime: time.Duration(r.WorkingTime),
+		WaitingTime: time.Duration(r.WaitingTime),
+		Language:    r.Language,
+	}
+	if recipe.Language == "" {
+		recipe.Language = i18n.DefaultLocale
+	}
+	if recipe.Servings <= 0 {
+		recipe.Servings = 1
+	}
+	if err := validateRecipe(recipe); err != nil {
+		return err
+	}
+
+	return app.inTx(ctx, func(q *queries.Queries) error {
+		row, err := q.AddRecipe(ctx, queries.AddRecipeParams{
+			Name:                recipe.Name,
+			Description:         r.Description,
+			WorkingTime:         nullInterval(recipe.WorkingTime),
+			WaitingTime:         nullInterval(recipe.WaitingTime),
+			CreatedBy:           int64(userID),
+			Source:              sql.NullString{String: strings.TrimSpace(r.Source), Valid: strings.TrimSpace(r.Source) != ""},
+			Servings:            int32(recipe.Servings),
+			ServingsDescription: r.ServingsDescription,
+			Language:            recipe.Language,
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, s := range r.Steps {
+			step := &Step{Instruction: s.Instruction, Time: time.Duration(s.Time)}
+			if err := validateStep(step); err != nil {
+				return err
+			}
+			stepID, err := q.AddNewStep(ctx, queries.AddNewStepParams{
+				RecipeID:    row.ID,
+				Instruction: step.Instruction,
+				Time:        pghelper.Interval(step.Time),
+			})
+			if err != nil {
+				return fmt.Errorf("adding step: %w", err)
+			}
+
+			for _, i := range s.Ingredients {
+				if err := importStepIngredient(ctx, q, stepID, i); err != nil {
+					return err
+				}
+			}
+		}
+		return recordRevision(ctx, q, int(row.ID))
+	})
+}
+
+// importStepIngredient adds the ingredient to the step, including the ingredient and unit themselves if they
+// don't exist yet.
+func importStepIngredient(ctx context.Context, q *queries.Queries, stepID int64, i ExportedIngredient) error {
+	name, unit := strings.TrimSpace(i.Name), strings.TrimSpace(i.Unit)
+	if name == "" {
+		return resperr.WithUserMessage(nil, "the name of an ingredient must not be empty")
+	}
+	ingredientID, err := q.AddIngredient(ctx, name)
+	if err != nil {
+		return fmt.Errorf("adding ingredient %q: %w", name, err)
+	}
+	var unitID int64
+	if unit != "" {
+		if unitID, err = q.AddUnit(ctx, unit); err != nil {
+			return fmt.Errorf("adding unit %q: %w", unit, err)
+		}
+	}
+
+	err = q.AddIngredientToStep(ctx, queries.AddIngredientToStepParams{
+		StepID:        stepID,
+		IngredientsID: ingredientID,
+		UnitID:        unitID,
+		Amount:        pghelper.Numeric(i.Amount),
+		Note:          i.Note,
+	})
+	if verr := fieldErrorFromDB(err); verr != nil {
+		return verr
+	}
+	if err != nil {
+		return fmt.Errorf("adding ingredient %q to step: %w", name, err)
+	}
+	return nil
+}
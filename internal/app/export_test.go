@@ -0,0 +1,86 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"codeberg.org/mahlzeit/mahlzeit/internal/testhelper"
+	"github.com/alecthomas/assert/v2"
+	"github.com/carlmjohnson/resperr"
+)
+
+func TestApplication_ImportRecipes(t *testing.T) {
+	t.Parallel()
+	app, ctx := newApp(t), testhelper.Context(t)
+	user, _ := app.AddTestUser(ctx)
+	ctx = WithUserID(ctx, user.ID)
+
+	recipe := ExportedRecipe{
+		Name:        testhelper.RandomString(20),
+		Description: t.Name(),
+		WorkingTime: exportDuration(15 * time.Minute),
+		Servings:    4,
+		Language:    "de",
+		Steps: []ExportedStep{
+			{Instruction: "Preheat the oven", Time: exportDuration(10 * time.Minute)},
+			{Instruction: "Bake", Ingredients: []ExportedIngredient{
+				{Name: testhelper.RandomString(10), Unit: testhelper.RandomString(10), Amount: 250, Note: "sifted"},
+				{Name: testhelper.RandomString(10), Amount: 2},
+			}},
+		},
+	}
+
+	t.Run("recipes are imported with their steps and ingredients", func(t *testing.T) {
+		res, err := app.ImportRecipes(ctx, []ExportedRecipe{recipe})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{recipe.Name}, res.Imported)
+
+		exported, err := app.ExportRecipes(ctx)
+		assert.NoError(t, err)
+		var found bool
+		for _, r := range exported {
+			if r.Name == recipe.Name {
+				assert.Equal(t, recipe, r)
+				found = true
+			}
+		}
+		assert.True(t, found, "imported recipe is not exported")
+	})
+	t.Run("existing recipes are skipped", func(t *testing.T) {
+		res, err := app.ImportRecipes(ctx, []ExportedRecipe{recipe})
+		assert.NoError(t, err)
+		assert.Equal(t, 0, len(res.Imported))
+		assert.Equal(t, []string{recipe.Name}, res.Skipped)
+	})
+	t.Run("invalid recipes are not imported", func(t *testing.T) {
+		invalid := ExportedRecipe{Name: testhelper.RandomString(20), Steps: []ExportedStep{{Instruction: " "}}}
+		_, err := app.ImportRecipes(ctx, []ExportedRecipe{invalid})
+		assert.Error(t, err)
+		assert.Equal(t, http.StatusBadRequest, resperr.StatusCode(err))
+
+		exported, err := app.ExportRecipes(ctx)
+		assert.NoError(t, err)
+		for _, r := range exported {
+			assert.NotEqual(t, invalid.Name, r.Name)
+		}
+	})
+	t.Run("imports require a user", func(t *testing.T) {
+		_, err := app.ImportRecipes(testhelper.Context(t), []ExportedRecipe{recipe})
+		assert.Equal(t, http.StatusUnauthorized, resperr.StatusCode(err))
+	})
+}
+
+func TestExportDuration(t *testing.T) {
+	t.Parallel()
+
+	b, err := json.Marshal(ExportedStep{Instruction: "Bake", Time: exportDuration(90 * time.Minute)})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"instruction":"Bake","time":"1h30m0s"}`, string(b))
+
+	var step ExportedStep
+	assert.NoError(t, json.Unmarshal([]byte(`{"instruction":"Bake","time":"45m"}`), &step))
+	assert.Equal(t, exportDuration(45*time.Minute), step.Time)
+	assert.Error(t, json.Unmarshal([]byte(`{"time":"soon"}`), &step))
+}
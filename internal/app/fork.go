@@ -0,0 +1,103 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"codeberg.org/mahlzeit/mahlzeit/db/queries"
+	"github.com/carlmjohnson/resperr"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+)
+
+type ForkRecipeParams struct {
+	RecipeID int
+	Name     string // optional, the name of the original recipe is used by default
+}
+
+// ForkRecipe creates a variant of a recipe by copying it, including all steps, their ingredients and the translations.
+// Because recipe names are unique, a numeric suffix is added if the name is taken already.
+// The ID of the new recipe is returned.
+func (app *Application) ForkRecipe(ctx context.Context, params ForkRecipeParams) (int, error) {
+	var id int64
+	err := app.inTx(ctx, func(q *queries.Queries) error {
+		origin, err := q.GetRecipeByID(ctx, int64(params.RecipeID))
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return resperr.WithCodeAndMessage(err, http.StatusNotFound, "recipe does not exist")
+			}
+			return fmt.Errorf("querying recipe %d: %w", params.RecipeID, err)
+		}
+
+		name := strings.TrimSpace(params.Name)
+		if name == "" {
+			name = origin.Name
+		}
+		name, err = q.GetAvailableRecipeName(ctx, name)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return resperr.WithCodeAndMessage(err, http.StatusConflict, "no free name for the recipe found")
+			}
+			return fmt.Errorf("finding available name for fork of recipe %d: %w", params.RecipeID, err)
+		}
+
+		id, err = q.ForkRecipe(ctx, queries.ForkRecipeParams{
+			Name:      name,
+			CreatedBy: int64(UserIDFromContext(ctx)),
+			ID:        origin.ID,
+		})
+		if err != nil {
+			// Another recipe with the same name could have been added concurrently.
+			var pgerr *pgconn.PgError
+			if errors.As(err, &pgerr) && pgerr.ConstraintName == "recipes_name_key" {
+				return resperr.WithCodeAndMessage(err, http.StatusConflict, "another recipe with the same name exists already")
+			}
+			return fmt.Errorf("forking recipe %d: %w", params.RecipeID, err)
+		}
+
+		if err := q.CopyRecipeTranslations(ctx, queries.CopyRecipeTranslationsParams{
+			TargetRecipeID: id,
+			SourceRecipeID: origin.ID,
+		}); err != nil {
+			return fmt.Errorf("copying translations of recipe %d: %w", params.RecipeID, err)
+		}
+
+		stepIDs, err := q.GetStepIDsForRecipe(ctx, origin.ID)
+		if err != nil {
+			return fmt.Errorf("querying steps of recipe %d: %w", params.RecipeID, err)
+		}
+		for _, stepID := range stepIDs {
+			newStepID, err := q.CopyStep(ctx, queries.CopyStepParams{
+				RecipeID: id,
+				ID:       stepID,
+			})
+			if err != nil {
+				return fmt.Errorf("copying step %d: %w", stepID, err)
+			}
+
+			if err := q.CopyStepIngredients(ctx, queries.CopyStepIngredientsParams{
+				TargetStepID: newStepID,
+				SourceStepID: stepID,
+			}); err != nil {
+				return fmt.Errorf("copying ingredients of step %d: %w", stepID, err)
+			}
+
+			if err := q.CopyStepTranslations(ctx, queries.CopyStepTranslationsParams{
+				TargetStepID: newStepID,
+				SourceStepID: stepID,
+			}); err != nil {
+				return fmt.Errorf("copying translations of step %d: %w", stepID, err)
+			}
+		}
+
+		return recordRevision(ctx, q, int(id))
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
+}
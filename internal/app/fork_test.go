@@ -0,0 +1,64 @@
+package app
+
+import (
+	"net/http"
+	"testing"
+
+	"codeberg.org/mahlzeit/mahlzeit/internal/testhelper"
+	"github.com/alecthomas/assert/v2"
+	"github.com/carlmjohnson/resperr"
+)
+
+func TestApplication_ForkRecipe(t *testing.T) {
+	t.Parallel()
+	app, ctx := newApp(t), testhelper.Context(t)
+
+	recipe := app.AddEmptyRecipe(ctx)
+	step := app.AddTestStep(ctx, recipe.ID)
+	ingredient, err := app.AddIngredient(ctx, t.Name())
+	assert.NoError(t, err)
+	err = app.AddIngredientToStep(ctx, AddIngredientToStepParams{
+		StepID:       step.ID,
+		IngredientID: ingredient.ID,
+		Amount:       2,
+		Note:         t.Name(),
+	})
+	assert.NoError(t, err)
+
+	t.Run("recipe is copied with all steps", func(t *testing.T) {
+		id, err := app.ForkRecipe(ctx, ForkRecipeParams{RecipeID: recipe.ID, Name: testhelper.RandomString(20)})
+		assert.NoError(t, err)
+
+		original, err := app.GetSingleRecipe(ctx, recipe.ID)
+		assert.NoError(t, err)
+		fork, err := app.GetSingleRecipe(ctx, id)
+		assert.NoError(t, err)
+
+		assert.Equal(t, &ListEntry{ID: original.ID, Name: original.Name}, fork.ForkedFrom)
+		assert.Equal(t, 1, len(fork.Steps))
+		assert.NotEqual(t, original.Steps[0].ID, fork.Steps[0].ID)
+		assert.Equal(t, original.Steps[0].Instruction, fork.Steps[0].Instruction)
+		assert.Equal(t, original.Steps[0].Ingredients[0].Name, fork.Steps[0].Ingredients[0].Name)
+		assert.Equal(t, original.Steps[0].Ingredients[0].Amount, fork.Steps[0].Ingredients[0].Amount)
+		assert.Equal(t, original.Steps[0].Ingredients[0].Note, fork.Steps[0].Ingredients[0].Note)
+	})
+	t.Run("name collisions are resolved with a suffix", func(t *testing.T) {
+		first, err := app.ForkRecipe(ctx, ForkRecipeParams{RecipeID: recipe.ID})
+		assert.NoError(t, err)
+		second, err := app.ForkRecipe(ctx, ForkRecipeParams{RecipeID: recipe.ID})
+		assert.NoError(t, err)
+
+		original, err := app.GetSingleRecipe(ctx, recipe.ID)
+		assert.NoError(t, err)
+		variants := map[int]string{}
+		for _, v := range original.Variants {
+			variants[v.ID] = v.Name
+		}
+		assert.Equal(t, recipe.Name+" (2)", variants[first])
+		assert.Equal(t, recipe.Name+" (3)", variants[second])
+	})
+	t.Run("missing recipe returns not found error", func(t *testing.T) {
+		_, err := app.ForkRecipe(ctx, ForkRecipeParams{RecipeID: -1})
+		assert.Equal(t, http.StatusNotFound, resperr.StatusCode(err))
+	})
+}
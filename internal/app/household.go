@@ -0,0 +1,173 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"codeberg.org/mahlzeit/mahlzeit/db/queries"
+	"github.com/carlmjohnson/resperr"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+)
+
+// Household is a group of users, e.g. a family or a shared flat.
+type Household struct {
+	ID      int
+	Name    string
+	Members []User // only set by GetHousehold
+}
+
+func (app *Application) GetAllHouseholds(ctx context.Context) ([]Household, error) {
+	households, err := app.Queries.GetAllHouseholds(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("querying all households: %w", err)
+	}
+
+	var res []Household
+	for _, h := range households {
+		res = append(res, Household{
+			ID:   int(h.ID),
+			Name: h.Name,
+		})
+	}
+
+	return res, nil
+}
+
+// GetHousehold returns a household including its members. If it does not exist, a not found error is returned.
+func (app *Application) GetHousehold(ctx context.Context, id int) (Household, error) {
+	h, err := app.Queries.GetHouseholdByID(ctx, int64(id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Household{}, resperr.WithCodeAndMessage(err, http.StatusNotFound, "household does not exist")
+		}
+		return Household{}, fmt.Errorf("querying household %d: %w", id, err)
+	}
+
+	members, err := app.Queries.GetHouseholdMembers(ctx, h.ID)
+	if err != nil {
+		return Household{}, fmt.Errorf("querying members of household %d: %w", id, err)
+	}
+
+	res := Household{
+		ID:   int(h.ID),
+		Name: h.Name,
+	}
+	for _, m := range members {
+		res.Members = append(res.Members, User{ID: int(m.ID), Name: m.Name})
+	}
+
+	return res, nil
+}
+
+// AddHousehold adds a new household without any members. Only superusers may add households.
+func (app *Application) AddHousehold(ctx context.Context, name string) (Household, error) {
+	if err := app.RequireSuperuser(ctx); err != nil {
+		return Household{}, err
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return Household{}, resperr.WithUserMessage(nil, "the name of a household must not be empty")
+	}
+
+	id, err := app.Queries.AddHousehold(ctx, name)
+	if err != nil {
+		return Household{}, fmt.Errorf("adding new household: %w", err)
+	}
+
+	return Household{
+		ID:   int(id),
+		Name: name,
+	}, nil
+}
+
+// DeleteHousehold deletes a household. Its members are kept, only their membership is removed.
+// Only superusers may delete households. If the household does not exist, a not found error is returned.
+func (app *Application) DeleteHousehold(ctx context.Context, id int) error {
+	if err := app.RequireSuperuser(ctx); err != nil {
+		return err
+	}
+
+	return app.inTx(ctx, func(q *queries.Queries) error {
+		if err := q.RemoveAllHouseholdMembers(ctx, int64(id)); err != nil {
+			return fmt.Errorf("removing members of household %d: %w", id, err)
+		}
+
+		n, err := q.DeleteHousehold(ctx, int64(id))
+		if err != nil {
+			return fmt.Errorf("deleting household %d: %w", id, err)
+		}
+		if n == 0 {
+			return resperr.WithCodeAndMessage(nil, http.StatusNotFound, "household does not exist")
+		}
+		return nil
+	})
+}
+
+// requireHouseholdMember returns an error unless the current user is a member of the household or a superuser,
+// see [Application.RequireSuperuser]. Only they may change the members.
+func (app *Application) requireHouseholdMember(ctx context.Context, householdID int) error {
+	err := app.RequireSuperuser(ctx)
+	if resperr.StatusCode(err) != http.StatusForbidden {
+		return err
+	}
+
+	member, err := app.Queries.IsHouseholdMember(ctx, queries.IsHouseholdMemberParams{
+		HouseholdID: int64(householdID),
+		UserID:      int64(UserIDFromContext(ctx)),
+	})
+	if err != nil {
+		return fmt.Errorf("querying membership of household %d: %w", householdID, err)
+	}
+	if !member {
+		return resperr.WithCodeAndMessage(nil, http.StatusForbidden, "only members may change a household")
+	}
+	return nil
+}
+
+// AddHouseholdMember adds a user to a household. This action is idempotent,
+// if the user is a member already, no error is returned. Only members and superusers may add members.
+func (app *Application) AddHouseholdMember(ctx context.Context, householdID, userID int) error {
+	if err := app.requireHouseholdMember(ctx, householdID); err != nil {
+		return err
+	}
+
+	err := app.Queries.AddHouseholdMember(ctx, queries.AddHouseholdMemberParams{
+		HouseholdID: int64(householdID),
+		UserID:      int64(userID),
+	})
+	if err != nil {
+		var pgerr *pgconn.PgError
+		if errors.As(err, &pgerr) {
+			switch pgerr.ConstraintName {
+			case "household_users_household_id_fkey":
+				return resperr.WithCodeAndMessage(err, http.StatusNotFound, "household does not exist")
+			case "household_users_user_id_fkey":
+				return resperr.WithCodeAndMessage(err, http.StatusNotFound, "user does not exist")
+			}
+		}
+		return fmt.Errorf("adding user %d to household %d: %w", userID, householdID, err)
+	}
+	return nil
+}
+
+// RemoveHouseholdMember removes a user from a household. This action is idempotent,
+// if the user is no member, no error is returned. Only members and superusers may remove members.
+func (app *Application) RemoveHouseholdMember(ctx context.Context, householdID, userID int) error {
+	if err := app.requireHouseholdMember(ctx, householdID); err != nil {
+		return err
+	}
+
+	err := app.Queries.RemoveHouseholdMember(ctx, queries.RemoveHouseholdMemberParams{
+		HouseholdID: int64(householdID),
+		UserID:      int64(userID),
+	})
+	if err != nil {
+		return fmt.Errorf("removing user %d from household %d: %w", userID, householdID, err)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package app
+
+import (
+	"net/http"
+	"testing"
+
+	"codeberg.org/mahlzeit/mahlzeit/internal/testhelper"
+	"github.com/alecthomas/assert/v2"
+	"github.com/carlmjohnson/resperr"
+)
+
+func TestApplication_HouseholdAdministration(t *testing.T) {
+	t.Parallel()
+	app := newApp(t)
+
+	superuser, _ := app.AddTestUser(testhelper.Context(t))
+	assert.NoError(t, app.SetSuperuser(testhelper.Context(t), superuser.ID, true))
+	user, _ := app.AddTestUser(testhelper.Context(t))
+
+	superuserCtx := WithUserID(testhelper.Context(t), superuser.ID)
+	userCtx := WithUserID(testhelper.Context(t), user.ID)
+
+	h, err := app.AddHousehold(superuserCtx, t.Name())
+	assert.NoError(t, err)
+
+	t.Run("users can't add households", func(t *testing.T) {
+		_, err := app.AddHousehold(userCtx, t.Name())
+		assert.Equal(t, http.StatusForbidden, resperr.StatusCode(err))
+	})
+
+	t.Run("users can't delete households", func(t *testing.T) {
+		err := app.DeleteHousehold(userCtx, h.ID)
+		assert.Equal(t, http.StatusForbidden, resperr.StatusCode(err))
+
+		_, err = app.GetHousehold(superuserCtx, h.ID)
+		assert.NoError(t, err)
+	})
+
+	t.Run("anonymous", func(t *testing.T) {
+		_, err := app.AddHousehold(testhelper.Context(t), t.Name())
+		assert.Equal(t, http.StatusUnauthorized, resperr.StatusCode(err))
+	})
+
+	t.Run("operator", func(t *testing.T) {
+		ctx := AsOperator(testhelper.Context(t))
+		other, err := app.AddHousehold(ctx, t.Name())
+		assert.NoError(t, err)
+		assert.NoError(t, app.DeleteHousehold(ctx, other.ID))
+	})
+
+	t.Run("superusers can delete households", func(t *testing.T) {
+		assert.NoError(t, app.DeleteHousehold(superuserCtx, h.ID))
+
+		_, err := app.GetHousehold(superuserCtx, h.ID)
+		assert.Equal(t, http.StatusNotFound, resperr.StatusCode(err))
+	})
+}
+
+func TestApplication_HouseholdMembers(t *testing.T) {
+	t.Parallel()
+	app := newApp(t)
+
+	member, _ := app.AddTestUser(testhelper.Context(t))
+	nonMember, _ := app.AddTestUser(testhelper.Context(t))
+	memberCtx := WithUserID(testhelper.Context(t), member.ID)
+	nonMemberCtx := WithUserID(testhelper.Context(t), nonMember.ID)
+
+	ctx := AsOperator(testhelper.Context(t))
+	h, err := app.AddHousehold(ctx, t.Name())
+	assert.NoError(t, err)
+	assert.NoError(t, app.AddHouseholdMember(ctx, h.ID, member.ID))
+
+	t.Run("non-members can't add members", func(t *testing.T) {
+		err := app.AddHouseholdMember(nonMemberCtx, h.ID, nonMember.ID)
+		assert.Equal(t, http.StatusForbidden, resperr.StatusCode(err))
+	})
+
+	t.Run("non-members can't remove members", func(t *testing.T) {
+		err := app.RemoveHouseholdMember(nonMemberCtx, h.ID, member.ID)
+		assert.Equal(t, http.StatusForbidden, resperr.StatusCode(err))
+
+		res, err := app.GetHousehold(ctx, h.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, []User{member}, res.Members)
+	})
+
+	t.Run("members can add and remove members", func(t *testing.T) {
+		assert.NoError(t, app.AddHouseholdMember(memberCtx, h.ID, nonMember.ID))
+		assert.NoError(t, app.RemoveHouseholdMember(memberCtx, h.ID, nonMember.ID))
+	})
+}
@@ -0,0 +1,83 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"codeberg.org/mahlzeit/mahlzeit/db/queries"
+	"codeberg.org/mahlzeit/mahlzeit/internal/oidc"
+	"github.com/carlmjohnson/resperr"
+	"github.com/jackc/pgx/v4"
+)
+
+// LoginWithIdentity returns the user of an identity at an external identity provider. On the first login,
+// the identity is linked to the user with the same email address, or a new user without a password is added.
+// The activation and the superuser flag are updated from the claims of the identity. Users who are not
+// activated get a forbidden error.
+func (app *Application) LoginWithIdentity(ctx context.Context, id oidc.Identity) (User, error) {
+	var user queries.UpdateUserFromIdentityRow
+	err := app.inTx(ctx, func(q *queries.Queries) error {
+		userID, err := q.GetIdentityUserID(ctx, queries.GetIdentityUserIDParams{Issuer: id.Issuer, Subject: id.Subject})
+		if errors.Is(err, pgx.ErrNoRows) {
+			userID, err = linkIdentity(ctx, q, id)
+		}
+		if err != nil {
+			return err
+		}
+
+		user, err = q.UpdateUserFromIdentity(ctx, queries.UpdateUserFromIdentityParams{
+			Activate:    id.Activated,
+			IsSuperuser: sql.NullBool{Bool: id.Superuser != nil && *id.Superuser, Valid: id.Superuser != nil},
+			ID:          userID,
+		})
+		if err != nil {
+			return fmt.Errorf("updating user %d from identity: %w", userID, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return User{}, err
+	}
+
+	if !user.IsActivated {
+		return User{}, resperr.WithCodeAndMessage(nil, http.StatusForbidden, "the account is not activated yet")
+	}
+	return User{ID: int(user.ID), Name: user.Name}, nil
+}
+
+// linkIdentity links a new identity to the user with the same email address. If there is none, a user is added.
+func linkIdentity(ctx context.Context, q *queries.Queries, id oidc.Identity) (int64, error) {
+	// Otherwise, anyone who can choose their email address at the identity provider could take over accounts.
+	email := normalizeEmail(id.Email)
+	if email == "" || !id.EmailVerified {
+		return 0, resperr.WithCodeAndMessage(nil, http.StatusForbidden, "the identity provider didn't provide a verified email address")
+	}
+
+	var userID int64
+	u, err := q.GetUserByEmail(ctx, email)
+	switch {
+	case err == nil:
+		userID = u.ID
+	case errors.Is(err, pgx.ErrNoRows):
+		name := id.Name
+		if name == "" {
+			name = email
+		}
+		// Without a password hash, the user can't log in with a password.
+		userID, err = q.AddUser(ctx, queries.AddUserParams{Name: name, Email: email})
+		if err != nil {
+			return 0, fmt.Errorf("adding user for identity: %w", err)
+		}
+	default:
+		return 0, fmt.Errorf("querying user by email: %w", err)
+	}
+
+	err = q.AddUserIdentity(ctx, queries.AddUserIdentityParams{Issuer: id.Issuer, Subject: id.Subject, UserID: userID})
+	if err != nil {
+		return 0, fmt.Errorf("linking identity to user %d: %w", userID, err)
+	}
+	return userID, nil
+}
@@ -0,0 +1,68 @@
+package app
+
+import (
+	"net/http"
+	"testing"
+
+	"codeberg.org/mahlzeit/mahlzeit/internal/oidc"
+	"codeberg.org/mahlzeit/mahlzeit/internal/testhelper"
+	"github.com/alecthomas/assert/v2"
+	"github.com/carlmjohnson/resperr"
+)
+
+func TestApplication_LoginWithIdentity(t *testing.T) {
+	t.Parallel()
+	app, ctx := newApp(t), testhelper.Context(t)
+
+	issuer := "https://idp.example.com/" + testhelper.RandomString(10)
+	superuser := true
+
+	t.Run("links existing user by email address", func(t *testing.T) {
+		user, email := app.AddTestUser(ctx)
+
+		res, err := app.LoginWithIdentity(ctx, oidc.Identity{
+			Issuer:        issuer,
+			Subject:       "existing",
+			Email:         email,
+			EmailVerified: true,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, user, res)
+
+		// The following logins use the subject, so a changed email address doesn't matter.
+		res, err = app.LoginWithIdentity(ctx, oidc.Identity{Issuer: issuer, Subject: "existing", Email: "changed@example.com"})
+		assert.NoError(t, err)
+		assert.Equal(t, user, res)
+	})
+
+	t.Run("adds new user", func(t *testing.T) {
+		id := oidc.Identity{
+			Issuer:        issuer,
+			Subject:       "new",
+			Email:         testhelper.RandomString(10) + "@example.com",
+			EmailVerified: true,
+			Name:          "New User",
+		}
+
+		// New users are not activated without the activation claims.
+		_, err := app.LoginWithIdentity(ctx, id)
+		assert.Equal(t, http.StatusForbidden, resperr.StatusCode(err))
+
+		id.Activated = true
+		id.Superuser = &superuser
+		res, err := app.LoginWithIdentity(ctx, id)
+		assert.NoError(t, err)
+		assert.Equal(t, "New User", res.Name)
+
+		// The user can't log in with a password.
+		_, err = app.Authenticate(ctx, id.Email, "")
+		assert.Equal(t, http.StatusUnauthorized, resperr.StatusCode(err))
+	})
+
+	t.Run("unverified email address", func(t *testing.T) {
+		_, email := app.AddTestUser(ctx)
+
+		_, err := app.LoginWithIdentity(ctx, oidc.Identity{Issuer: issuer, Subject: "unverified", Email: email})
+		assert.Equal(t, http.StatusForbidden, resperr.StatusCode(err))
+	})
+}
@@ -0,0 +1,183 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"codeberg.org/mahlzeit/mahlzeit/db/queries"
+	"codeberg.org/mahlzeit/mahlzeit/internal/imaging"
+	"github.com/carlmjohnson/resperr"
+	"github.com/google/uuid"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"go.uber.org/zap"
+)
+
+type AddImageParams struct {
+	RecipeID int
+	StepID   int // optional, only set if the image belongs to a step
+	AltText  string
+	Data     io.Reader
+}
+
+// AddImage processes an uploaded image and stores all variants of it in the blob storage.
+// Unsupported files are rejected with a bad request error.
+func (app *Application) AddImage(ctx context.Context, params AddImageParams) (Image, error) {
+	if params.StepID != 0 {
+		step, err := app.GetStepByID(ctx, params.StepID)
+		if err != nil {
+			return Image{}, err
+		}
+		if step.RecipeID != params.RecipeID {
+			return Image{}, resperr.New(http.StatusNotFound, "step %d does not belong to recipe %d", params.StepID, params.RecipeID)
+		}
+	}
+
+	processed, err := imaging.Process(params.Data)
+	if err != nil {
+		switch {
+		case errors.Is(err, imaging.ErrUnsupportedFormat):
+			return Image{}, resperr.WithCodeAndMessage(err, http.StatusBadRequest, "unsupported image format")
+		case errors.Is(err, imaging.ErrTooLarge):
+			return Image{}, resperr.WithCodeAndMessage(err, http.StatusRequestEntityTooLarge, "image dimensions are too large")
+		}
+		return Image{}, fmt.Errorf("processing image for recipe %d: %w", params.RecipeID, err)
+	}
+
+	key := fmt.Sprintf("recipes/%d/%s", params.RecipeID, uuid.NewString())
+	for _, v := range processed.Variants {
+		if err := app.Blobs.Put(ctx, variantKey(key, v.Width, v.Format), bytes.NewReader(v.Data), v.Format.ContentType()); err != nil {
+			app.deleteImageVariants(ctx, key, processed.Width)
+			return Image{}, fmt.Errorf("storing image variant: %w", err)
+		}
+	}
+
+	id, err := app.Queries.AddImage(ctx, queries.AddImageParams{
+		RecipeID:   int64(params.RecipeID),
+		StepID:     int64(params.StepID),
+		StorageKey: key,
+		Width:      int32(processed.Width),
+		Height:     int32(processed.Height),
+		AltText:    params.AltText,
+	})
+	if err != nil {
+		app.deleteImageVariants(ctx, key, processed.Width)
+
+		var pgerr *pgconn.PgError
+		if errors.As(err, &pgerr) && pgerr.ConstraintName == "images_recipe_id_fkey" {
+			return Image{}, resperr.WithCodeAndMessage(err, http.StatusNotFound, "recipe does not exist")
+		}
+		return Image{}, fmt.Errorf("adding image to recipe %d: %w", params.RecipeID, err)
+	}
+
+	return Image{
+		ID:       int(id),
+		RecipeID: params.RecipeID,
+		StepID:   params.StepID,
+		Key:      key,
+		Width:    processed.Width,
+		Height:   processed.Height,
+		AltText:  params.AltText,
+	}, nil
+}
+
+// DeleteImage deletes an image of a recipe, including all its variants.
+// This action is idempotent, if the image is already deleted, no error is returned.
+func (app *Application) DeleteImage(ctx context.Context, recipeID, imageID int) error {
+	row, err := app.Queries.DeleteImage(ctx, queries.DeleteImageParams{
+		ID:       int64(imageID),
+		RecipeID: int64(recipeID),
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		return fmt.Errorf("deleting image %d: %w", imageID, err)
+	}
+
+	app.deleteImageVariants(ctx, row.StorageKey, int(row.Width))
+	return nil
+}
+
+// deleteImageVariants removes all variants of an image from the blob storage.
+// Because the database is the source of truth, failures are only logged.
+func (app *Application) deleteImageVariants(ctx context.Context, key string, width int) {
+	for _, w := range imaging.Widths(width) {
+		for _, f := range imaging.Formats {
+			variant := variantKey(key, w, f)
+			if err := app.Blobs.Delete(ctx, variant); err != nil {
+				app.Logger.Warn("deleting image variant failed", zap.String("key", variant), zap.Error(err))
+			}
+		}
+	}
+}
+
+type Image struct {
+	ID       int
+	RecipeID int
+	StepID   int
+	Key      string // prefix of all variants in the blob storage
+	Width    int    // dimensions of the largest variant
+	Height   int
+	AltText  string
+}
+
+// URL returns the path to the variant with the given width and format.
+func (i Image) URL(width int, format imaging.Format) string {
+	return "/images/" + variantKey(i.Key, width, format)
+}
+
+// Src returns the path to the largest JPEG variant, which is supported by all browsers.
+func (i Image) Src() string {
+	return i.URL(i.Width, imaging.JPEG)
+}
+
+// Thumbnail returns the path to the smallest variant in the given format.
+func (i Image) Thumbnail(format imaging.Format) string {
+	return i.URL(imaging.Widths(i.Width)[0], format)
+}
+
+// Srcset returns the value for the srcset attribute, containing all variants in the given format.
+// It's empty if the format isn't generated, e.g. WebP in builds without cgo.
+func (i Image) Srcset(format imaging.Format) string {
+	if !generated(format) {
+		return ""
+	}
+	var entries []string
+	for _, w := range imaging.Widths(i.Width) {
+		entries = append(entries, i.URL(w, format)+" "+strconv.Itoa(w)+"w")
+	}
+	return strings.Join(entries, ", ")
+}
+
+// generated reports whether variants are generated in the format.
+func generated(format imaging.Format) bool {
+	for _, f := range imaging.Formats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+func variantKey(key string, width int, format imaging.Format) string {
+	return key + "/" + strconv.Itoa(width) + "." + format.Extension()
+}
+
+func imageFromDB(i queries.Image) Image {
+	return Image{
+		ID:       int(i.ID),
+		RecipeID: int(i.RecipeID),
+		StepID:   int(i.StepID.Int64),
+		Key:      i.StorageKey,
+		Width:    int(i.Width),
+		Height:   int(i.Height),
+		AltText:  i.AltText,
+	}
+}
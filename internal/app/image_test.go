@@ -0,0 +1,102 @@
+package app
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/png"
+	"net/http"
+	"strings"
+	"testing"
+
+	"codeberg.org/mahlzeit/mahlzeit/internal/blob"
+	"codeberg.org/mahlzeit/mahlzeit/internal/imaging"
+	"codeberg.org/mahlzeit/mahlzeit/internal/testhelper"
+	"github.com/alecthomas/assert/v2"
+	"github.com/carlmjohnson/resperr"
+)
+
+func testPNG(t *testing.T, width, height int) *bytes.Buffer {
+	t.Helper()
+
+	var buf bytes.Buffer
+	err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height)))
+	assert.NoError(t, err)
+	return &buf
+}
+
+func TestApplication_AddImage(t *testing.T) {
+	t.Parallel()
+	app, ctx := newApp(t), testhelper.Context(t)
+
+	recipe := app.AddEmptyRecipe(ctx)
+	step := app.AddTestStep(ctx, recipe.ID)
+	otherRecipe := app.AddEmptyRecipe(ctx)
+
+	tests := []struct {
+		name     string
+		params   AddImageParams
+		wantCode int
+	}{
+		{
+			name:     "recipe image",
+			params:   AddImageParams{RecipeID: recipe.ID, Data: testPNG(t, 400, 300)},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "step image",
+			params:   AddImageParams{RecipeID: recipe.ID, StepID: step.ID, Data: testPNG(t, 400, 300)},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "step of another recipe",
+			params:   AddImageParams{RecipeID: otherRecipe.ID, StepID: step.ID, Data: testPNG(t, 400, 300)},
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "missing recipe",
+			params:   AddImageParams{RecipeID: -1, Data: testPNG(t, 400, 300)},
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "not an image",
+			params:   AddImageParams{RecipeID: recipe.ID, Data: strings.NewReader("no image")},
+			wantCode: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := app.AddImage(ctx, tt.params)
+			code := resperr.StatusCode(err)
+			assert.Equal(t, tt.wantCode, code)
+		})
+	}
+}
+
+func TestApplication_DeleteImage(t *testing.T) {
+	t.Parallel()
+	app, ctx := newApp(t), testhelper.Context(t)
+	recipe := app.AddEmptyRecipe(ctx)
+
+	img, err := app.AddImage(ctx, AddImageParams{RecipeID: recipe.ID, AltText: t.Name(), Data: testPNG(t, 400, 300)})
+	assert.NoError(t, err)
+	assert.Equal(t, 400, img.Width)
+
+	r, err := app.Blobs.Get(ctx, variantKey(img.Key, 320, imaging.JPEG))
+	assert.NoError(t, err)
+	r.Close()
+
+	got, err := app.GetSingleRecipe(ctx, recipe.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, []Image{img}, got.Images)
+
+	assert.NoError(t, app.DeleteImage(ctx, recipe.ID, img.ID))
+	_, err = app.Blobs.Get(ctx, variantKey(img.Key, 320, imaging.JPEG))
+	assert.True(t, errors.Is(err, blob.ErrNotFound), "expected variant to be deleted, got: %v", err)
+
+	// deleting again is not an error
+	assert.NoError(t, app.DeleteImage(ctx, recipe.ID, img.ID))
+}
@@ -5,13 +5,19 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/carlmjohnson/resperr"
 	"github.com/jackc/pgx/v4"
 )
 
-// AddIngredient adds a new ingredient.
+// AddIngredient adds a new ingredient. If an ingredient with the same name exists already, it is returned instead.
 func (app *Application) AddIngredient(ctx context.Context, name string) (Ingredient, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return Ingredient{}, resperr.WithUserMessage(nil, "the name of an ingredient must not be empty")
+	}
+
 	id, err := app.Queries.AddIngredient(ctx, name)
 	if err != nil {
 		return Ingredient{}, fmt.Errorf("adding new ingredient: %w", err)
@@ -0,0 +1,41 @@
+package app
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/carlmjohnson/resperr"
+)
+
+// GetLogLevels returns the default level of the log and those of the named loggers, e.g. "info" and
+// {"http": "warn"}. Only superusers may see them.
+func (app *Application) GetLogLevels(ctx context.Context) (string, map[string]string, error) {
+	if err := app.requireLogLevels(ctx); err != nil {
+		return "", nil, err
+	}
+	level, named := app.LogLevels.All()
+	return level, named, nil
+}
+
+// SetLogLevel changes the level of the named logger and its children at runtime, or the default level if the
+// name is empty. The change is lost on restart. Only superusers may change the levels.
+func (app *Application) SetLogLevel(ctx context.Context, logger, level string) error {
+	if err := app.requireLogLevels(ctx); err != nil {
+		return err
+	}
+	if err := app.LogLevels.SetLevel(logger, level); err != nil {
+		return resperr.WithUserMessagef(err, "unknown log level %q", level)
+	}
+	return nil
+}
+
+// requireLogLevels returns an error unless the current user is a superuser and the levels can be changed.
+func (app *Application) requireLogLevels(ctx context.Context) error {
+	if err := app.RequireSuperuser(ctx); err != nil {
+		return err
+	}
+	if app.LogLevels == nil {
+		return resperr.New(http.StatusNotFound, "the log levels can't be changed at runtime")
+	}
+	return nil
+}
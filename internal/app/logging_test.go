@@ -0,0 +1,44 @@
+package app
+
+import (
+	"net/http"
+	"testing"
+
+	"codeberg.org/mahlzeit/mahlzeit/internal/testhelper"
+	"codeberg.org/mahlzeit/mahlzeit/internal/zaphelper"
+	"github.com/alecthomas/assert/v2"
+	"github.com/carlmjohnson/resperr"
+)
+
+func TestApplication_LogLevels(t *testing.T) {
+	t.Parallel()
+	app := newApp(t)
+
+	var err error
+	app.LogLevels, err = zaphelper.NewLevels("info", nil)
+	assert.NoError(t, err)
+
+	superuser, _ := app.AddTestUser(testhelper.Context(t))
+	assert.NoError(t, app.SetSuperuser(testhelper.Context(t), superuser.ID, true))
+	user, _ := app.AddTestUser(testhelper.Context(t))
+
+	t.Run("users can't change the levels", func(t *testing.T) {
+		ctx := WithUserID(testhelper.Context(t), user.ID)
+		err := app.SetLogLevel(ctx, "", "debug")
+		assert.Equal(t, http.StatusForbidden, resperr.StatusCode(err))
+		_, _, err = app.GetLogLevels(ctx)
+		assert.Equal(t, http.StatusForbidden, resperr.StatusCode(err))
+	})
+
+	t.Run("superusers can change the levels", func(t *testing.T) {
+		ctx := WithUserID(testhelper.Context(t), superuser.ID)
+		assert.NoError(t, app.SetLogLevel(ctx, "http", "warn"))
+		err := app.SetLogLevel(ctx, "http", "loud")
+		assert.Equal(t, http.StatusBadRequest, resperr.StatusCode(err))
+
+		level, named, err := app.GetLogLevels(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, "info", level)
+		assert.Equal(t, map[string]string{"http": "warn"}, named)
+	})
+}
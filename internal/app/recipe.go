@@ -2,11 +2,21 @@ package app
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
 	"codeberg.org/mahlzeit/mahlzeit/db/queries"
+	"codeberg.org/mahlzeit/mahlzeit/internal/i18n"
+	"codeberg.org/mahlzeit/mahlzeit/internal/pghelper"
+	"github.com/carlmjohnson/resperr"
+	"github.com/jackc/pgconn"
 	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
 )
 
 func (app *Application) GetAllRecipes(ctx context.Context) ([]ListEntry, error) {
@@ -15,12 +25,26 @@ func (app *Application) GetAllRecipes(ctx context.Context) ([]ListEntry, error)
 		return nil, fmt.Errorf("fetching recipes from database: %w", err)
 	}
 
+	covers, err := app.Queries.GetRecipeCoverImages(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("fetching cover images from database: %w", err)
+	}
+
+	coversByRecipe := make(map[int64]Image, len(covers))
+	for _, c := range covers {
+		coversByRecipe[c.RecipeID] = imageFromDB(c)
+	}
+
 	var res []ListEntry
 	for _, row := range dbResult {
-		res = append(res, ListEntry{
+		entry := ListEntry{
 			ID:   int(row.ID),
 			Name: row.Name,
-		})
+		}
+		if cover, ok := coversByRecipe[row.ID]; ok {
+			entry.Cover = &cover
+		}
+		res = append(res, entry)
 	}
 
 	return res, nil
@@ -31,6 +55,9 @@ func (app *Application) GetSingleRecipe(ctx context.Context, id int) (*Recipe, e
 	// TODO: execute the following queries in a transaction
 	base, err := app.Queries.GetRecipeByID(ctx, int64(id))
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, resperr.WithCodeAndMessage(err, http.StatusNotFound, "recipe does not exist")
+		}
 		return nil, fmt.Errorf("querying recipe %d: %w", id, err)
 	}
 
@@ -44,6 +71,7 @@ func (app *Application) GetSingleRecipe(ctx context.Context, id int) (*Recipe, e
 		BaseServings:        int(base.Servings),
 		Servings:            int(base.Servings),
 		ServingsDescription: base.ServingsDescription,
+		Language:            base.Language,
 	}
 	_ = base.WorkingTime.AssignTo(&res.WorkingTime)
 	_ = base.WaitingTime.AssignTo(&res.WaitingTime)
@@ -55,8 +83,10 @@ func (app *Application) GetSingleRecipe(ctx context.Context, id int) (*Recipe, e
 
 	for _, ingredient := range ingredients {
 		res.Ingredients = append(res.Ingredients, Ingredient{
+			ID:       int(ingredient.ID),
 			Name:     ingredient.Name,
 			Amount:   float64(ingredient.TotalAmount),
+			UnitID:   int(ingredient.UnitID.Int64),
 			UnitName: ingredient.UnitName.String,
 		})
 	}
@@ -93,33 +123,182 @@ func (app *Application) GetSingleRecipe(ctx context.Context, id int) (*Recipe, e
 		res.Steps = append(res.Steps, s)
 	}
 
+	if base.ForkedFrom.Valid {
+		origin, err := app.Queries.GetRecipeByID(ctx, base.ForkedFrom.Int64)
+		if err != nil {
+			return nil, fmt.Errorf("querying origin of recipe %d: %w", id, err)
+		}
+		res.ForkedFrom = &ListEntry{ID: int(origin.ID), Name: origin.Name}
+	}
+
+	variants, err := app.Queries.GetRecipeVariants(ctx, int64(id))
+	if err != nil {
+		return nil, fmt.Errorf("querying variants of recipe %d: %w", id, err)
+	}
+	for _, v := range variants {
+		res.Variants = append(res.Variants, ListEntry{ID: int(v.ID), Name: v.Name})
+	}
+
+	images, err := app.Queries.GetImagesForRecipe(ctx, int64(id))
+	if err != nil {
+		return nil, fmt.Errorf("querying images for recipe %d: %w", id, err)
+	}
+
+	for _, i := range images {
+		img := imageFromDB(i)
+		if img.StepID == 0 {
+			res.Images = append(res.Images, img)
+			continue
+		}
+		for j := range res.Steps {
+			if res.Steps[j].ID == img.StepID {
+				res.Steps[j].Images = append(res.Steps[j].Images, img)
+			}
+		}
+	}
+
 	return res, nil
 }
 
+// AddRecipe adds a new recipe without any steps. The recipe is created by the current user, see [WithUserID].
+// The ID and creation date are set on r.
+func (app *Application) AddRecipe(ctx context.Context, r *Recipe) error {
+	userID := UserIDFromContext(ctx)
+	if userID == 0 {
+		return resperr.WithCodeAndMessage(nil, http.StatusUnauthorized, "recipes can only be added by users")
+	}
+	if r.Language == "" {
+		r.Language = i18n.DefaultLocale
+	}
+	if r.Servings <= 0 {
+		r.Servings = 1
+	}
+	if err := validateRecipe(r); err != nil {
+		return err
+	}
+
+	return app.inTx(ctx, func(q *queries.Queries) error {
+		row, err := q.AddRecipe(ctx, queries.AddRecipeParams{
+			Name:                strings.TrimSpace(r.Name),
+			Description:         r.Description,
+			WorkingTime:         nullInterval(r.WorkingTime),
+			WaitingTime:         nullInterval(r.WaitingTime),
+			CreatedBy:           int64(userID),
+			Source:              sql.NullString{String: strings.TrimSpace(r.Source), Valid: strings.TrimSpace(r.Source) != ""},
+			Servings:            int32(r.Servings),
+			ServingsDescription: r.ServingsDescription,
+			Language:            r.Language,
+		})
+		if err != nil {
+			var pgerr *pgconn.PgError
+			if errors.As(err, &pgerr) && pgerr.ConstraintName == "recipes_name_key" {
+				return resperr.WithCodeAndMessage(err, http.StatusConflict, "another recipe with the same name exists already")
+			}
+			return fmt.Errorf("adding recipe: %w", err)
+		}
+
+		r.ID = int(row.ID)
+		r.CreatedAt = row.CreatedAt
+		r.BaseServings = r.Servings
+		return recordRevision(ctx, q, r.ID)
+	})
+}
+
+// DeleteRecipe deletes a recipe, including its steps, images, revisions and translations.
+// Variants of the recipe are kept. If the recipe does not exist, a not found error is returned.
+func (app *Application) DeleteRecipe(ctx context.Context, id int) error {
+	var images []queries.Image
+	err := app.inTx(ctx, func(q *queries.Queries) error {
+		// The image rows are deleted by the database, but the files must be removed by us.
+		var err error
+		images, err = q.GetImagesForRecipe(ctx, int64(id))
+		if err != nil {
+			return fmt.Errorf("querying images for recipe %d: %w", id, err)
+		}
+
+		n, err := q.DeleteRecipe(ctx, int64(id))
+		if err != nil {
+			return fmt.Errorf("deleting recipe %d: %w", id, err)
+		}
+		if n == 0 {
+			return resperr.WithCodeAndMessage(nil, http.StatusNotFound, "recipe does not exist")
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, i := range images {
+		app.deleteImageVariants(ctx, i.StorageKey, int(i.Width))
+	}
+	return nil
+}
+
 // UpdateRecipe updates basic information about a recipe. This includes:
 //   - Name
 //   - Servings
 //   - Description
+//   - Working and waiting time
+//   - Source
+//   - Language, which is kept if it's empty
 //
 // All other properties are unaffected.
+//
+// Invalid values result in a [ValidationError].
 func (app *Application) UpdateRecipe(ctx context.Context, r *Recipe) error {
-	err := app.Queries.UpdateBasicRecipeInformation(ctx, queries.UpdateBasicRecipeInformationParams{
-		ID:                  int64(r.ID),
-		Name:                r.Name,
-		Servings:            int32(r.Servings),
-		Description:         r.Description,
-		ServingsDescription: r.ServingsDescription,
-	})
-	if err != nil {
-		return fmt.Errorf("updating recipe %d in database: %w", r.ID, err)
+	if err := validateRecipe(r); err != nil {
+		return err
 	}
 
-	return nil
+	return app.inTx(ctx, func(q *queries.Queries) error {
+		err := q.UpdateBasicRecipeInformation(ctx, queries.UpdateBasicRecipeInformationParams{
+			ID:                  int64(r.ID),
+			Name:                strings.TrimSpace(r.Name),
+			Servings:            int32(r.Servings),
+			Description:         r.Description,
+			ServingsDescription: r.ServingsDescription,
+			WorkingTime:         nullInterval(r.WorkingTime),
+			WaitingTime:         nullInterval(r.WaitingTime),
+			Source:              strings.TrimSpace(r.Source),
+			Language:            r.Language,
+		})
+		if err != nil {
+			if verr := fieldErrorFromDB(err); verr != nil {
+				return verr
+			}
+			return fmt.Errorf("updating recipe %d in database: %w", r.ID, err)
+		}
+
+		return recordRevision(ctx, q, r.ID)
+	})
+}
+
+// validateRecipe checks the fields of r that users can change, see [Application.UpdateRecipe].
+func validateRecipe(r *Recipe) error {
+	errs := FieldErrors{}
+	if strings.TrimSpace(r.Name) == "" {
+		errs["Name"] = "validation.required"
+	}
+	if r.Servings < 1 {
+		errs["Servings"] = "validation.min_servings"
+	}
+	if r.WorkingTime < 0 {
+		errs["WorkingTime"] = "validation.negative_duration"
+	}
+	if r.WaitingTime < 0 {
+		errs["WaitingTime"] = "validation.negative_duration"
+	}
+	if r.Language != "" && !i18n.IsSupported(r.Language) {
+		errs["Language"] = "validation.unsupported_language"
+	}
+	return errs.Err()
 }
 
 type ListEntry struct {
-	ID   int
-	Name string
+	ID    int
+	Name  string
+	Cover *Image // optional
 }
 
 type Recipe struct {
@@ -134,9 +313,41 @@ type Recipe struct {
 	BaseServings        int // The number of servings that the recipe was written for.
 	Servings            int // The current amount of servings, calculated with WithServings.
 	ServingsDescription string
+	Language            string // the original language of the texts, e.g. "de", see TranslateRecipe
 
 	Ingredients []Ingredient
 	Steps       []Step
+	Images      []Image // only the images of the recipe itself, step images are part of each Step
+
+	ForkedFrom *ListEntry  // optional, the recipe that this one is a variant of
+	Variants   []ListEntry // recipes that were forked from this one
+}
+
+// TotalTime returns the sum of the working and waiting time of the recipe and the times of all its steps.
+func (r *Recipe) TotalTime() time.Duration {
+	total := r.WorkingTime + r.WaitingTime
+	for _, s := range r.Steps {
+		total += s.Time
+	}
+	return total
+}
+
+// SourceURL returns the source as URL, if it is a link to a website. Otherwise, e.g. if the source
+// is the name of a cookbook, an empty string is returned.
+func (r *Recipe) SourceURL() string {
+	u, err := url.Parse(strings.TrimSpace(r.Source))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ""
+	}
+	return u.String()
+}
+
+// nullInterval returns an interval for d, which is null for a duration of zero.
+func nullInterval(d time.Duration) pgtype.Interval {
+	if d == 0 {
+		return pgtype.Interval{Status: pgtype.Null}
+	}
+	return pghelper.Interval(d)
 }
 
 // WithServings recalculates the recipe with the given amount of servings.
@@ -171,6 +382,7 @@ type Ingredient struct {
 	Name     string
 	Amount   float64
 	Note     string
+	UnitID   int
 	UnitName string
 
 	// only used for the template "ingredient" and its delete button
@@ -183,6 +395,10 @@ type Step struct {
 	Instruction string
 	Time        time.Duration
 	Ingredients []Ingredient
+	Images      []Image
+
+	// only used for the template "single_step_edit", if a new step should be inserted after another one
+	InsertAfter int
 }
 type Unit struct {
 	ID   int
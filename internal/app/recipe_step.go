@@ -2,9 +2,11 @@ package app
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 
 	"codeberg.org/mahlzeit/mahlzeit/db/queries"
 	"codeberg.org/mahlzeit/mahlzeit/internal/pghelper"
@@ -13,15 +15,79 @@ import (
 	"github.com/jackc/pgx/v4"
 )
 
-// AddStepToRecipe adds a step to the recipe.
+// AddStepToRecipe adds a step to the recipe. Invalid values result in a [ValidationError].
 func (app *Application) AddStepToRecipe(ctx context.Context, recipeID int, s *Step) error {
-	id, err := app.Queries.AddNewStep(ctx, queries.AddNewStepParams{
-		RecipeID:    int64(recipeID),
-		Instruction: s.Instruction,
-		Time:        pghelper.Interval(s.Time),
+	if err := validateStep(s); err != nil {
+		return err
+	}
+
+	var id int64
+	err := app.inTx(ctx, func(q *queries.Queries) error {
+		var err error
+		id, err = q.AddNewStep(ctx, queries.AddNewStepParams{
+			RecipeID:    int64(recipeID),
+			Instruction: s.Instruction,
+			Time:        pghelper.Interval(s.Time),
+		})
+		if err != nil {
+			if verr := fieldErrorFromDB(err); verr != nil {
+				return verr
+			}
+			return fmt.Errorf("adding step in database for recipe %d: %w", recipeID, err)
+		}
+
+		return recordRevision(ctx, q, recipeID)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.RecipeID = recipeID
+	s.ID = int(id)
+
+	return nil
+}
+
+// InsertStepAfter adds a step to a recipe directly after another step of it. If that step belongs to another
+// recipe, a not found error is returned. Invalid values result in a [ValidationError].
+func (app *Application) InsertStepAfter(ctx context.Context, recipeID, afterStepID int, s *Step) error {
+	if err := validateStep(s); err != nil {
+		return err
+	}
+
+	var id int64
+	err := app.inTx(ctx, func(q *queries.Queries) error {
+		after, err := getRecipeStep(ctx, q, recipeID, afterStepID)
+		if err != nil {
+			return err
+		}
+
+		// The step is added at the end first, and moved to the correct position afterwards.
+		id, err = q.AddNewStep(ctx, queries.AddNewStepParams{
+			RecipeID:    int64(recipeID),
+			Instruction: s.Instruction,
+			Time:        pghelper.Interval(s.Time),
+		})
+		if err != nil {
+			if verr := fieldErrorFromDB(err); verr != nil {
+				return verr
+			}
+			return fmt.Errorf("adding step in database for recipe %d: %w", recipeID, err)
+		}
+
+		stepIDs, err := q.GetStepIDsForRecipe(ctx, int64(recipeID))
+		if err != nil {
+			return fmt.Errorf("querying steps of recipe %d: %w", recipeID, err)
+		}
+		position := indexOf(stepIDs, after.ID) + 2
+		if err := setStepOrder(ctx, q, int64(recipeID), moveTo(stepIDs, id, position)); err != nil {
+			return err
+		}
+
+		return recordRevision(ctx, q, recipeID)
 	})
 	if err != nil {
-		return fmt.Errorf("adding step in database for recipe %d: %w", id, err)
+		return err
 	}
 
 	s.RecipeID = recipeID
@@ -30,6 +96,113 @@ func (app *Application) AddStepToRecipe(ctx context.Context, recipeID int, s *St
 	return nil
 }
 
+// MoveStep moves a step to the given position within its recipe, starting at 1.
+// Positions outside the range of existing steps move the step to the beginning or the end.
+// If the step belongs to another recipe, a not found error is returned.
+func (app *Application) MoveStep(ctx context.Context, recipeID, stepID, position int) error {
+	return app.inTx(ctx, func(q *queries.Queries) error {
+		step, err := getRecipeStep(ctx, q, recipeID, stepID)
+		if err != nil {
+			return err
+		}
+
+		stepIDs, err := q.GetStepIDsForRecipe(ctx, step.RecipeID)
+		if err != nil {
+			return fmt.Errorf("querying steps of recipe %d: %w", step.RecipeID, err)
+		}
+		if err := setStepOrder(ctx, q, step.RecipeID, moveTo(stepIDs, step.ID, position)); err != nil {
+			return err
+		}
+
+		return recordRevision(ctx, q, int(step.RecipeID))
+	})
+}
+
+// ReorderSteps sets the order of all steps of a recipe. The given IDs must contain every step
+// of the recipe exactly once, otherwise a bad request error is returned.
+func (app *Application) ReorderSteps(ctx context.Context, recipeID int, stepIDs []int) error {
+	return app.inTx(ctx, func(q *queries.Queries) error {
+		current, err := q.GetStepIDsForRecipe(ctx, int64(recipeID))
+		if err != nil {
+			return fmt.Errorf("querying steps of recipe %d: %w", recipeID, err)
+		}
+
+		order := make([]int64, 0, len(stepIDs))
+		seen := make(map[int64]bool, len(stepIDs))
+		for _, id := range stepIDs {
+			if seen[int64(id)] || indexOf(current, int64(id)) < 0 {
+				return resperr.New(http.StatusBadRequest, "step %d is not part of recipe %d or given twice", id, recipeID)
+			}
+			seen[int64(id)] = true
+			order = append(order, int64(id))
+		}
+		if len(order) != len(current) {
+			return resperr.New(http.StatusBadRequest, "the order must contain all %d steps of recipe %d", len(current), recipeID)
+		}
+
+		if err := setStepOrder(ctx, q, int64(recipeID), order); err != nil {
+			return err
+		}
+		return recordRevision(ctx, q, recipeID)
+	})
+}
+
+// setStepOrder rewrites the sort order of all given steps according to their position in stepIDs.
+func setStepOrder(ctx context.Context, q *queries.Queries, recipeID int64, stepIDs []int64) error {
+	if err := q.SetStepSortOrder(ctx, queries.SetStepSortOrderParams{
+		StepIds:  stepIDs,
+		RecipeID: recipeID,
+	}); err != nil {
+		return fmt.Errorf("updating order of steps for recipe %d: %w", recipeID, err)
+	}
+	return nil
+}
+
+// getRecipeStep returns a step of a recipe. If it does not exist or belongs to another recipe, a not found error
+// is returned, so that the ID of a step can't be used to change another recipe than the one of the URL.
+func getRecipeStep(ctx context.Context, q *queries.Queries, recipeID, stepID int) (queries.Step, error) {
+	step, err := q.GetStepByID(ctx, int64(stepID))
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && step.RecipeID != int64(recipeID)) {
+		return queries.Step{}, resperr.WithCodeAndMessage(fmt.Errorf("step %d is not part of recipe %d", stepID, recipeID),
+			http.StatusNotFound, "step does not exist")
+	}
+	if err != nil {
+		return queries.Step{}, fmt.Errorf("querying step %d: %w", stepID, err)
+	}
+	return step, nil
+}
+
+// moveTo returns a copy of ids, in which id is moved to the given position, starting at 1.
+// The position is clamped to the valid range.
+func moveTo(ids []int64, id int64, position int) []int64 {
+	res := make([]int64, 0, len(ids))
+	for _, v := range ids {
+		if v != id {
+			res = append(res, v)
+		}
+	}
+
+	index := position - 1
+	if index < 0 {
+		index = 0
+	}
+	if index > len(res) {
+		index = len(res)
+	}
+
+	res = append(res[:index], append([]int64{id}, res[index:]...)...)
+	return res
+}
+
+func indexOf(ids []int64, id int64) int {
+	for i, v := range ids {
+		if v == id {
+			return i
+		}
+	}
+	return -1
+}
+
 // GetStepByID returns a step by its ID. If it does not exist, a not found error is returned.
 func (app *Application) GetStepByID(ctx context.Context, id int) (Step, error) {
 	step, err := app.Queries.GetStepByID(ctx, int64(id))
@@ -40,36 +213,110 @@ func (app *Application) GetStepByID(ctx context.Context, id int) (Step, error) {
 		return Step{}, fmt.Errorf("querying step %d: %w", id, err)
 	}
 
-	return Step{
+	images, err := app.Queries.GetImagesForStep(ctx, sql.NullInt64{Int64: int64(id), Valid: true})
+	if err != nil {
+		return Step{}, fmt.Errorf("querying images for step %d: %w", id, err)
+	}
+
+	ingredients, err := app.getStepIngredients(ctx, int(step.ID), int(step.RecipeID))
+	if err != nil {
+		return Step{}, err
+	}
+
+	res := Step{
 		ID:          int(step.ID),
 		RecipeID:    int(step.RecipeID),
 		Instruction: step.Instruction,
 		Time:        pghelper.ToDuration(step.Time),
-	}, nil
+		Ingredients: ingredients,
+	}
+	for _, i := range images {
+		res.Images = append(res.Images, imageFromDB(i))
+	}
+
+	return res, nil
 }
 
-// UpdateStep updates an existing step.
+// UpdateStep updates an existing step of the recipe s.RecipeID. If it does not exist or belongs to another recipe,
+// a not found error is returned. Invalid values result in a [ValidationError].
 func (app *Application) UpdateStep(ctx context.Context, s Step) error {
-	if err := app.Queries.UpdateStepByID(ctx, queries.UpdateStepByIDParams{
-		ID:          int64(s.ID),
-		Instruction: s.Instruction,
-		Time:        pghelper.Interval(s.Time),
-	}); err != nil {
-		return fmt.Errorf("updating step %d: %w", s.ID, err)
+	if err := validateStep(&s); err != nil {
+		return err
 	}
 
-	return nil
+	return app.inTx(ctx, func(q *queries.Queries) error {
+		step, err := getRecipeStep(ctx, q, s.RecipeID, s.ID)
+		if err != nil {
+			return err
+		}
+
+		if err := q.UpdateStepByID(ctx, queries.UpdateStepByIDParams{
+			ID:          int64(s.ID),
+			Instruction: s.Instruction,
+			Time:        pghelper.Interval(s.Time),
+		}); err != nil {
+			if verr := fieldErrorFromDB(err); verr != nil {
+				return verr
+			}
+			return fmt.Errorf("updating step %d: %w", s.ID, err)
+		}
+
+		return recordRevision(ctx, q, int(step.RecipeID))
+	})
 }
 
-// DeleteRecipeStepByID deletes a recipe step by its ID.
+// DeleteRecipeStepByID deletes a step of a recipe by its ID, including its images.
 // This is an idempotent action, if the step is already deleted, no error is returned.
-func (app *Application) DeleteRecipeStepByID(ctx context.Context, id int) error {
-	if err := app.Queries.DeleteStepByID(ctx, int64(id)); err != nil {
-		return fmt.Errorf("deleting step %d: %w", id, err)
+// If it belongs to another recipe, a not found error is returned.
+func (app *Application) DeleteRecipeStepByID(ctx context.Context, recipeID, id int) error {
+	var images []queries.Image
+	err := app.inTx(ctx, func(q *queries.Queries) error {
+		step, err := q.GetStepByID(ctx, int64(id))
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return nil
+			}
+			return fmt.Errorf("querying step %d: %w", id, err)
+		}
+		if step.RecipeID != int64(recipeID) {
+			return resperr.New(http.StatusNotFound, "step %d is not part of recipe %d", id, recipeID)
+		}
+
+		// The image rows are deleted by the database, but the files must be removed by us.
+		images, err = q.GetImagesForStep(ctx, sql.NullInt64{Int64: int64(id), Valid: true})
+		if err != nil {
+			return fmt.Errorf("querying images for step %d: %w", id, err)
+		}
+
+		if err := q.DeleteStepByID(ctx, int64(id)); err != nil {
+			return fmt.Errorf("deleting step %d: %w", id, err)
+		}
+
+		return recordRevision(ctx, q, int(step.RecipeID))
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, i := range images {
+		app.deleteImageVariants(ctx, i.StorageKey, int(i.Width))
 	}
 	return nil
 }
 
+// validateStep checks the fields of s that users can change. Like in the database, the instruction must not be
+// empty, but whitespace is not accepted either.
+func validateStep(s *Step) error {
+	errs := FieldErrors{}
+	if strings.TrimSpace(s.Instruction) == "" {
+		errs["Instruction"] = "validation.required"
+	}
+	if s.Time < 0 {
+		errs["Time"] = "validation.negative_duration"
+	}
+	return errs.Err()
+}
+
 type AddIngredientToStepParams struct {
 	StepID       int
 	IngredientID int
@@ -80,29 +327,34 @@ type AddIngredientToStepParams struct {
 
 // AddIngredientToStep adds an ingredient to a step.
 func (app *Application) AddIngredientToStep(ctx context.Context, params AddIngredientToStepParams) error {
-	if err := app.Queries.AddIngredientToStep(ctx, queries.AddIngredientToStepParams{
-		StepID:        int64(params.StepID),
-		IngredientsID: int64(params.IngredientID),
-		UnitID:        int64(valueOrDefault(params.UnitID)),
-		Amount:        pghelper.Numeric(params.Amount),
-		Note:          params.Note,
-	}); err != nil {
-		var pgerr *pgconn.PgError
-		if !errors.As(err, &pgerr) {
-			return fmt.Errorf("adding ingredient to step %d: %w", params.StepID, err)
+	return app.inTx(ctx, func(q *queries.Queries) error {
+		step, err := q.GetStepByID(ctx, int64(params.StepID))
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return resperr.WithCodeAndMessage(err, http.StatusNotFound, "step does not exist")
+			}
+			return fmt.Errorf("querying step %d: %w", params.StepID, err)
 		}
 
-		switch pgerr.ConstraintName {
-		case "step_ingredients_step_id_fkey":
-			return resperr.WithCodeAndMessage(err, http.StatusNotFound, "step does not exist")
-		case "step_ingredients_ingredients_id_fkey":
-			return resperr.WithCodeAndMessage(err, http.StatusBadRequest, "ingredient does not exist")
+		if err := q.AddIngredientToStep(ctx, queries.AddIngredientToStepParams{
+			StepID:        int64(params.StepID),
+			IngredientsID: int64(params.IngredientID),
+			UnitID:        int64(valueOrDefault(params.UnitID)),
+			Amount:        pghelper.Numeric(params.Amount),
+			Note:          params.Note,
+		}); err != nil {
+			var pgerr *pgconn.PgError
+			if errors.As(err, &pgerr) && pgerr.ConstraintName == "step_ingredients_step_id_fkey" {
+				return resperr.WithCodeAndMessage(err, http.StatusNotFound, "step does not exist")
+			}
+			if verr := fieldErrorFromDB(err); verr != nil {
+				return verr
+			}
+			return fmt.Errorf("adding ingredient to step %d: %w", params.StepID, err)
 		}
 
-		return fmt.Errorf("adding ingredient to step %d: %w", params.StepID, err)
-	}
-
-	return nil
+		return recordRevision(ctx, q, int(step.RecipeID))
+	})
 }
 
 type DeleteIngredientFromStepParams struct {
@@ -113,14 +365,152 @@ type DeleteIngredientFromStepParams struct {
 // DeleteIngredientFromStep deletes an ingredient from a step. The ingredient itself is unaffected.
 // This action is idempotent, if the step is already deleted, no error is returned.
 func (app *Application) DeleteIngredientFromStep(ctx context.Context, params DeleteIngredientFromStepParams) error {
-	if err := app.Queries.DeleteIngredientFromStep(ctx, queries.DeleteIngredientFromStepParams{
-		StepID:        int64(params.StepID),
-		IngredientsID: int64(params.IngredientID),
-	}); err != nil {
-		return fmt.Errorf("deleting ingredient %d from step %d: %w", params.IngredientID, params.StepID, err)
+	return app.inTx(ctx, func(q *queries.Queries) error {
+		step, err := q.GetStepByID(ctx, int64(params.StepID))
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return nil
+			}
+			return fmt.Errorf("querying step %d: %w", params.StepID, err)
+		}
+
+		if err := q.DeleteIngredientFromStep(ctx, queries.DeleteIngredientFromStepParams{
+			StepID:        int64(params.StepID),
+			IngredientsID: int64(params.IngredientID),
+		}); err != nil {
+			return fmt.Errorf("deleting ingredient %d from step %d: %w", params.IngredientID, params.StepID, err)
+		}
+
+		return recordRevision(ctx, q, int(step.RecipeID))
+	})
+}
+
+type UpdateStepIngredientParams struct {
+	RecipeID     int
+	StepID       int
+	IngredientID int
+	UnitID       *int
+	Amount       float64
+	Note         string
+}
+
+// UpdateStepIngredient changes the amount, unit and note of an ingredient within a step of the recipe.
+// If the step is not part of the recipe or the ingredient is not part of the step, a not found error is returned.
+func (app *Application) UpdateStepIngredient(ctx context.Context, params UpdateStepIngredientParams) error {
+	return app.inTx(ctx, func(q *queries.Queries) error {
+		step, err := getRecipeStep(ctx, q, params.RecipeID, params.StepID)
+		if err != nil {
+			return err
+		}
+
+		n, err := q.UpdateStepIngredient(ctx, queries.UpdateStepIngredientParams{
+			UnitID:        int64(valueOrDefault(params.UnitID)),
+			Amount:        pghelper.Numeric(params.Amount),
+			Note:          params.Note,
+			StepID:        int64(params.StepID),
+			IngredientsID: int64(params.IngredientID),
+		})
+		if err != nil {
+			if verr := fieldErrorFromDB(err); verr != nil {
+				return verr
+			}
+			return fmt.Errorf("updating ingredient %d of step %d: %w", params.IngredientID, params.StepID, err)
+		}
+		if n == 0 {
+			return resperr.New(http.StatusNotFound, "ingredient %d is not part of step %d", params.IngredientID, params.StepID)
+		}
+
+		return recordRevision(ctx, q, int(step.RecipeID))
+	})
+}
+
+// GetStepIngredient returns a single ingredient of a step.
+func (app *Application) GetStepIngredient(ctx context.Context, stepID, ingredientID int) (Ingredient, error) {
+	step, err := app.Queries.GetStepByID(ctx, int64(stepID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Ingredient{}, resperr.WithStatusCode(err, http.StatusNotFound)
+		}
+		return Ingredient{}, fmt.Errorf("querying step %d: %w", stepID, err)
 	}
 
-	return nil
+	ingredients, err := app.getStepIngredients(ctx, stepID, int(step.RecipeID))
+	if err != nil {
+		return Ingredient{}, err
+	}
+	for _, i := range ingredients {
+		if i.ID == ingredientID {
+			return i, nil
+		}
+	}
+	return Ingredient{}, resperr.New(http.StatusNotFound, "ingredient %d is not part of step %d", ingredientID, stepID)
+}
+
+// ReorderStepIngredients sets the order of the ingredients within a step of the recipe. The given IDs must contain
+// every ingredient of the step exactly once, otherwise a bad request error is returned. If the step is not part
+// of the recipe, a not found error is returned.
+func (app *Application) ReorderStepIngredients(ctx context.Context, recipeID, stepID int, ingredientIDs []int) error {
+	return app.inTx(ctx, func(q *queries.Queries) error {
+		step, err := getRecipeStep(ctx, q, recipeID, stepID)
+		if err != nil {
+			return err
+		}
+
+		current, err := q.GetStepIngredients(ctx, step.ID)
+		if err != nil {
+			return fmt.Errorf("querying ingredients of step %d: %w", stepID, err)
+		}
+		currentIDs := make([]int64, 0, len(current))
+		for _, i := range current {
+			currentIDs = append(currentIDs, i.ID)
+		}
+
+		order := make([]int64, 0, len(ingredientIDs))
+		seen := make(map[int64]bool, len(ingredientIDs))
+		for _, id := range ingredientIDs {
+			if seen[int64(id)] || indexOf(currentIDs, int64(id)) < 0 {
+				return resperr.New(http.StatusBadRequest, "ingredient %d is not part of step %d or given twice", id, stepID)
+			}
+			seen[int64(id)] = true
+			order = append(order, int64(id))
+		}
+		if len(order) != len(currentIDs) {
+			return resperr.New(http.StatusBadRequest, "the order must contain all %d ingredients of step %d", len(currentIDs), stepID)
+		}
+
+		if err := q.SetStepIngredientSortOrder(ctx, queries.SetStepIngredientSortOrderParams{
+			IngredientIds: order,
+			StepID:        step.ID,
+		}); err != nil {
+			return fmt.Errorf("updating order of ingredients for step %d: %w", stepID, err)
+		}
+
+		return recordRevision(ctx, q, int(step.RecipeID))
+	})
+}
+
+// getStepIngredients returns the ingredients of a step in their order.
+func (app *Application) getStepIngredients(ctx context.Context, stepID, recipeID int) ([]Ingredient, error) {
+	rows, err := app.Queries.GetStepIngredients(ctx, int64(stepID))
+	if err != nil {
+		return nil, fmt.Errorf("querying ingredients for step %d: %w", stepID, err)
+	}
+
+	var res []Ingredient
+	for _, row := range rows {
+		i := Ingredient{
+			ID:       int(row.ID),
+			Name:     row.Name,
+			Note:     row.Note,
+			UnitID:   int(row.UnitID.Int64),
+			UnitName: row.UnitName.String,
+			RecipeID: recipeID,
+			StepID:   stepID,
+		}
+		_ = row.Amount.AssignTo(&i.Amount)
+		res = append(res, i)
+	}
+	return res, nil
 }
 
 // valueOrDefault returns the value for v or the default value of T otherwise.
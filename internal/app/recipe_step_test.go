@@ -1,6 +1,7 @@
 package app
 
 import (
+	"errors"
 	"net/http"
 	"testing"
 	"time"
@@ -132,13 +133,22 @@ func TestApplication_DeleteRecipeStepByID(t *testing.T) {
 	recipe := app.AddEmptyRecipe(ctx)
 
 	t.Run("step deletion is idempotent", func(t *testing.T) {
-		err := app.DeleteRecipeStepByID(ctx, -1)
+		err := app.DeleteRecipeStepByID(ctx, recipe.ID, -1)
 		code := resperr.StatusCode(err)
 		assert.Equal(t, http.StatusOK, code)
 	})
+	t.Run("step of another recipe is rejected", func(t *testing.T) {
+		other := app.AddEmptyRecipe(ctx)
+		step := app.AddTestStep(ctx, other.ID)
+		err := app.DeleteRecipeStepByID(ctx, recipe.ID, step.ID)
+		assert.Equal(t, http.StatusNotFound, resperr.StatusCode(err))
+
+		_, err = app.GetStepByID(ctx, step.ID)
+		assert.NoError(t, err)
+	})
 	t.Run("step is deleted", func(t *testing.T) {
 		step := app.AddTestStep(ctx, recipe.ID)
-		err := app.DeleteRecipeStepByID(ctx, step.ID)
+		err := app.DeleteRecipeStepByID(ctx, recipe.ID, step.ID)
 		assert.NoError(t, err)
 
 		var count int
@@ -196,6 +206,31 @@ func TestApplication_UpdateStep(t *testing.T) {
 			testhelper.PartialEqual(t, tt.want, dbStep)
 		})
 	}
+
+	t.Run("invalid values", func(t *testing.T) {
+		step := app.AddTestStep(ctx, recipe.ID)
+		step.Instruction = " \n"
+		step.Time = -time.Minute
+
+		err := app.UpdateStep(ctx, step)
+		var verr *ValidationError
+		assert.True(t, errors.As(err, &verr))
+		assert.Equal(t, FieldErrors{"Instruction": "validation.required", "Time": "validation.negative_duration"}, verr.Fields)
+	})
+
+	t.Run("step of another recipe is rejected", func(t *testing.T) {
+		step := app.AddTestStep(ctx, recipe.ID)
+		instruction := step.Instruction
+		step.RecipeID = app.AddEmptyRecipe(ctx).ID
+		step.Instruction = "changed"
+
+		err := app.UpdateStep(ctx, step)
+		assert.Equal(t, http.StatusNotFound, resperr.StatusCode(err))
+
+		dbStep, err := app.Queries.GetStepByID(ctx, int64(step.ID))
+		assert.NoError(t, err)
+		assert.Equal(t, instruction, dbStep.Instruction)
+	})
 }
 
 func TestApplication_GetStepByID(t *testing.T) {
@@ -217,3 +252,155 @@ func TestApplication_GetStepByID(t *testing.T) {
 		assert.Equal(t, testStep, got)
 	})
 }
+
+func TestApplication_ReorderSteps(t *testing.T) {
+	t.Parallel()
+	app, ctx := newApp(t), testhelper.Context(t)
+	recipe := app.AddEmptyRecipe(ctx)
+
+	first := app.AddTestStep(ctx, recipe.ID)
+	second := app.AddTestStep(ctx, recipe.ID)
+	third := app.AddTestStep(ctx, recipe.ID)
+
+	stepIDs := func() []int {
+		r, err := app.GetSingleRecipe(ctx, recipe.ID)
+		assert.NoError(t, err)
+		var ids []int
+		for _, s := range r.Steps {
+			ids = append(ids, s.ID)
+		}
+		return ids
+	}
+
+	err := app.ReorderSteps(ctx, recipe.ID, []int{third.ID, first.ID, second.ID})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{third.ID, first.ID, second.ID}, stepIDs())
+
+	err = app.MoveStep(ctx, recipe.ID, third.ID, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{first.ID, second.ID, third.ID}, stepIDs())
+
+	inserted := &Step{Instruction: testhelper.RandomString(10)}
+	err = app.InsertStepAfter(ctx, recipe.ID, first.ID, inserted)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{first.ID, inserted.ID, second.ID, third.ID}, stepIDs())
+
+	t.Run("step of another recipe is rejected", func(t *testing.T) {
+		other := app.AddEmptyRecipe(ctx)
+		err := app.InsertStepAfter(ctx, other.ID, first.ID, &Step{Instruction: testhelper.RandomString(10)})
+		assert.Equal(t, http.StatusNotFound, resperr.StatusCode(err))
+		err = app.MoveStep(ctx, other.ID, first.ID, 4)
+		assert.Equal(t, http.StatusNotFound, resperr.StatusCode(err))
+		assert.Equal(t, []int{first.ID, inserted.ID, second.ID, third.ID}, stepIDs())
+
+		r, err := app.GetSingleRecipe(ctx, other.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, len(r.Steps))
+	})
+
+	t.Run("incomplete order is rejected", func(t *testing.T) {
+		err := app.ReorderSteps(ctx, recipe.ID, []int{first.ID, second.ID})
+		assert.Equal(t, http.StatusBadRequest, resperr.StatusCode(err))
+
+		err = app.ReorderSteps(ctx, recipe.ID, []int{first.ID, first.ID, second.ID, third.ID})
+		assert.Equal(t, http.StatusBadRequest, resperr.StatusCode(err))
+	})
+	t.Run("missing step returns not found error", func(t *testing.T) {
+		err := app.MoveStep(ctx, recipe.ID, -1, 1)
+		assert.Equal(t, http.StatusNotFound, resperr.StatusCode(err))
+	})
+}
+
+func TestMoveTo(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		id       int64
+		position int
+		want     []int64
+	}{
+		{name: "to the front", id: 3, position: 1, want: []int64{3, 1, 2}},
+		{name: "to the end", id: 1, position: 3, want: []int64{2, 3, 1}},
+		{name: "same position", id: 2, position: 2, want: []int64{1, 2, 3}},
+		{name: "position too small", id: 2, position: 0, want: []int64{2, 1, 3}},
+		{name: "position too large", id: 2, position: 10, want: []int64{1, 3, 2}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, moveTo([]int64{1, 2, 3}, tt.id, tt.position))
+		})
+	}
+}
+
+func TestApplication_UpdateStepIngredient(t *testing.T) {
+	t.Parallel()
+	app, ctx := newApp(t), testhelper.Context(t)
+
+	first, err := app.AddIngredient(ctx, testhelper.RandomString(10))
+	assert.NoError(t, err)
+	second, err := app.AddIngredient(ctx, testhelper.RandomString(10))
+	assert.NoError(t, err)
+
+	recipe := app.AddEmptyRecipe(ctx)
+	step := app.AddTestStep(ctx, recipe.ID)
+	for _, i := range []Ingredient{first, second} {
+		err := app.AddIngredientToStep(ctx, AddIngredientToStepParams{StepID: step.ID, IngredientID: i.ID, Amount: 1})
+		assert.NoError(t, err)
+	}
+
+	err = app.UpdateStepIngredient(ctx, UpdateStepIngredientParams{
+		RecipeID:     recipe.ID,
+		StepID:       step.ID,
+		IngredientID: first.ID,
+		Amount:       2.5,
+		Note:         "gesiebt",
+	})
+	assert.NoError(t, err)
+
+	got, err := app.GetStepIngredient(ctx, step.ID, first.ID)
+	assert.NoError(t, err)
+	testhelper.PartialEqual(t, Ingredient{ID: first.ID, Amount: 2.5, Note: "gesiebt"}, got)
+
+	err = app.ReorderStepIngredients(ctx, recipe.ID, step.ID, []int{second.ID, first.ID})
+	assert.NoError(t, err)
+
+	got2, err := app.GetStepByID(ctx, step.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(got2.Ingredients))
+	assert.Equal(t, second.ID, got2.Ingredients[0].ID)
+	assert.Equal(t, first.ID, got2.Ingredients[1].ID)
+
+	t.Run("adding an ingredient twice returns conflict error", func(t *testing.T) {
+		err := app.AddIngredientToStep(ctx, AddIngredientToStepParams{StepID: step.ID, IngredientID: first.ID, Amount: 1})
+		assert.Equal(t, http.StatusConflict, resperr.StatusCode(err))
+	})
+	t.Run("missing ingredient returns not found error", func(t *testing.T) {
+		err := app.UpdateStepIngredient(ctx, UpdateStepIngredientParams{RecipeID: recipe.ID, StepID: step.ID, IngredientID: -1})
+		assert.Equal(t, http.StatusNotFound, resperr.StatusCode(err))
+	})
+	t.Run("incomplete order is rejected", func(t *testing.T) {
+		err := app.ReorderStepIngredients(ctx, recipe.ID, step.ID, []int{first.ID})
+		assert.Equal(t, http.StatusBadRequest, resperr.StatusCode(err))
+	})
+	t.Run("step of another recipe is rejected", func(t *testing.T) {
+		other := app.AddEmptyRecipe(ctx)
+		err := app.UpdateStepIngredient(ctx, UpdateStepIngredientParams{
+			RecipeID:     other.ID,
+			StepID:       step.ID,
+			IngredientID: first.ID,
+			Amount:       5,
+		})
+		assert.Equal(t, http.StatusNotFound, resperr.StatusCode(err))
+		err = app.ReorderStepIngredients(ctx, other.ID, step.ID, []int{first.ID, second.ID})
+		assert.Equal(t, http.StatusNotFound, resperr.StatusCode(err))
+
+		got, err := app.GetStepByID(ctx, step.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, second.ID, got.Ingredients[0].ID)
+		assert.Equal(t, 2.5, got.Ingredients[1].Amount)
+	})
+}
@@ -1,10 +1,14 @@
 package app
 
 import (
+	"errors"
+	"net/http"
 	"testing"
+	"time"
 
 	"codeberg.org/mahlzeit/mahlzeit/internal/testhelper"
 	"github.com/alecthomas/assert/v2"
+	"github.com/carlmjohnson/resperr"
 )
 
 func TestApplication_GetAllRecipes(t *testing.T) {
@@ -92,6 +96,19 @@ func TestApplication_UpdateRecipe(t *testing.T) {
 				Description: t.Name(),
 			},
 		},
+		{
+			name: "times and source",
+			updateFn: func(r *Recipe) {
+				r.WorkingTime = 90 * time.Minute
+				r.WaitingTime = time.Hour
+				r.Source = "https://example.org/zwiebelkuchen"
+			},
+			want: Recipe{
+				WorkingTime: 90 * time.Minute,
+				WaitingTime: time.Hour,
+				Source:      "https://example.org/zwiebelkuchen",
+			},
+		},
 	}
 	for _, tt := range tests {
 		tt := tt
@@ -110,3 +127,95 @@ func TestApplication_UpdateRecipe(t *testing.T) {
 		})
 	}
 }
+
+func TestApplication_UpdateRecipeValidation(t *testing.T) {
+	t.Parallel()
+	app, ctx := newApp(t), testhelper.Context(t)
+	other := app.AddEmptyRecipe(ctx)
+
+	tests := []struct {
+		name       string
+		updateFn   func(r *Recipe)
+		wantFields FieldErrors
+		wantCode   int
+	}{
+		{
+			name:       "empty name",
+			updateFn:   func(r *Recipe) { r.Name = " " },
+			wantFields: FieldErrors{"Name": "validation.required"},
+			wantCode:   http.StatusBadRequest,
+		},
+		{
+			name:       "name of another recipe",
+			updateFn:   func(r *Recipe) { r.Name = other.Name },
+			wantFields: FieldErrors{"Name": "validation.name_taken"},
+			wantCode:   http.StatusConflict,
+		},
+		{
+			name: "servings and times",
+			updateFn: func(r *Recipe) {
+				r.Servings = 0
+				r.WaitingTime = -time.Minute
+			},
+			wantFields: FieldErrors{"Servings": "validation.min_servings", "WaitingTime": "validation.negative_duration"},
+			wantCode:   http.StatusBadRequest,
+		},
+		{
+			name:       "unsupported language",
+			updateFn:   func(r *Recipe) { r.Language = "xx" },
+			wantFields: FieldErrors{"Language": "validation.unsupported_language"},
+			wantCode:   http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			recipe := app.AddEmptyRecipe(ctx)
+			tt.updateFn(&recipe)
+
+			err := app.UpdateRecipe(ctx, &recipe)
+			var verr *ValidationError
+			assert.True(t, errors.As(err, &verr))
+			assert.Equal(t, tt.wantFields, verr.Fields)
+			assert.Equal(t, tt.wantCode, resperr.StatusCode(err))
+		})
+	}
+}
+
+func TestRecipe_TotalTime(t *testing.T) {
+	t.Parallel()
+
+	r := Recipe{
+		WorkingTime: 30 * time.Minute,
+		WaitingTime: time.Hour,
+		Steps:       []Step{{Time: 10 * time.Minute}, {}, {Time: 5 * time.Minute}},
+	}
+	assert.Equal(t, 105*time.Minute, r.TotalTime())
+}
+
+func TestRecipe_SourceURL(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{source: "https://example.org/rezept", want: "https://example.org/rezept"},
+		{source: " http://example.org ", want: "http://example.org"},
+		{source: "Omas Kochbuch, Seite 12"},
+		{source: "example.org/rezept"},
+		{source: "javascript:alert(1)"},
+		{source: ""},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.source, func(t *testing.T) {
+			t.Parallel()
+
+			r := Recipe{Source: tt.source}
+			assert.Equal(t, tt.want, r.SourceURL())
+		})
+	}
+}
@@ -0,0 +1,534 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"codeberg.org/mahlzeit/mahlzeit/db/queries"
+	"codeberg.org/mahlzeit/mahlzeit/internal/duration"
+	"codeberg.org/mahlzeit/mahlzeit/internal/i18n"
+	"codeberg.org/mahlzeit/mahlzeit/internal/pghelper"
+	"codeberg.org/mahlzeit/mahlzeit/internal/textdiff"
+	"github.com/carlmjohnson/resperr"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
+)
+
+// recordRevision stores the current state of a recipe as a new revision. The author is taken from ctx.
+// It must be called with the same queries as the change itself, so that both are part of the same transaction.
+func recordRevision(ctx context.Context, q *queries.Queries, recipeID int) error {
+	if err := q.AddRecipeRevision(ctx, queries.AddRecipeRevisionParams{
+		CreatedBy: int64(UserIDFromContext(ctx)),
+		RecipeID:  int64(recipeID),
+	}); err != nil {
+		return fmt.Errorf("recording revision for recipe %d: %w", recipeID, err)
+	}
+	return nil
+}
+
+// GetRecipeHistory returns all revisions of a recipe, starting with the latest one.
+func (app *Application) GetRecipeHistory(ctx context.Context, recipeID int) (*RecipeHistory, error) {
+	recipe, err := app.Queries.GetRecipeByID(ctx, int64(recipeID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, resperr.WithCodeAndMessage(err, http.StatusNotFound, "recipe does not exist")
+		}
+		return nil, fmt.Errorf("querying recipe %d: %w", recipeID, err)
+	}
+
+	revisions, err := app.Queries.GetRecipeRevisions(ctx, int64(recipeID))
+	if err != nil {
+		return nil, fmt.Errorf("querying revisions for recipe %d: %w", recipeID, err)
+	}
+
+	res := &RecipeHistory{
+		RecipeID:   recipeID,
+		RecipeName: recipe.Name,
+	}
+	for _, r := range revisions {
+		res.Revisions = append(res.Revisions, Revision{
+			ID:        int(r.ID),
+			RecipeID:  recipeID,
+			CreatedAt: r.CreatedAt,
+			Author:    r.AuthorName.String,
+		})
+	}
+
+	return res, nil
+}
+
+// GetRevision returns a single revision of a recipe, including all changes compared to the previous revision.
+// The values of the changes, e.g. durations and amounts, are formatted for the locale.
+func (app *Application) GetRevision(ctx context.Context, recipeID, revisionID int, locale string) (*RevisionDetails, error) {
+	row, err := app.Queries.GetRecipeRevision(ctx, queries.GetRecipeRevisionParams{
+		RecipeID: int64(recipeID),
+		ID:       int64(revisionID),
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, resperr.WithCodeAndMessage(err, http.StatusNotFound, "revision does not exist")
+		}
+		return nil, fmt.Errorf("querying revision %d of recipe %d: %w", revisionID, recipeID, err)
+	}
+
+	content, err := decodeRevisionContent(row.Content)
+	if err != nil {
+		return nil, fmt.Errorf("decoding revision %d: %w", revisionID, err)
+	}
+	previous, err := decodeRevisionContent(row.PreviousContent)
+	if err != nil {
+		return nil, fmt.Errorf("decoding predecessor of revision %d: %w", revisionID, err)
+	}
+
+	return &RevisionDetails{
+		Revision: Revision{
+			ID:        int(row.ID),
+			RecipeID:  recipeID,
+			CreatedAt: row.CreatedAt,
+			Author:    row.AuthorName.String,
+		},
+		RecipeName: content.Name,
+		IsFirst:    row.PreviousContent.Status != pgtype.Present,
+		Changes:    diffRevisions(previous, content, i18n.New(locale)),
+	}, nil
+}
+
+// RestoreRevision resets a recipe, its steps and their ingredients to the state of the given revision.
+// Steps that were deleted in the meantime are added again, steps that were added are deleted, including
+// their images. The restoration itself is recorded as a new revision.
+func (app *Application) RestoreRevision(ctx context.Context, recipeID, revisionID int) error {
+	var removedImages []queries.Image
+
+	err := app.inTx(ctx, func(q *queries.Queries) error {
+		row, err := q.GetRecipeRevision(ctx, queries.GetRecipeRevisionParams{
+			RecipeID: int64(recipeID),
+			ID:       int64(revisionID),
+		})
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return resperr.WithCodeAndMessage(err, http.StatusNotFound, "revision does not exist")
+			}
+			return fmt.Errorf("querying revision %d of recipe %d: %w", revisionID, recipeID, err)
+		}
+
+		content, err := decodeRevisionContent(row.Content)
+		if err != nil {
+			return fmt.Errorf("decoding revision %d: %w", revisionID, err)
+		}
+
+		if err := q.RestoreRecipe(ctx, queries.RestoreRecipeParams{
+			Name:                content.Name,
+			Description:         content.Description,
+			WorkingTime:         content.WorkingTime.interval(),
+			WaitingTime:         content.WaitingTime.interval(),
+			Source:              nullString(content.Source),
+			Servings:            int32(content.Servings),
+			ServingsDescription: content.ServingsDescription,
+			ID:                  int64(recipeID),
+		}); err != nil {
+			var pgerr *pgconn.PgError
+			if errors.As(err, &pgerr) && pgerr.ConstraintName == "recipes_name_key" {
+				return resperr.WithCodeAndMessage(err, http.StatusConflict, "another recipe with the same name exists already")
+			}
+			return fmt.Errorf("restoring recipe %d: %w", recipeID, err)
+		}
+
+		stepIDs := make([]int64, 0, len(content.Steps))
+		kept := make(map[int64]bool, len(content.Steps))
+		for _, s := range content.Steps {
+			stepIDs = append(stepIDs, int64(s.ID))
+			kept[int64(s.ID)] = true
+		}
+
+		// The images of deleted steps are removed by the database, but the files must be removed by us.
+		images, err := q.GetImagesForRecipe(ctx, int64(recipeID))
+		if err != nil {
+			return fmt.Errorf("querying images for recipe %d: %w", recipeID, err)
+		}
+		for _, i := range images {
+			if i.StepID.Valid && !kept[i.StepID.Int64] {
+				removedImages = append(removedImages, i)
+			}
+		}
+
+		if err := q.DeleteStepsExcept(ctx, queries.DeleteStepsExceptParams{
+			RecipeID: int64(recipeID),
+			StepIds:  stepIDs,
+		}); err != nil {
+			return fmt.Errorf("deleting steps of recipe %d: %w", recipeID, err)
+		}
+		if err := q.DeleteStepIngredientsForRecipe(ctx, int64(recipeID)); err != nil {
+			return fmt.Errorf("deleting step ingredients of recipe %d: %w", recipeID, err)
+		}
+
+		for i, s := range content.Steps {
+			if err := q.RestoreStep(ctx, queries.RestoreStepParams{
+				ID:          int64(s.ID),
+				RecipeID:    int64(recipeID),
+				SortOrder:   int32(i + 1),
+				Instruction: s.Instruction,
+				Time:        s.Time.interval(),
+			}); err != nil {
+				return fmt.Errorf("restoring step %d: %w", s.ID, err)
+			}
+
+			for _, ingredient := range s.Ingredients {
+				// Ingredients are referenced by their name, in case the ID changed in the meantime.
+				ingredientID, err := q.AddIngredient(ctx, ingredient.Name)
+				if err != nil {
+					return fmt.Errorf("restoring ingredient %q: %w", ingredient.Name, err)
+				}
+
+				// The same applies to units, which may have been deleted since.
+				var unitID int64
+				if ingredient.UnitName != nil {
+					unitID, err = q.AddUnit(ctx, *ingredient.UnitName)
+					if err != nil {
+						return fmt.Errorf("restoring unit %q: %w", *ingredient.UnitName, err)
+					}
+				}
+
+				if err := q.AddIngredientToStep(ctx, queries.AddIngredientToStepParams{
+					StepID:        int64(s.ID),
+					IngredientsID: ingredientID,
+					UnitID:        unitID,
+					Amount:        pghelper.Numeric(ingredient.Amount),
+					Note:          ingredient.Note,
+				}); err != nil {
+					return fmt.Errorf("restoring ingredient %q of step %d: %w", ingredient.Name, s.ID, err)
+				}
+			}
+		}
+
+		return recordRevision(ctx, q, recipeID)
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, i := range removedImages {
+		app.deleteImageVariants(ctx, i.StorageKey, int(i.Width))
+	}
+	return nil
+}
+
+// RecipeHistory contains all revisions of a recipe.
+type RecipeHistory struct {
+	RecipeID   int
+	RecipeName string
+	Revisions  []Revision // the latest revision comes first
+}
+
+type Revision struct {
+	ID        int
+	RecipeID  int
+	CreatedAt time.Time
+	Author    string // empty, if the author is unknown
+}
+
+// RevisionDetails contains a revision and all changes that were made with it.
+type RevisionDetails struct {
+	Revision
+	RecipeName string // name of the recipe at the time of the revision
+	IsFirst    bool   // the first revision is compared to an empty recipe
+	Changes    []Change
+}
+
+// ChangeKind describes in which way a part of a recipe was changed.
+type ChangeKind string
+
+const (
+	ChangeAdded    ChangeKind = "added"
+	ChangeRemoved  ChangeKind = "removed"
+	ChangeModified ChangeKind = "modified"
+	ChangeMoved    ChangeKind = "moved"
+)
+
+// Fields of a recipe that are referenced by a [Change].
+const (
+	FieldName                = "name"
+	FieldDescription         = "description"
+	FieldServings            = "servings"
+	FieldServingsDescription = "servings_description"
+	FieldWorkingTime         = "working_time"
+	FieldWaitingTime         = "waiting_time"
+	FieldSource              = "source"
+	FieldStep                = "step"
+	FieldInstruction         = "instruction"
+	FieldStepTime            = "step_time"
+	FieldIngredient          = "ingredient"
+)
+
+// Change is a single difference between two revisions of a recipe.
+type Change struct {
+	Kind    ChangeKind
+	Field   string // one of the Field constants
+	Step    int    // position of the affected step, starting at 1; zero for changes of the recipe itself
+	Subject string // name of the affected ingredient, only set for FieldIngredient
+	Old     string
+	New     string
+	Diff    []textdiff.Segment // word-based difference between Old and New, only set for texts
+}
+
+// revisionContent is the content of a recipe as it's stored in a revision.
+// See the SQL function recipe_snapshot for the structure.
+type revisionContent struct {
+	Name                string         `json:"name"`
+	Description         string         `json:"description"`
+	WorkingTime         nullDuration   `json:"workingTime"`
+	WaitingTime         nullDuration   `json:"waitingTime"`
+	Source              *string        `json:"source"`
+	Servings            int            `json:"servings"`
+	ServingsDescription string         `json:"servingsDescription"`
+	Steps               []revisionStep `json:"steps"`
+}
+
+type revisionStep struct {
+	ID          int                  `json:"id"`
+	Instruction string               `json:"instruction"`
+	Time        nullDuration         `json:"time"`
+	Ingredients []revisionIngredient `json:"ingredients"`
+}
+
+type revisionIngredient struct {
+	ID       int     `json:"id"`
+	Name     string  `json:"name"`
+	UnitID   *int    `json:"unitID"`
+	UnitName *string `json:"unitName"`
+	Amount   float64 `json:"amount"`
+	Note     string  `json:"note"`
+}
+
+// format returns a human-readable representation, e.g. "200 g (fein gehackt)" or "0,5 l" for German.
+func (i revisionIngredient) format(l *i18n.Localizer) string {
+	parts := []string{l.Number(i.Amount)}
+	if i.UnitName != nil {
+		parts = append(parts, *i.UnitName)
+	}
+	if i.Note != "" {
+		parts = append(parts, "("+i.Note+")")
+	}
+	return strings.Join(parts, " ")
+}
+
+// nullDuration is a duration that is stored as seconds. In contrast to a plain duration,
+// null values are kept, so that restored revisions are identical to the original.
+type nullDuration struct {
+	Duration time.Duration
+	Valid    bool
+}
+
+func (d *nullDuration) UnmarshalJSON(b []byte) error {
+	if bytes.Equal(b, []byte("null")) {
+		*d = nullDuration{}
+		return nil
+	}
+
+	var seconds int64
+	if err := json.Unmarshal(b, &seconds); err != nil {
+		return fmt.Errorf("parsing duration: %w", err)
+	}
+	*d = nullDuration{Duration: time.Duration(seconds) * time.Second, Valid: true}
+	return nil
+}
+
+// format returns the duration in the language of l, or an empty string if it's null.
+func (d nullDuration) format(l *i18n.Localizer) string {
+	if !d.Valid {
+		return ""
+	}
+	return duration.Format(d.Duration, l.Locale())
+}
+
+func (d nullDuration) interval() pgtype.Interval {
+	if !d.Valid {
+		return pgtype.Interval{Status: pgtype.Null}
+	}
+	return pghelper.Interval(d.Duration)
+}
+
+// decodeRevisionContent decodes the content of a revision. Missing contents, e.g. the predecessor
+// of the first revision, result in an empty recipe.
+func decodeRevisionContent(j pgtype.JSONB) (revisionContent, error) {
+	var res revisionContent
+	if j.Status != pgtype.Present {
+		return res, nil
+	}
+	if err := json.Unmarshal(j.Bytes, &res); err != nil {
+		return res, err
+	}
+	return res, nil
+}
+
+// diffRevisions returns all changes that are required to get from a to b. Their values are formatted with l.
+func diffRevisions(a, b revisionContent, l *i18n.Localizer) []Change {
+	var res []Change
+
+	res = appendTextChange(res, Change{Field: FieldName}, a.Name, b.Name)
+	res = appendTextChange(res, Change{Field: FieldDescription}, a.Description, b.Description)
+	res = appendValueChange(res, Change{Field: FieldServings}, formatServings(a.Servings), formatServings(b.Servings))
+	res = appendTextChange(res, Change{Field: FieldServingsDescription}, a.ServingsDescription, b.ServingsDescription)
+	res = appendValueChange(res, Change{Field: FieldWorkingTime}, a.WorkingTime.format(l), b.WorkingTime.format(l))
+	res = appendValueChange(res, Change{Field: FieldWaitingTime}, a.WaitingTime.format(l), b.WaitingTime.format(l))
+	res = appendTextChange(res, Change{Field: FieldSource}, valueOrDefault(a.Source), valueOrDefault(b.Source))
+
+	oldSteps := make(map[int]revisionStep, len(a.Steps))
+	for _, s := range a.Steps {
+		oldSteps[s.ID] = s
+	}
+	newSteps := make(map[int]bool, len(b.Steps))
+	for _, s := range b.Steps {
+		newSteps[s.ID] = true
+	}
+
+	// Steps are moved, if their relative order changed. Steps that are only shifted, because
+	// other steps were added or removed before them, are not considered as moved.
+	var oldOrder, newOrder []int
+	for _, s := range a.Steps {
+		if newSteps[s.ID] {
+			oldOrder = append(oldOrder, s.ID)
+		}
+	}
+	for _, s := range b.Steps {
+		if _, ok := oldSteps[s.ID]; ok {
+			newOrder = append(newOrder, s.ID)
+		}
+	}
+	inOrder := longestCommonSubsequence(oldOrder, newOrder)
+
+	for i, s := range b.Steps {
+		position := i + 1
+
+		old, ok := oldSteps[s.ID]
+		if !ok {
+			res = append(res, Change{Kind: ChangeAdded, Field: FieldStep, Step: position, New: s.Instruction})
+			continue
+		}
+		if !inOrder[s.ID] {
+			res = append(res, Change{Kind: ChangeMoved, Field: FieldStep, Step: position, New: s.Instruction})
+		}
+
+		res = appendTextChange(res, Change{Field: FieldInstruction, Step: position}, old.Instruction, s.Instruction)
+		res = appendValueChange(res, Change{Field: FieldStepTime, Step: position}, old.Time.format(l), s.Time.format(l))
+		res = appendIngredientChanges(res, position, old.Ingredients, s.Ingredients, l)
+	}
+
+	for i, s := range a.Steps {
+		if !newSteps[s.ID] {
+			res = append(res, Change{Kind: ChangeRemoved, Field: FieldStep, Step: i + 1, Old: s.Instruction})
+		}
+	}
+
+	return res
+}
+
+func appendIngredientChanges(res []Change, step int, a, b []revisionIngredient, l *i18n.Localizer) []Change {
+	old := make(map[string]revisionIngredient, len(a))
+	for _, i := range a {
+		old[i.Name] = i
+	}
+	current := make(map[string]bool, len(b))
+
+	for _, i := range b {
+		current[i.Name] = true
+		change := Change{Field: FieldIngredient, Step: step, Subject: i.Name}
+		if o, ok := old[i.Name]; ok {
+			res = appendValueChange(res, change, o.format(l), i.format(l))
+		} else {
+			change.Kind, change.New = ChangeAdded, i.format(l)
+			res = append(res, change)
+		}
+	}
+	for _, i := range a {
+		if !current[i.Name] {
+			res = append(res, Change{Kind: ChangeRemoved, Field: FieldIngredient, Step: step, Subject: i.Name, Old: i.format(l)})
+		}
+	}
+
+	return res
+}
+
+// appendTextChange appends a change with a word-based diff, if a and b differ.
+func appendTextChange(res []Change, c Change, a, b string) []Change {
+	if a == b {
+		return res
+	}
+	c.Diff = textdiff.Words(a, b)
+	return appendValueChange(res, c, a, b)
+}
+
+// appendValueChange appends a change, if a and b differ. The kind is determined by the values.
+func appendValueChange(res []Change, c Change, a, b string) []Change {
+	if a == b {
+		return res
+	}
+
+	switch {
+	case a == "":
+		c.Kind = ChangeAdded
+	case b == "":
+		c.Kind = ChangeRemoved
+	default:
+		c.Kind = ChangeModified
+	}
+	c.Old, c.New = a, b
+	return append(res, c)
+}
+
+func formatServings(servings int) string {
+	if servings == 0 {
+		return ""
+	}
+	return strconv.Itoa(servings)
+}
+
+// longestCommonSubsequence returns the set of elements that are part of the longest common subsequence of a and b.
+func longestCommonSubsequence(a, b []int) map[int]bool {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			switch {
+			case a[i] == b[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	res := make(map[int]bool)
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] == b[j]:
+			res[a[i]] = true
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return res
+}
+
+func nullString(s *string) sql.NullString {
+	if s == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: *s, Valid: true}
+}
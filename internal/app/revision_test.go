@@ -0,0 +1,214 @@
+package app
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"codeberg.org/mahlzeit/mahlzeit/internal/i18n"
+	"codeberg.org/mahlzeit/mahlzeit/internal/testhelper"
+	"codeberg.org/mahlzeit/mahlzeit/internal/textdiff"
+	"github.com/alecthomas/assert/v2"
+	"github.com/carlmjohnson/resperr"
+)
+
+func TestApplication_RecipeRevisions(t *testing.T) {
+	t.Parallel()
+	app, ctx := newApp(t), testhelper.Context(t)
+	recipe := app.AddEmptyRecipe(ctx)
+
+	recipe.Name = testhelper.RandomString(20)
+	err := app.UpdateRecipe(ctx, &recipe)
+	assert.NoError(t, err)
+
+	// Saving without any changes doesn't add a revision.
+	err = app.UpdateRecipe(ctx, &recipe)
+	assert.NoError(t, err)
+
+	step := app.AddTestStep(ctx, recipe.ID)
+
+	history, err := app.GetRecipeHistory(ctx, recipe.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(history.Revisions))
+
+	latest, err := app.GetRevision(ctx, recipe.ID, history.Revisions[0].ID, "de")
+	assert.NoError(t, err)
+	assert.Equal(t, []Change{{
+		Kind:  ChangeAdded,
+		Field: FieldStep,
+		Step:  1,
+		New:   step.Instruction,
+	}}, latest.Changes)
+
+	t.Run("revision can be restored", func(t *testing.T) {
+		err := app.RestoreRevision(ctx, recipe.ID, history.Revisions[1].ID)
+		assert.NoError(t, err)
+
+		got, err := app.GetSingleRecipe(ctx, recipe.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, recipe.Name, got.Name)
+		assert.Zero(t, got.Steps)
+
+		history, err := app.GetRecipeHistory(ctx, recipe.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, len(history.Revisions))
+	})
+	t.Run("deleted units are restored by name", func(t *testing.T) {
+		recipe := app.AddEmptyRecipe(ctx)
+		step := app.AddTestStep(ctx, recipe.ID)
+		ingredient, err := app.AddIngredient(ctx, t.Name())
+		assert.NoError(t, err)
+		unit, err := app.AddUnit(ctx, testhelper.RandomString(20))
+		assert.NoError(t, err)
+		err = app.AddIngredientToStep(ctx, AddIngredientToStepParams{
+			StepID:       step.ID,
+			IngredientID: ingredient.ID,
+			UnitID:       &unit.ID,
+			Amount:       2,
+		})
+		assert.NoError(t, err)
+
+		history, err := app.GetRecipeHistory(ctx, recipe.ID)
+		assert.NoError(t, err)
+		err = app.DeleteIngredientFromStep(ctx, DeleteIngredientFromStepParams{StepID: step.ID, IngredientID: ingredient.ID})
+		assert.NoError(t, err)
+		_, err = app.DB.Exec(ctx, "delete from units where id = $1", unit.ID)
+		assert.NoError(t, err)
+
+		err = app.RestoreRevision(ctx, recipe.ID, history.Revisions[0].ID)
+		assert.NoError(t, err)
+
+		got, err := app.GetSingleRecipe(ctx, recipe.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(got.Steps[0].Ingredients))
+		assert.Equal(t, unit.Name, got.Steps[0].Ingredients[0].UnitName)
+		assert.NotEqual(t, unit.ID, got.Steps[0].Ingredients[0].UnitID)
+	})
+	t.Run("missing revision returns not found error", func(t *testing.T) {
+		_, err := app.GetRevision(ctx, recipe.ID, -1, "de")
+		assert.Equal(t, http.StatusNotFound, resperr.StatusCode(err))
+
+		err = app.RestoreRevision(ctx, recipe.ID, -1)
+		assert.Equal(t, http.StatusNotFound, resperr.StatusCode(err))
+	})
+	t.Run("missing recipe returns not found error", func(t *testing.T) {
+		_, err := app.GetRecipeHistory(ctx, -1)
+		assert.Equal(t, http.StatusNotFound, resperr.StatusCode(err))
+	})
+}
+
+func TestDiffRevisions(t *testing.T) {
+	t.Parallel()
+
+	gram := "g"
+	base := revisionContent{
+		Name:        "Zwiebelkuchen",
+		Servings:    4,
+		WorkingTime: nullDuration{Duration: 30 * time.Minute, Valid: true},
+		Steps: []revisionStep{
+			{ID: 1, Instruction: "Teig kneten"},
+			{ID: 2, Instruction: "Zwiebeln schneiden", Ingredients: []revisionIngredient{
+				{Name: "Zwiebeln", Amount: 500, UnitName: &gram},
+			}},
+			{ID: 3, Instruction: "Backen"},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		locale string // German, if empty
+		change func(c *revisionContent)
+		want   []Change
+	}{
+		{
+			name:   "no changes",
+			change: func(c *revisionContent) {},
+		},
+		{
+			name: "recipe fields",
+			change: func(c *revisionContent) {
+				c.Name = "Flammkuchen"
+				c.Servings = 2
+				c.WorkingTime = nullDuration{}
+			},
+			want: []Change{
+				{
+					Kind: ChangeModified, Field: FieldName, Old: "Zwiebelkuchen", New: "Flammkuchen",
+					Diff: []textdiff.Segment{{Op: textdiff.Delete, Text: "Zwiebelkuchen"}, {Op: textdiff.Insert, Text: "Flammkuchen"}},
+				},
+				{Kind: ChangeModified, Field: FieldServings, Old: "4", New: "2"},
+				{Kind: ChangeRemoved, Field: FieldWorkingTime, Old: "30 Min."},
+			},
+		},
+		{
+			name: "added and removed steps",
+			change: func(c *revisionContent) {
+				c.Steps = []revisionStep{c.Steps[1], c.Steps[2], {ID: 4, Instruction: "Servieren"}}
+			},
+			want: []Change{
+				{Kind: ChangeAdded, Field: FieldStep, Step: 3, New: "Servieren"},
+				{Kind: ChangeRemoved, Field: FieldStep, Step: 1, Old: "Teig kneten"},
+			},
+		},
+		{
+			name: "moved step",
+			change: func(c *revisionContent) {
+				c.Steps = []revisionStep{c.Steps[1], c.Steps[0], c.Steps[2]}
+			},
+			want: []Change{
+				{Kind: ChangeMoved, Field: FieldStep, Step: 2, New: "Teig kneten"},
+			},
+		},
+		{
+			name: "step ingredients",
+			change: func(c *revisionContent) {
+				c.Steps[1].Ingredients = []revisionIngredient{
+					{Name: "Zwiebeln", Amount: 600, UnitName: &gram, Note: "rot"},
+					{Name: "Salz", Amount: 1},
+				}
+			},
+			want: []Change{
+				{Kind: ChangeModified, Field: FieldIngredient, Step: 2, Subject: "Zwiebeln", Old: "500 g", New: "600 g (rot)"},
+				{Kind: ChangeAdded, Field: FieldIngredient, Step: 2, Subject: "Salz", New: "1"},
+			},
+		},
+		{
+			name: "decimal amounts in German",
+			change: func(c *revisionContent) {
+				c.Steps[1].Ingredients = []revisionIngredient{{Name: "Zwiebeln", Amount: 1500.5, UnitName: &gram}}
+			},
+			want: []Change{
+				{Kind: ChangeModified, Field: FieldIngredient, Step: 2, Subject: "Zwiebeln", Old: "500 g", New: "1.500,5 g"},
+			},
+		},
+		{
+			name:   "values in English",
+			locale: "en",
+			change: func(c *revisionContent) {
+				c.WorkingTime = nullDuration{Duration: 90 * time.Minute, Valid: true}
+				c.Steps[1].Ingredients = []revisionIngredient{{Name: "Zwiebeln", Amount: 1500.5, UnitName: &gram}}
+			},
+			want: []Change{
+				{Kind: ChangeModified, Field: FieldWorkingTime, Old: "30 min", New: "1 hr 30 min"},
+				{Kind: ChangeModified, Field: FieldIngredient, Step: 2, Subject: "Zwiebeln", Old: "500 g", New: "1,500.5 g"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			changed := base
+			changed.Steps = make([]revisionStep, len(base.Steps))
+			copy(changed.Steps, base.Steps)
+			tt.change(&changed)
+
+			locale := tt.locale
+			if locale == "" {
+				locale = "de"
+			}
+			assert.Equal(t, tt.want, diffRevisions(base, changed, i18n.New(locale)))
+		})
+	}
+}
@@ -0,0 +1,301 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"codeberg.org/mahlzeit/mahlzeit/db/queries"
+	"codeberg.org/mahlzeit/mahlzeit/internal/i18n"
+	"github.com/carlmjohnson/resperr"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+)
+
+// RecipeTranslation contains the translations of the texts of a recipe into a single locale,
+// next to the original texts. Empty translations fall back to the original when viewing the recipe.
+type RecipeTranslation struct {
+	RecipeID    int
+	Locale      string
+	Name        string
+	Description string
+
+	Original    *Recipe           // the recipe in its original language, only set by GetRecipeTranslation
+	Steps       []StepTranslation // in the order of the steps of the recipe
+	Ingredients []TermTranslation // all ingredients that are used in the recipe
+	Units       []TermTranslation // all units that are used in the recipe
+}
+
+type StepTranslation struct {
+	StepID      int
+	Original    string
+	Instruction string
+}
+
+// TermTranslation is the translation of the name of an ingredient or unit.
+// Those are shared by all recipes, so changing it affects other recipes as well.
+type TermTranslation struct {
+	ID       int
+	Original string
+	Name     string
+}
+
+// TranslateRecipe replaces the texts of r with their translations into locale. This includes the name,
+// description, instructions of the steps and the names of ingredients and units. Texts without
+// a translation keep their original, so a partially translated recipe can be shown.
+// Nothing is changed if the recipe is written in locale already.
+func (app *Application) TranslateRecipe(ctx context.Context, r *Recipe, locale string) error {
+	if locale == r.Language || !i18n.IsSupported(locale) {
+		return nil
+	}
+
+	t, err := getTranslations(ctx, app.Queries, r, locale)
+	if err != nil {
+		return err
+	}
+
+	r.Name = fallback(t.Name, r.Name)
+	r.Description = fallback(t.Description, r.Description)
+
+	steps := make(map[int]string, len(t.Steps))
+	for _, s := range t.Steps {
+		steps[s.StepID] = s.Instruction
+	}
+	ingredients := termsByID(t.Ingredients)
+	units := termsByID(t.Units)
+
+	translateIngredients := func(list []Ingredient) {
+		for i := range list {
+			list[i].Name = fallback(ingredients[list[i].ID], list[i].Name)
+			list[i].UnitName = fallback(units[list[i].UnitID], list[i].UnitName)
+		}
+	}
+	translateIngredients(r.Ingredients)
+	for i := range r.Steps {
+		r.Steps[i].Instruction = fallback(steps[r.Steps[i].ID], r.Steps[i].Instruction)
+		translateIngredients(r.Steps[i].Ingredients)
+	}
+
+	return nil
+}
+
+// TranslateRecipeNames replaces the names of the recipes with their translation into locale, if one exists.
+func (app *Application) TranslateRecipeNames(ctx context.Context, recipes []ListEntry, locale string) error {
+	rows, err := app.Queries.GetRecipeNameTranslations(ctx, locale)
+	if err != nil {
+		return fmt.Errorf("querying translated recipe names for locale %q: %w", locale, err)
+	}
+
+	names := make(map[int]string, len(rows))
+	for _, row := range rows {
+		names[int(row.RecipeID)] = row.Name
+	}
+	for i := range recipes {
+		recipes[i].Name = fallback(names[recipes[i].ID], recipes[i].Name)
+	}
+	return nil
+}
+
+// GetRecipeTranslation returns the translation of a recipe into locale, including all original texts.
+// Missing translations are returned as empty strings.
+func (app *Application) GetRecipeTranslation(ctx context.Context, recipeID int, locale string) (*RecipeTranslation, error) {
+	recipe, err := app.GetSingleRecipe(ctx, recipeID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, resperr.WithCodeAndMessage(err, http.StatusNotFound, "recipe does not exist")
+		}
+		return nil, err
+	}
+	if err := validateTranslationLocale(locale, recipe.Language); err != nil {
+		return nil, err
+	}
+
+	res, err := getTranslations(ctx, app.Queries, recipe, locale)
+	if err != nil {
+		return nil, err
+	}
+	res.Original = recipe
+	return res, nil
+}
+
+// SaveRecipeTranslation stores all translations of t. Translations are not part of the revisions of a recipe.
+func (app *Application) SaveRecipeTranslation(ctx context.Context, t *RecipeTranslation) error {
+	return app.inTx(ctx, func(q *queries.Queries) error {
+		recipe, err := q.GetRecipeByID(ctx, int64(t.RecipeID))
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return resperr.WithCodeAndMessage(err, http.StatusNotFound, "recipe does not exist")
+			}
+			return fmt.Errorf("querying recipe %d: %w", t.RecipeID, err)
+		}
+		if err := validateTranslationLocale(t.Locale, recipe.Language); err != nil {
+			return err
+		}
+
+		if err := q.SaveRecipeTranslation(ctx, queries.SaveRecipeTranslationParams{
+			RecipeID:    recipe.ID,
+			Locale:      t.Locale,
+			Name:        strings.TrimSpace(t.Name),
+			Description: strings.TrimSpace(t.Description),
+		}); err != nil {
+			return fmt.Errorf("saving translation of recipe %d: %w", t.RecipeID, err)
+		}
+
+		for _, s := range t.Steps {
+			n, err := q.SaveStepTranslation(ctx, queries.SaveStepTranslationParams{
+				Locale:      t.Locale,
+				Instruction: strings.TrimSpace(s.Instruction),
+				StepID:      int64(s.StepID),
+				RecipeID:    recipe.ID,
+			})
+			if err != nil {
+				return fmt.Errorf("saving translation of step %d: %w", s.StepID, err)
+			}
+			if n == 0 {
+				return resperr.New(http.StatusNotFound, "step %d does not belong to recipe %d", s.StepID, t.RecipeID)
+			}
+		}
+
+		for _, i := range t.Ingredients {
+			err := q.SaveIngredientTranslation(ctx, queries.SaveIngredientTranslationParams{
+				IngredientID: int64(i.ID),
+				Locale:       t.Locale,
+				Name:         strings.TrimSpace(i.Name),
+			})
+			if err != nil {
+				var pgerr *pgconn.PgError
+				if errors.As(err, &pgerr) && pgerr.ConstraintName == "ingredient_translations_ingredient_id_fkey" {
+					return resperr.WithCodeAndMessage(err, http.StatusNotFound, "ingredient does not exist")
+				}
+				return fmt.Errorf("saving translation of ingredient %d: %w", i.ID, err)
+			}
+		}
+
+		for _, u := range t.Units {
+			err := q.SaveUnitTranslation(ctx, queries.SaveUnitTranslationParams{
+				UnitID: int64(u.ID),
+				Locale: t.Locale,
+				Name:   strings.TrimSpace(u.Name),
+			})
+			if err != nil {
+				var pgerr *pgconn.PgError
+				if errors.As(err, &pgerr) && pgerr.ConstraintName == "unit_translations_unit_id_fkey" {
+					return resperr.WithCodeAndMessage(err, http.StatusNotFound, "unit does not exist")
+				}
+				return fmt.Errorf("saving translation of unit %d: %w", u.ID, err)
+			}
+		}
+
+		return nil
+	})
+}
+
+func validateTranslationLocale(locale, original string) error {
+	if !i18n.IsSupported(locale) {
+		return resperr.WithUserMessagef(nil, "unsupported language %q", locale)
+	}
+	if locale == original {
+		return resperr.WithUserMessagef(nil, "the recipe is written in %q already", locale)
+	}
+	return nil
+}
+
+// getTranslations queries all translations of the texts of r into locale. The ingredients and units
+// are taken from the steps of r, in the order of their first use.
+func getTranslations(ctx context.Context, q *queries.Queries, r *Recipe, locale string) (*RecipeTranslation, error) {
+	res := &RecipeTranslation{RecipeID: r.ID, Locale: locale}
+
+	recipe, err := q.GetRecipeTranslation(ctx, queries.GetRecipeTranslationParams{
+		RecipeID: int64(r.ID),
+		Locale:   locale,
+	})
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("querying translation of recipe %d: %w", r.ID, err)
+	}
+	res.Name, res.Description = recipe.Name, recipe.Description
+
+	steps, err := q.GetStepTranslations(ctx, queries.GetStepTranslationsParams{
+		RecipeID: int64(r.ID),
+		Locale:   locale,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("querying step translations of recipe %d: %w", r.ID, err)
+	}
+	instructions := make(map[int]string, len(steps))
+	for _, s := range steps {
+		instructions[int(s.StepID)] = s.Instruction
+	}
+
+	var ingredientIDs, unitIDs []int64
+	seenIngredients, seenUnits := map[int]bool{}, map[int]bool{}
+	for _, s := range r.Steps {
+		res.Steps = append(res.Steps, StepTranslation{
+			StepID:      s.ID,
+			Original:    s.Instruction,
+			Instruction: instructions[s.ID],
+		})
+
+		for _, i := range s.Ingredients {
+			if !seenIngredients[i.ID] {
+				seenIngredients[i.ID] = true
+				ingredientIDs = append(ingredientIDs, int64(i.ID))
+				res.Ingredients = append(res.Ingredients, TermTranslation{ID: i.ID, Original: i.Name})
+			}
+			if i.UnitID != 0 && !seenUnits[i.UnitID] {
+				seenUnits[i.UnitID] = true
+				unitIDs = append(unitIDs, int64(i.UnitID))
+				res.Units = append(res.Units, TermTranslation{ID: i.UnitID, Original: i.UnitName})
+			}
+		}
+	}
+
+	ingredients, err := q.GetIngredientTranslations(ctx, queries.GetIngredientTranslationsParams{
+		Locale:        locale,
+		IngredientIds: ingredientIDs,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("querying ingredient translations of recipe %d: %w", r.ID, err)
+	}
+	ingredientNames := make(map[int]string, len(ingredients))
+	for _, i := range ingredients {
+		ingredientNames[int(i.IngredientID)] = i.Name
+	}
+	for i := range res.Ingredients {
+		res.Ingredients[i].Name = ingredientNames[res.Ingredients[i].ID]
+	}
+
+	units, err := q.GetUnitTranslations(ctx, queries.GetUnitTranslationsParams{
+		Locale:  locale,
+		UnitIds: unitIDs,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("querying unit translations of recipe %d: %w", r.ID, err)
+	}
+	unitNames := make(map[int]string, len(units))
+	for _, u := range units {
+		unitNames[int(u.UnitID)] = u.Name
+	}
+	for i := range res.Units {
+		res.Units[i].Name = unitNames[res.Units[i].ID]
+	}
+
+	return res, nil
+}
+
+func termsByID(terms []TermTranslation) map[int]string {
+	res := make(map[int]string, len(terms))
+	for _, t := range terms {
+		res[t.ID] = t.Name
+	}
+	return res
+}
+
+// fallback returns translation, or original if there is no translation.
+func fallback(translation, original string) string {
+	if strings.TrimSpace(translation) == "" {
+		return original
+	}
+	return translation
+}
@@ -0,0 +1,94 @@
+package app
+
+import (
+	"net/http"
+	"testing"
+
+	"codeberg.org/mahlzeit/mahlzeit/internal/testhelper"
+	"github.com/alecthomas/assert/v2"
+	"github.com/carlmjohnson/resperr"
+)
+
+func TestApplication_TranslateRecipe(t *testing.T) {
+	t.Parallel()
+	app, ctx := newApp(t), testhelper.Context(t)
+
+	recipe := app.AddEmptyRecipe(ctx)
+	translatedStep := app.AddTestStep(ctx, recipe.ID)
+	untranslatedStep := app.AddTestStep(ctx, recipe.ID)
+	ingredient, err := app.AddIngredient(ctx, t.Name())
+	assert.NoError(t, err)
+	err = app.AddIngredientToStep(ctx, AddIngredientToStepParams{
+		StepID:       translatedStep.ID,
+		IngredientID: ingredient.ID,
+		Amount:       2,
+	})
+	assert.NoError(t, err)
+
+	err = app.SaveRecipeTranslation(ctx, &RecipeTranslation{
+		RecipeID: recipe.ID,
+		Locale:   "en",
+		Name:     "translated " + recipe.Name,
+		Steps: []StepTranslation{
+			{StepID: translatedStep.ID, Instruction: "translated instruction"},
+			{StepID: untranslatedStep.ID, Instruction: ""},
+		},
+		Ingredients: []TermTranslation{{ID: ingredient.ID, Name: "translated ingredient"}},
+	})
+	assert.NoError(t, err)
+
+	t.Run("translations replace the original texts", func(t *testing.T) {
+		r, err := app.GetSingleRecipe(ctx, recipe.ID)
+		assert.NoError(t, err)
+		assert.NoError(t, app.TranslateRecipe(ctx, r, "en"))
+
+		assert.Equal(t, "translated "+recipe.Name, r.Name)
+		assert.Equal(t, "translated instruction", r.Steps[0].Instruction)
+		assert.Equal(t, "translated ingredient", r.Steps[0].Ingredients[0].Name)
+		assert.Equal(t, "translated ingredient", r.Ingredients[0].Name)
+	})
+	t.Run("missing translations fall back to the original", func(t *testing.T) {
+		r, err := app.GetSingleRecipe(ctx, recipe.ID)
+		assert.NoError(t, err)
+		assert.NoError(t, app.TranslateRecipe(ctx, r, "en"))
+
+		assert.Equal(t, recipe.Description, r.Description)
+		assert.Equal(t, untranslatedStep.Instruction, r.Steps[1].Instruction)
+	})
+	t.Run("original language is not translated", func(t *testing.T) {
+		r, err := app.GetSingleRecipe(ctx, recipe.ID)
+		assert.NoError(t, err)
+		assert.NoError(t, app.TranslateRecipe(ctx, r, r.Language))
+
+		assert.Equal(t, recipe.Name, r.Name)
+	})
+	t.Run("translation contains the original texts", func(t *testing.T) {
+		translation, err := app.GetRecipeTranslation(ctx, recipe.ID, "en")
+		assert.NoError(t, err)
+
+		assert.Equal(t, recipe.Name, translation.Original.Name)
+		assert.Equal(t, []StepTranslation{
+			{StepID: translatedStep.ID, Original: translatedStep.Instruction, Instruction: "translated instruction"},
+			{StepID: untranslatedStep.ID, Original: untranslatedStep.Instruction},
+		}, translation.Steps)
+		assert.Equal(t, []TermTranslation{
+			{ID: ingredient.ID, Original: ingredient.Name, Name: "translated ingredient"},
+		}, translation.Ingredients)
+	})
+	t.Run("steps of other recipes can't be translated", func(t *testing.T) {
+		other := app.AddEmptyRecipe(ctx)
+		err := app.SaveRecipeTranslation(ctx, &RecipeTranslation{
+			RecipeID: other.ID,
+			Locale:   "en",
+			Steps:    []StepTranslation{{StepID: translatedStep.ID, Instruction: "invalid"}},
+		})
+		assert.Equal(t, http.StatusNotFound, resperr.StatusCode(err))
+	})
+	t.Run("unsupported and original language are rejected", func(t *testing.T) {
+		_, err := app.GetRecipeTranslation(ctx, recipe.ID, "xx")
+		assert.Equal(t, http.StatusBadRequest, resperr.StatusCode(err))
+
+		err = app.SaveRecipeTranslation(ctx, &RecipeTranslation{RecipeID: recipe.ID, Locale: "de"})
+		assert.Equal(t, http.StatusBadRequest, resperr.StatusCode(err))
+	})
+}
@@ -3,6 +3,9 @@ package app
 import (
 	"context"
 	"fmt"
+	"strings"
+
+	"github.com/carlmjohnson/resperr"
 )
 
 func (app *Application) GetAllUnits(ctx context.Context) ([]Unit, error) {
@@ -21,3 +24,21 @@ func (app *Application) GetAllUnits(ctx context.Context) ([]Unit, error) {
 
 	return res, nil
 }
+
+// AddUnit adds a new unit. If a unit with the same name exists already, it is returned instead.
+func (app *Application) AddUnit(ctx context.Context, name string) (Unit, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return Unit{}, resperr.WithUserMessage(nil, "the name of a unit must not be empty")
+	}
+
+	id, err := app.Queries.AddUnit(ctx, name)
+	if err != nil {
+		return Unit{}, fmt.Errorf("adding new unit: %w", err)
+	}
+
+	return Unit{
+		ID:   int(id),
+		Name: name,
+	}, nil
+}
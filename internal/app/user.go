@@ -0,0 +1,144 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"codeberg.org/mahlzeit/mahlzeit/db/queries"
+	"codeberg.org/mahlzeit/mahlzeit/internal/i18n"
+	"codeberg.org/mahlzeit/mahlzeit/internal/password"
+	"github.com/carlmjohnson/resperr"
+	"github.com/jackc/pgx/v4"
+)
+
+type User struct {
+	ID   int
+	Name string
+}
+
+type userIDKey struct{}
+
+// WithUserID returns a copy of ctx that carries the ID of the user who performs the current action.
+// It's used to record the author of changes, e.g. for the revisions of a recipe.
+func WithUserID(ctx context.Context, id int) context.Context {
+	return context.WithValue(ctx, userIDKey{}, id)
+}
+
+// UserIDFromContext returns the ID of the current user, as set by [WithUserID].
+// If it's unknown, zero is returned.
+func UserIDFromContext(ctx context.Context) int {
+	id, _ := ctx.Value(userIDKey{}).(int)
+	return id
+}
+
+type operatorKey struct{}
+
+// AsOperator returns a copy of ctx for the actions of the operator of the application, e.g. on the command line.
+// They are allowed everything without being logged in, like a superuser.
+func AsOperator(ctx context.Context) context.Context {
+	return context.WithValue(ctx, operatorKey{}, true)
+}
+
+// RequireSuperuser returns an error unless the current user, see [WithUserID], is a superuser or the action is
+// performed by the operator, see [AsOperator]. Without a user, the status of the error is 401, otherwise 403.
+func (app *Application) RequireSuperuser(ctx context.Context) error {
+	if isOperator, _ := ctx.Value(operatorKey{}).(bool); isOperator {
+		return nil
+	}
+
+	userID := UserIDFromContext(ctx)
+	if userID == 0 {
+		return resperr.WithCodeAndMessage(nil, http.StatusUnauthorized, "authentication required")
+	}
+	superuser, err := app.Queries.IsUserSuperuser(ctx, int64(userID))
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("querying superuser of user %d: %w", userID, err)
+	}
+	if !superuser {
+		return resperr.WithCodeAndMessage(nil, http.StatusForbidden, "only superusers may do this")
+	}
+	return nil
+}
+
+// GetUserLocale returns the preferred locale of the user, e.g. "de".
+// If the user hasn't chosen one, an empty string is returned.
+func (app *Application) GetUserLocale(ctx context.Context, userID int) (string, error) {
+	locale, err := app.Queries.GetUserLocale(ctx, int64(userID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", resperr.New(http.StatusNotFound, "user %d not found", userID)
+	}
+	if err != nil {
+		return "", fmt.Errorf("querying locale of user %d: %w", userID, err)
+	}
+	return locale.String, nil
+}
+
+// SetUserLocale stores the preferred locale of the user. An empty locale removes the preference.
+func (app *Application) SetUserLocale(ctx context.Context, userID int, locale string) error {
+	if locale != "" && !i18n.IsSupported(locale) {
+		return resperr.WithUserMessagef(nil, "unsupported locale %q", locale)
+	}
+
+	err := app.Queries.SetUserLocale(ctx, queries.SetUserLocaleParams{
+		Locale: sql.NullString{String: locale, Valid: locale != ""},
+		ID:     int64(userID),
+	})
+	if err != nil {
+		return fmt.Errorf("setting locale of user %d: %w", userID, err)
+	}
+	return nil
+}
+
+// GetUserByEmail returns the user with the email address. If there's none, a not found error is returned.
+func (app *Application) GetUserByEmail(ctx context.Context, email string) (User, error) {
+	u, err := app.Queries.GetUserByEmail(ctx, normalizeEmail(email))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return User{}, resperr.New(http.StatusNotFound, "no user with the email address %q", email)
+	}
+	if err != nil {
+		return User{}, fmt.Errorf("querying user by email: %w", err)
+	}
+	return User{ID: int(u.ID), Name: u.Name}, nil
+}
+
+// SetPassword sets a new password for the user, e.g. by an administrator. Like [Application.ResetPassword],
+// all sessions of the user are ended.
+func (app *Application) SetPassword(ctx context.Context, userID int, pw string) error {
+	hash, err := hashPassword(pw)
+	if err != nil {
+		return err
+	}
+
+	return app.inTx(ctx, func(q *queries.Queries) error {
+		if err := q.SetUserPassword(ctx, queries.SetUserPasswordParams{
+			PasswordHash:          hash,
+			PasswordHashAlgorithm: password.Algorithm,
+			ID:                    int64(userID),
+		}); err != nil {
+			return fmt.Errorf("setting password of user %d: %w", userID, err)
+		}
+		if err := q.DeleteUserSessions(ctx, int64(userID)); err != nil {
+			return fmt.Errorf("deleting sessions of user %d: %w", userID, err)
+		}
+		return nil
+	})
+}
+
+// SetSuperuser grants or revokes the permissions of a superuser. If the user does not exist, a not found
+// error is returned.
+func (app *Application) SetSuperuser(ctx context.Context, userID int, superuser bool) error {
+	n, err := app.Queries.SetUserSuperuser(ctx, queries.SetUserSuperuserParams{
+		IsSuperuser: superuser,
+		ID:          int64(userID),
+	})
+	if err != nil {
+		return fmt.Errorf("setting superuser of user %d: %w", userID, err)
+	}
+	if n == 0 {
+		return resperr.New(http.StatusNotFound, "user %d not found", userID)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package app
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+
+	"codeberg.org/mahlzeit/mahlzeit/internal/i18n"
+	"github.com/jackc/pgconn"
+)
+
+// FieldErrors maps the names of invalid fields to the translation keys of their messages, e.g.
+// "Name" to "validation.required". The names are the ones of the struct fields, like [Recipe.Name].
+type FieldErrors map[string]string
+
+// Err returns a [ValidationError] with the field errors, or nil if there are none.
+func (f FieldErrors) Err() error {
+	if len(f) == 0 {
+		return nil
+	}
+	return &ValidationError{Fields: f}
+}
+
+// ValidationError is returned if the input of a user is invalid. Forms show the messages of Fields next to
+// their fields, other clients get all of them in English as user message.
+type ValidationError struct {
+	Fields FieldErrors
+	Code   int   // the status code, http.StatusBadRequest if it's not set
+	err    error // optional, the error of the database that the violation was detected by
+}
+
+func (e *ValidationError) Error() string {
+	msg := "invalid input: " + e.UserMessage()
+	if e.err != nil {
+		msg += ": " + e.err.Error()
+	}
+	return msg
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.err
+}
+
+// StatusCode implements [resperr.StatusCoder].
+func (e *ValidationError) StatusCode() int {
+	if e.Code == 0 {
+		return http.StatusBadRequest
+	}
+	return e.Code
+}
+
+// UserMessage implements [resperr.UserMessenger].
+func (e *ValidationError) UserMessage() string {
+	fields := make([]string, 0, len(e.Fields))
+	for f := range e.Fields {
+		fields = append(fields, f)
+	}
+	sort.Strings(fields)
+
+	l := i18n.New("en")
+	msgs := make([]string, 0, len(fields))
+	for _, f := range fields {
+		msgs = append(msgs, f+": "+l.T(e.Fields[f]))
+	}
+	return strings.Join(msgs, " ")
+}
+
+// constraintViolation describes a constraint of the database as error of a single field.
+type constraintViolation struct {
+	field string
+	key   string
+	code  int
+}
+
+// constraintViolations are the constraints of the database that are violated by invalid input. Some of them are
+// checked before the queries, too, but only the database detects e.g. names that were taken concurrently.
+var constraintViolations = map[string]constraintViolation{
+	"recipes_name_key":         {field: "Name", key: "validation.name_taken", code: http.StatusConflict},
+	"instruction_length_check": {field: "Instruction", key: "validation.required", code: http.StatusBadRequest},
+	"negative_time_check":      {field: "Time", key: "validation.negative_duration", code: http.StatusBadRequest},
+
+	"step_ingredients_ingredients_id_fkey": {field: "IngredientID", key: "validation.unknown_ingredient", code: http.StatusBadRequest},
+	"step_ingredients_unit_id_fkey":        {field: "UnitID", key: "validation.unknown_unit", code: http.StatusBadRequest},
+	"step_ingredient_uniqueness":           {field: "IngredientID", key: "validation.ingredient_in_step", code: http.StatusConflict},
+}
+
+// fieldErrorFromDB returns a [ValidationError] if err is the violation of one of the [constraintViolations],
+// otherwise nil.
+func fieldErrorFromDB(err error) error {
+	var pgerr *pgconn.PgError
+	if !errors.As(err, &pgerr) {
+		return nil
+	}
+	v, ok := constraintViolations[pgerr.ConstraintName]
+	if !ok {
+		return nil
+	}
+	return &ValidationError{Fields: FieldErrors{v.field: v.key}, Code: v.code, err: err}
+}
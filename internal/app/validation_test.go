@@ -0,0 +1,98 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"codeberg.org/mahlzeit/mahlzeit/db/queries"
+	"codeberg.org/mahlzeit/mahlzeit/internal/pghelper"
+	"codeberg.org/mahlzeit/mahlzeit/internal/testhelper"
+	"github.com/alecthomas/assert/v2"
+	"github.com/carlmjohnson/resperr"
+)
+
+func TestValidationError(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, FieldErrors{}.Err())
+
+	err := fmt.Errorf("updating recipe: %w", FieldErrors{
+		"WorkingTime": "validation.negative_duration",
+		"Name":        "validation.required",
+	}.Err())
+	assert.Equal(t, http.StatusBadRequest, resperr.StatusCode(err))
+	assert.Equal(t, "Name: This field must not be empty. WorkingTime: The time must not be negative.", resperr.UserMessage(err))
+
+	var verr *ValidationError
+	assert.True(t, errors.As(err, &verr))
+	assert.Equal(t, "validation.required", verr.Fields["Name"])
+
+	conflict := &ValidationError{Fields: FieldErrors{"Name": "validation.name_taken"}, Code: http.StatusConflict}
+	assert.Equal(t, http.StatusConflict, resperr.StatusCode(conflict))
+}
+
+func TestFieldErrorFromDB(t *testing.T) {
+	t.Parallel()
+	app, ctx := newApp(t), testhelper.Context(t)
+
+	recipe := app.AddEmptyRecipe(ctx)
+	other := app.AddEmptyRecipe(ctx)
+	step := app.AddTestStep(ctx, recipe.ID)
+
+	tests := []struct {
+		name       string
+		query      func() error
+		wantFields FieldErrors
+		wantCode   int
+	}{
+		{
+			name: "name of another recipe",
+			query: func() error {
+				return app.Queries.UpdateBasicRecipeInformation(ctx, queries.UpdateBasicRecipeInformationParams{
+					ID:       int64(recipe.ID),
+					Name:     other.Name,
+					Servings: 1,
+					Language: recipe.Language,
+				})
+			},
+			wantFields: FieldErrors{"Name": "validation.name_taken"},
+			wantCode:   http.StatusConflict,
+		},
+		{
+			name: "empty instruction",
+			query: func() error {
+				return app.Queries.UpdateStepByID(ctx, queries.UpdateStepByIDParams{ID: int64(step.ID)})
+			},
+			wantFields: FieldErrors{"Instruction": "validation.required"},
+			wantCode:   http.StatusBadRequest,
+		},
+		{
+			name: "negative time",
+			query: func() error {
+				return app.Queries.UpdateStepByID(ctx, queries.UpdateStepByIDParams{
+					ID:          int64(step.ID),
+					Instruction: t.Name(),
+					Time:        pghelper.Interval(-time.Minute),
+				})
+			},
+			wantFields: FieldErrors{"Time": "validation.negative_duration"},
+			wantCode:   http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			err := fieldErrorFromDB(tt.query())
+
+			var verr *ValidationError
+			assert.True(t, errors.As(err, &verr))
+			assert.Equal(t, tt.wantFields, verr.Fields)
+			assert.Equal(t, tt.wantCode, resperr.StatusCode(err))
+		})
+	}
+
+	assert.NoError(t, fieldErrorFromDB(errors.New("no database error")))
+}
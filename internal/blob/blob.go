@@ -0,0 +1,49 @@
+// Package blob provides a small abstraction over storages for binary objects, like uploaded images.
+// Objects are addressed by slash-separated keys, e.g. "recipes/1/image.jpg".
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ErrNotFound is returned if an object does not exist.
+var ErrNotFound = errors.New("blob not found")
+
+// ErrInvalidKey is returned if a key could escape the storage or is malformed otherwise.
+var ErrInvalidKey = errors.New("invalid blob key")
+
+// Storage is implemented by all storage backends.
+type Storage interface {
+	// Put stores the content of r under the given key. Existing objects are overwritten.
+	Put(ctx context.Context, key string, r io.Reader, contentType string) error
+
+	// Get returns the content of the object. If it doesn't exist, [ErrNotFound] is returned.
+	// The caller must close the returned reader.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+
+	// Delete deletes the object. Deleting an object that doesn't exist is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// Ensure that all implementations satisfy the interface.
+var (
+	_ Storage = (*Filesystem)(nil)
+	_ Storage = (*S3)(nil)
+)
+
+// validateKey ensures that keys can't escape the storage, e.g. by using "..".
+func validateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return fmt.Errorf("%w %q", ErrInvalidKey, key)
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return fmt.Errorf("%w %q", ErrInvalidKey, key)
+		}
+	}
+	return nil
+}
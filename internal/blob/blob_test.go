@@ -0,0 +1,135 @@
+package blob
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"codeberg.org/mahlzeit/mahlzeit/internal/testhelper"
+	"github.com/alecthomas/assert/v2"
+)
+
+// testStorage runs the same set of tests against all storage implementations.
+func testStorage(t *testing.T, s Storage) {
+	t.Helper()
+	ctx := testhelper.Context(t)
+
+	t.Run("object can be stored and read", func(t *testing.T) {
+		err := s.Put(ctx, "recipes/1/test.txt", strings.NewReader(t.Name()), "text/plain")
+		assert.NoError(t, err)
+
+		r, err := s.Get(ctx, "recipes/1/test.txt")
+		assert.NoError(t, err)
+		defer r.Close()
+
+		content, err := io.ReadAll(r)
+		assert.NoError(t, err)
+		assert.Equal(t, t.Name(), string(content))
+	})
+	t.Run("missing object returns not found error", func(t *testing.T) {
+		_, err := s.Get(ctx, "does/not/exist")
+		assert.True(t, errors.Is(err, ErrNotFound), "expected ErrNotFound, got: %v", err)
+	})
+	t.Run("object can be deleted", func(t *testing.T) {
+		err := s.Put(ctx, "delete-me", strings.NewReader("content"), "")
+		assert.NoError(t, err)
+
+		assert.NoError(t, s.Delete(ctx, "delete-me"))
+
+		_, err = s.Get(ctx, "delete-me")
+		assert.True(t, errors.Is(err, ErrNotFound), "expected ErrNotFound, got: %v", err)
+	})
+	t.Run("deletion is idempotent", func(t *testing.T) {
+		assert.NoError(t, s.Delete(ctx, "never/existed"))
+	})
+	t.Run("invalid keys are rejected", func(t *testing.T) {
+		for _, key := range []string{"", "/absolute", "../escape", "a//b", "a/./b"} {
+			err := s.Put(ctx, key, strings.NewReader("content"), "")
+			assert.True(t, errors.Is(err, ErrInvalidKey), "key %q should be rejected, got: %v", key, err)
+		}
+	})
+}
+
+func TestFilesystem(t *testing.T) {
+	t.Parallel()
+
+	s, err := NewFilesystem(t.TempDir())
+	assert.NoError(t, err)
+	testStorage(t, s)
+}
+
+func TestS3(t *testing.T) {
+	t.Parallel()
+
+	fake := newFakeS3(t, "mahlzeit")
+	s, err := newS3(S3Config{
+		Endpoint:        fake.URL,
+		Bucket:          "mahlzeit",
+		AccessKeyID:     "test",
+		SecretAccessKey: "secret",
+		PathStyle:       true,
+	}, fake.Client().Transport)
+	assert.NoError(t, err)
+	testStorage(t, s)
+}
+
+// newFakeS3 starts a minimal stand-in for an S3-compatible service with path-style addressing over HTTPS.
+// It only implements the operations that we use, and checks that each request is signed.
+func newFakeS3(t *testing.T, bucket string) *httptest.Server {
+	t.Helper()
+
+	var (
+		mu      sync.Mutex
+		objects = map[string][]byte{}
+	)
+
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=test/") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		key, ok := strings.CutPrefix(r.URL.Path, "/"+bucket+"/")
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch r.Method {
+		case http.MethodPut:
+			body, _ := io.ReadAll(r.Body)
+			if r.Header.Get("X-Amz-Content-Sha256") != "UNSIGNED-PAYLOAD" {
+				// The content is streamed, instead of being read completely to hash it.
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			objects[key] = body
+		case http.MethodGet:
+			body, ok := objects[key]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			// The client requires the headers that S3 always sends.
+			w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+			w.Header().Set("ETag", `"etag"`)
+			_, _ = w.Write(body)
+		case http.MethodDelete:
+			delete(objects, key)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
@@ -0,0 +1,124 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3Config contains the settings for an S3-compatible storage, e.g. AWS S3, MinIO or Garage.
+type S3Config struct {
+	// Endpoint is the base URL of the service, e.g. "https://s3.eu-central-1.amazonaws.com".
+	Endpoint        string `toml:"endpoint"`
+	Region          string `toml:"region"`
+	Bucket          string `toml:"bucket"`
+	AccessKeyID     string `toml:"access-key-id"`
+	SecretAccessKey string `toml:"secret-access-key" secret:"true"`
+
+	// PathStyle addresses the bucket as part of the path ("endpoint/bucket/key") instead of
+	// the host ("bucket.endpoint/key"). Most self-hosted implementations require this.
+	PathStyle bool `toml:"path-style"`
+}
+
+// S3 stores objects in an S3-compatible storage with the client of MinIO.
+type S3 struct {
+	bucket string
+	client *minio.Client
+}
+
+// NewS3 returns a storage for the given configuration.
+func NewS3(cfg S3Config) (*S3, error) {
+	return newS3(cfg, nil)
+}
+
+// newS3 returns a storage whose requests are sent with transport, or the default transport if it's nil.
+func newS3(cfg S3Config, transport http.RoundTripper) (*S3, error) {
+	endpoint, err := url.Parse(cfg.Endpoint)
+	if err != nil || endpoint.Host == "" || (endpoint.Scheme != "http" && endpoint.Scheme != "https") ||
+		(endpoint.Path != "" && endpoint.Path != "/") {
+		return nil, fmt.Errorf("invalid S3 endpoint %q", cfg.Endpoint)
+	}
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("no S3 bucket configured")
+	}
+	if cfg.Region == "" {
+		// With a region, the client doesn't have to look up the location of the bucket.
+		cfg.Region = "us-east-1"
+	}
+
+	lookup := minio.BucketLookupDNS
+	if cfg.PathStyle {
+		lookup = minio.BucketLookupPath
+	}
+	client, err := minio.New(endpoint.Host, &minio.Options{
+		Creds:        credentials.NewStaticV4(cfg.AccessKeyID, cfg.SecretAccessKey, ""),
+		Secure:       endpoint.Scheme == "https",
+		Region:       cfg.Region,
+		BucketLookup: lookup,
+		Transport:    transport,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating S3 client: %w", err)
+	}
+	return &S3{bucket: cfg.Bucket, client: client}, nil
+}
+
+// Put streams the content of r to the storage. If the size of r is known, e.g. for a [bytes.Reader], it's
+// uploaded with a single request. Otherwise, it's uploaded in parts.
+func (s *S3) Put(ctx context.Context, key string, r io.Reader, contentType string) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+
+	size := int64(-1)
+	if l, ok := r.(interface{ Len() int }); ok {
+		size = int64(l.Len())
+	}
+	if _, err := s.client.PutObject(ctx, s.bucket, key, r, size, minio.PutObjectOptions{ContentType: contentType}); err != nil {
+		return fmt.Errorf("uploading %q to S3: %w", key, err)
+	}
+	return nil
+}
+
+func (s *S3) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
+
+	// Unlike the object of Client.GetObject, which is requested lazily, Core.GetObject sends the request
+	// right away, so that missing objects are detected here.
+	body, _, _, err := minio.Core{Client: s.client}.GetObject(ctx, s.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		if isNotFound(err) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("downloading %q from S3: %w", key, err)
+	}
+	return body, nil
+}
+
+func (s *S3) Delete(ctx context.Context, key string) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+
+	if err := s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{}); err != nil && !isNotFound(err) {
+		return fmt.Errorf("deleting %q from S3: %w", key, err)
+	}
+	return nil
+}
+
+// isNotFound returns true if the error of the client means that the object doesn't exist.
+func isNotFound(err error) bool {
+	var resp minio.ErrorResponse
+	if !errors.As(err, &resp) {
+		return false
+	}
+	return resp.Code == "NoSuchKey" || resp.StatusCode == http.StatusNotFound
+}
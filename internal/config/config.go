@@ -0,0 +1,233 @@
+// Package config loads configurations in layers: the values of a TOML file override the defaults, and are
+// overridden by environment variables and explicitly set values, e.g. of flags.
+//
+// A configuration is a struct with toml tags. Each value has a key, which is the path of its TOML names, e.g.
+// "web.endpoint", and an environment variable, e.g. MAHLZEIT_WEB_ENDPOINT. Secrets can be read from files with
+// the suffix _FILE, e.g. MAHLZEIT_DATABASE_CONNECTION_STRING_FILE, which is common for container secrets.
+// Fields with the tag secret:"true" are hidden by [Redacted].
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// field is a single value of a configuration.
+type field struct {
+	key   string // e.g. "storage.s3.access-key-id"
+	index []int
+}
+
+// fields returns all values of the struct type, including the ones of nested structs.
+func fields(t reflect.Type, prefix string) []field {
+	var res []field
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("toml"), ",")
+		if name == "-" || !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+
+		if f.Type.Kind() == reflect.Struct {
+			for _, nested := range fields(f.Type, prefix+name+".") {
+				nested.index = append([]int{i}, nested.index...)
+				res = append(res, nested)
+			}
+			continue
+		}
+		res = append(res, field{key: prefix + name, index: []int{i}})
+	}
+	return res
+}
+
+// EnvName returns the name of the environment variable for the key, e.g. MAHLZEIT_WEB_ENDPOINT for
+// "web.endpoint" with the prefix "MAHLZEIT".
+func EnvName(prefix, key string) string {
+	return prefix + "_" + strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(key))
+}
+
+// LoadFile decodes the TOML file into cfg, which must be a pointer to a struct. A missing file is only an error if
+// it's required, e.g. because it was configured explicitly. Unknown keys are reported as errors, since they're
+// most likely typos.
+func LoadFile(cfg any, path string, required bool) error {
+	md, err := toml.DecodeFile(path, cfg)
+	if errors.Is(err, fs.ErrNotExist) && !required {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("parsing %s failed: %w", path, err)
+	}
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		keys := make([]string, 0, len(undecoded))
+		for _, k := range undecoded {
+			keys = append(keys, k.String())
+		}
+		return fmt.Errorf("parsing %s failed: unknown keys %s", path, strings.Join(keys, ", "))
+	}
+	return nil
+}
+
+// LoadEnv sets the values of cfg, which must be a pointer to a struct, from the environment variables with the
+// prefix. Either a variable or the one with the suffix _FILE may be set, in which case the content of the file
+// is used.
+func LoadEnv(cfg any, prefix string, lookup func(string) (string, bool)) error {
+	v := reflect.ValueOf(cfg).Elem()
+	var errs []error
+	for _, f := range fields(v.Type(), "") {
+		name := EnvName(prefix, f.key)
+		value, ok := lookup(name)
+		file, fromFile := lookup(name + "_FILE")
+		if ok && fromFile {
+			errs = append(errs, fmt.Errorf("%s: only one of %s and %s_FILE may be set", name, name, name))
+			continue
+		}
+		if fromFile {
+			b, err := os.ReadFile(file)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s_FILE: %w", name, err))
+				continue
+			}
+			// Files usually end with a newline, which isn't part of the secret.
+			value, ok = strings.TrimRight(string(b), "\r\n"), true
+		}
+		if !ok {
+			continue
+		}
+
+		if err := setValue(v.FieldByIndex(f.index), value); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Set sets the value of the key, e.g. "web.endpoint", in cfg, which must be a pointer to a struct.
+func Set(cfg any, key, value string) error {
+	v := reflect.ValueOf(cfg).Elem()
+	for _, f := range fields(v.Type(), "") {
+		if f.key == key {
+			if err := setValue(v.FieldByIndex(f.index), value); err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown key %q", key)
+}
+
+// setValue parses the text for the type of v. Lists are separated by commas, e.g. "a,b", and so are the entries of
+// maps, e.g. "a=1,b=2". Durations have the format of [time.ParseDuration], e.g. "30s".
+func setValue(v reflect.Value, text string) error {
+	if v.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(text)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q, expected e.g. 30s", text)
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(text)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(text)
+		if err != nil {
+			return fmt.Errorf("invalid value %q, expected true or false", text)
+		}
+		v.SetBool(b)
+	case reflect.Int:
+		i, err := strconv.Atoi(text)
+		if err != nil {
+			return fmt.Errorf("invalid value %q, expected a number", text)
+		}
+		v.SetInt(int64(i))
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return fmt.Errorf("invalid value %q, expected a number", text)
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported type %s", v.Type())
+		}
+		var list []string
+		for _, s := range strings.Split(text, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				list = append(list, s)
+			}
+		}
+		v.Set(reflect.ValueOf(list))
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String || v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported type %s", v.Type())
+		}
+		m := make(map[string]string)
+		for _, entry := range strings.Split(text, ",") {
+			if strings.TrimSpace(entry) == "" {
+				continue
+			}
+			k, val, ok := strings.Cut(entry, "=")
+			if !ok {
+				return fmt.Errorf("invalid entry %q, expected key=value", entry)
+			}
+			m[strings.TrimSpace(k)] = strings.TrimSpace(val)
+		}
+		v.Set(reflect.ValueOf(m))
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// redactedValue replaces secrets, like [url.URL.Redacted].
+const redactedValue = "xxxxx"
+
+// Redacted returns a copy of cfg, which must be a struct, without secrets. Fields with the tag secret:"true" are
+// replaced entirely, unless they're empty. With secret:"dsn", only the password of a database connection string
+// is replaced, so that the other parameters are still visible.
+func Redacted[T any](cfg T) T {
+	v := reflect.ValueOf(&cfg).Elem()
+	for _, f := range fields(v.Type(), "") {
+		sf := v.Type().FieldByIndex(f.index)
+		fv := v.FieldByIndex(f.index)
+		if fv.Kind() != reflect.String || fv.String() == "" {
+			continue
+		}
+		switch sf.Tag.Get("secret") {
+		case "true":
+			fv.SetString(redactedValue)
+		case "dsn":
+			fv.SetString(redactDSN(fv.String()))
+		}
+	}
+	return cfg
+}
+
+var dsnPassword = regexp.MustCompile(`password=('[^']*'|\S*)`)
+
+// redactDSN replaces the password of a connection string, which is either a URL or has the format key=value.
+func redactDSN(dsn string) string {
+	if u, err := url.Parse(dsn); err == nil && u.Scheme != "" {
+		if q := u.Query(); q.Has("password") {
+			q.Set("password", redactedValue)
+			u.RawQuery = q.Encode()
+		}
+		return u.Redacted()
+	}
+	return dsnPassword.ReplaceAllLiteralString(dsn, "password="+redactedValue)
+}
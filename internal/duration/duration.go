@@ -0,0 +1,329 @@
+// Package duration parses and formats durations in a human-friendly way, e.g. "1 Std. 30 Min.",
+// "1:30" or "anderthalb Stunden", so they can be entered in forms without knowing the syntax
+// of [time.ParseDuration]. German and English are supported.
+package duration
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/carlmjohnson/resperr"
+)
+
+// DefaultLocale is used for formatting, if no or an unsupported locale is given.
+const DefaultLocale = "de"
+
+// units maps all known spellings of a unit to its length. Keys are lower case and without a trailing dot.
+var units = map[string]time.Duration{
+	"h":        time.Hour,
+	"std":      time.Hour,
+	"stde":     time.Hour,
+	"stunde":   time.Hour,
+	"stunden":  time.Hour,
+	"hr":       time.Hour,
+	"hrs":      time.Hour,
+	"hour":     time.Hour,
+	"hours":    time.Hour,
+	"m":        time.Minute,
+	"min":      time.Minute,
+	"mins":     time.Minute,
+	"minute":   time.Minute,
+	"minuten":  time.Minute,
+	"minutes":  time.Minute,
+	"s":        time.Second,
+	"sek":      time.Second,
+	"sekunde":  time.Second,
+	"sekunden": time.Second,
+	"sec":      time.Second,
+	"secs":     time.Second,
+	"second":   time.Second,
+	"seconds":  time.Second,
+}
+
+// numberWords maps numbers that are written as words to their value. Consecutive numbers are
+// multiplied, so "eine halbe Stunde" and "half an hour" both result in 30 minutes.
+var numberWords = map[string]float64{
+	"ein":         1,
+	"eine":        1,
+	"einer":       1,
+	"einen":       1,
+	"zwei":        2,
+	"drei":        3,
+	"vier":        4,
+	"fünf":        5,
+	"sechs":       6,
+	"sieben":      7,
+	"acht":        8,
+	"neun":        9,
+	"zehn":        10,
+	"zwölf":       12,
+	"fünfzehn":    15,
+	"zwanzig":     20,
+	"dreißig":     30,
+	"halb":        0.5,
+	"halbe":       0.5,
+	"halben":      0.5,
+	"viertel":     0.25,
+	"dreiviertel": 0.75,
+	"anderthalb":  1.5,
+	"eineinhalb":  1.5,
+	"zweieinhalb": 2.5,
+	"a":           1,
+	"an":          1,
+	"one":         1,
+	"two":         2,
+	"three":       3,
+	"four":        4,
+	"five":        5,
+	"six":         6,
+	"seven":       7,
+	"eight":       8,
+	"nine":        9,
+	"ten":         10,
+	"twelve":      12,
+	"fifteen":     15,
+	"twenty":      20,
+	"thirty":      30,
+	"half":        0.5,
+	"quarter":     0.25,
+}
+
+// fillers are words that are ignored, e.g. in "1 Stunde und 30 Minuten".
+var fillers = map[string]bool{
+	"und": true,
+	"and": true,
+	"of":  true,
+}
+
+// Parse parses a duration like "1 Std. 30 Min.", "90 min", "1:30", "anderthalb Stunden" or "1h30m".
+// Numbers can contain a decimal comma or point, e.g. "1,5 Stunden". A number without a unit is
+// interpreted as the next smaller unit, e.g. "1 h 30" as 1 hour and 30 minutes, or as minutes if
+// it's the only number. Fractions in words belong to the preceding unit, e.g. "an hour and a half".
+// An empty string results in a duration of zero.
+//
+// Invalid input results in an error with a bad request status code and a message for the user.
+func Parse(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+
+	d, err := parse(s)
+	if err != nil {
+		return 0, resperr.WithUserMessagef(err, "invalid duration %q: %s", s, err.Error())
+	}
+	if d < 0 {
+		return 0, resperr.WithUserMessagef(nil, "invalid duration %q: must not be negative", s)
+	}
+	return d.Round(time.Second), nil
+}
+
+func parse(s string) (time.Duration, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		return d, nil
+	}
+	if d, ok := parseClock(s); ok {
+		return d, nil
+	}
+
+	tokens := tokenize(strings.ToLower(s))
+	if len(tokens) == 0 {
+		return 0, fmt.Errorf("no number found")
+	}
+
+	var (
+		res       time.Duration
+		err       error
+		amount    float64
+		hasValue  bool
+		hasDigits bool // whether the current amount was written with digits
+		lastUnit  time.Duration
+	)
+	for _, token := range tokens {
+		if fillers[token] {
+			continue
+		}
+
+		if n, ok := parseNumber(token); ok {
+			isDigits := unicode.IsDigit([]rune(token)[len([]rune(token))-1])
+			if !hasValue {
+				amount, hasDigits = 1, false
+			} else if hasDigits && isDigits {
+				return 0, fmt.Errorf("unexpected number %q", token)
+			}
+			amount *= n
+			hasValue = true
+			hasDigits = hasDigits || isDigits
+			continue
+		}
+
+		unit, ok := units[token]
+		if !ok {
+			return 0, fmt.Errorf("unknown unit %q", token)
+		}
+		if !hasValue {
+			return 0, fmt.Errorf("missing number before %q", token)
+		}
+
+		if res, err = add(res, amount, unit); err != nil {
+			return 0, err
+		}
+		lastUnit = unit
+		hasValue = false
+	}
+
+	if hasValue {
+		unit := smallerUnit(lastUnit)
+		if lastUnit != 0 && !hasDigits && amount < 1 {
+			// A fraction in words belongs to the preceding unit, e.g. in "an hour and a half".
+			unit = lastUnit
+		}
+		return add(res, amount, unit)
+	}
+	return res, nil
+}
+
+// add returns d plus amount times unit. Results that are not finite or exceed the range of [time.Duration] are
+// rejected, because the conversion of such floats is undefined.
+func add(d time.Duration, amount float64, unit time.Duration) (time.Duration, error) {
+	sum := float64(d) + amount*float64(unit)
+	if math.IsNaN(sum) || math.Abs(sum) >= math.MaxInt64 {
+		return 0, fmt.Errorf("too long")
+	}
+	return time.Duration(sum), nil
+}
+
+// parseClock parses durations in the form "h:mm" or "h:mm:ss".
+func parseClock(s string) (time.Duration, bool) {
+	parts := strings.Split(s, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, false
+	}
+
+	var res time.Duration
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 || (i > 0 && (len(p) != 2 || n >= 60)) {
+			return 0, false
+		}
+		res += time.Duration(n) * []time.Duration{time.Hour, time.Minute, time.Second}[i]
+	}
+	return res, true
+}
+
+// parseNumber parses a number, either as digits with an optional decimal comma or point, or as word.
+// Words that [strconv.ParseFloat] accepts, e.g. "inf" or "nan", aren't numbers. Numbers out of the range
+// of a float result in an infinite value, which is rejected by add.
+func parseNumber(s string) (float64, bool) {
+	if n, ok := numberWords[s]; ok {
+		return n, true
+	}
+
+	if digits := []rune(strings.TrimPrefix(s, "-")); len(digits) == 0 || !unicode.IsDigit(digits[0]) {
+		return 0, false
+	}
+	n, err := strconv.ParseFloat(strings.Replace(s, ",", ".", 1), 64)
+	if err != nil && !errors.Is(err, strconv.ErrRange) {
+		return 0, false
+	}
+	return n, true
+}
+
+// smallerUnit returns the unit that follows u, e.g. minutes for hours. Without a unit, minutes are used.
+func smallerUnit(u time.Duration) time.Duration {
+	switch u {
+	case time.Hour:
+		return time.Minute
+	case time.Minute, time.Second:
+		return time.Second
+	default:
+		return time.Minute
+	}
+}
+
+// tokenize splits s into numbers and words, e.g. "1h 30min." results in "1", "h", "30", "min".
+// Dots after words, e.g. in abbreviations, and other punctuation are removed.
+func tokenize(s string) []string {
+	var tokens []string
+	var current strings.Builder
+	var isNumber bool
+
+	flush := func() {
+		if current.Len() > 0 {
+			tokens = append(tokens, current.String())
+			current.Reset()
+		}
+	}
+
+	runes := []rune(s)
+	for i, r := range runes {
+		switch {
+		case unicode.IsDigit(r):
+			if !isNumber {
+				flush()
+			}
+			isNumber = true
+			current.WriteRune(r)
+		case (r == ',' || r == '.') && isNumber && current.Len() > 0 && i+1 < len(runes) && unicode.IsDigit(runes[i+1]):
+			current.WriteRune(r)
+		case unicode.IsLetter(r) || r == '-' && current.Len() == 0 && i+1 < len(runes) && unicode.IsDigit(runes[i+1]):
+			if isNumber {
+				flush()
+			}
+			isNumber = r == '-'
+			current.WriteRune(r)
+		default:
+			flush()
+			isNumber = false
+		}
+	}
+	flush()
+
+	return tokens
+}
+
+type localeUnits struct {
+	hours, minutes, seconds string
+}
+
+var formats = map[string]localeUnits{
+	"de": {hours: "Std.", minutes: "Min.", seconds: "Sek."},
+	"en": {hours: "hr", minutes: "min", seconds: "sec"},
+}
+
+// Format returns d for the given locale, e.g. "1 Std. 30 Min." for German or "1 hr 30 min" for English.
+// The result is accepted by Parse. Locales can contain a region, e.g. "en-GB". For unsupported locales,
+// the DefaultLocale is used. Seconds are only included if they are not zero, and a duration of zero
+// results in an empty string.
+func Format(d time.Duration, locale string) string {
+	if d <= 0 {
+		return ""
+	}
+
+	lang, _, _ := strings.Cut(strings.ToLower(locale), "-")
+	lang, _, _ = strings.Cut(lang, "_")
+	f, ok := formats[lang]
+	if !ok {
+		f = formats[DefaultLocale]
+	}
+
+	d = d.Round(time.Second)
+	h, m, s := d/time.Hour, (d%time.Hour)/time.Minute, (d%time.Minute)/time.Second
+
+	var parts []string
+	if h > 0 {
+		parts = append(parts, fmt.Sprintf("%d %s", h, f.hours))
+	}
+	if m > 0 {
+		parts = append(parts, fmt.Sprintf("%d %s", m, f.minutes))
+	}
+	if s > 0 {
+		parts = append(parts, fmt.Sprintf("%d %s", s, f.seconds))
+	}
+	return strings.Join(parts, " ")
+}
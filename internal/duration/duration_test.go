@@ -0,0 +1,101 @@
+package duration
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/carlmjohnson/resperr"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{input: "", want: 0},
+		{input: "1h30m", want: 90 * time.Minute},
+		{input: "1 h 30 min", want: 90 * time.Minute},
+		{input: "1 Std. 30 Min.", want: 90 * time.Minute},
+		{input: "1 Stunde und 30 Minuten", want: 90 * time.Minute},
+		{input: "1 h 30", want: 90 * time.Minute},
+		{input: "90 Minuten", want: 90 * time.Minute},
+		{input: "90 min", want: 90 * time.Minute},
+		{input: "1:30", want: 90 * time.Minute},
+		{input: "0:45:30", want: 45*time.Minute + 30*time.Second},
+		{input: "1,5 Stunden", want: 90 * time.Minute},
+		{input: "anderthalb Stunden", want: 90 * time.Minute},
+		{input: "eine halbe Stunde", want: 30 * time.Minute},
+		{input: "half an hour", want: 30 * time.Minute},
+		{input: "2 hours and 15 minutes", want: 135 * time.Minute},
+		{input: "1 hr 5 sec", want: time.Hour + 5*time.Second},
+		{input: "45", want: 45 * time.Minute},
+		{input: "10min 30s", want: 10*time.Minute + 30*time.Second},
+		{input: "an hour and a half", want: 90 * time.Minute},
+		{input: "eine Stunde und eine halbe", want: 90 * time.Minute},
+		{input: "2 hours and a quarter", want: 135 * time.Minute},
+		{input: "1 Tag", wantErr: true},
+		{input: "Stunden", wantErr: true},
+		{input: "1 1/2 Stunden", wantErr: true},
+		{input: "1:75", wantErr: true},
+		{input: "-5 min", wantErr: true},
+		{input: "-1h", wantErr: true},
+		{input: "inf min", wantErr: true},
+		{input: "NaN Stunden", wantErr: true},
+		{input: "infinity", wantErr: true},
+		{input: "1" + strings.Repeat("0", 400) + " h", wantErr: true},
+		{input: "9999999999 h", wantErr: true},
+		{input: "2562047 h 60 min", wantErr: true},
+		{input: "-9999999999 h 1 min", wantErr: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.input, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := Parse(tt.input)
+			if tt.wantErr {
+				assert.Equal(t, http.StatusBadRequest, resperr.StatusCode(err))
+				assert.NotZero(t, resperr.UserMessage(err))
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestFormat(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input  time.Duration
+		locale string
+		want   string
+	}{
+		{input: 0, locale: "de", want: ""},
+		{input: 90 * time.Minute, locale: "de", want: "1 Std. 30 Min."},
+		{input: 90 * time.Minute, locale: "en-GB", want: "1 hr 30 min"},
+		{input: 2 * time.Hour, locale: "", want: "2 Std."},
+		{input: 45*time.Minute + 10*time.Second, locale: "en", want: "45 min 10 sec"},
+		{input: 20 * time.Second, locale: "fr", want: "20 Sek."},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.want, func(t *testing.T) {
+			t.Parallel()
+
+			got := Format(tt.input, tt.locale)
+			assert.Equal(t, tt.want, got)
+
+			parsed, err := Parse(got)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.input, parsed)
+		})
+	}
+}
@@ -0,0 +1,185 @@
+// Package api provides the versioned JSON API. It uses the same methods of [app.Application] as the
+// HTML handlers in package routes, but returns JSON instead of HTML fragments for HTMX.
+//
+// Requests are authenticated with a personal API token of a user or with the session of the browser.
+// All endpoints are documented in openapi.json, which is served at /api/v1/openapi.json.
+// Its paths are checked against the registered handlers by the tests of this package.
+package api
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"codeberg.org/mahlzeit/mahlzeit/internal/app"
+	"codeberg.org/mahlzeit/mahlzeit/internal/zaphelper"
+	"github.com/carlmjohnson/resperr"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+// Prefix is the path at which the handlers of [Routes] are mounted.
+const Prefix = "/api/v1"
+
+// maxBodySize limits the size of JSON request bodies.
+const maxBodySize = 1 << 20
+
+//go:embed openapi.json
+var openAPIDocument []byte
+
+// Routes returns the handlers of the API. They must be mounted at [Prefix].
+func Routes(c *app.Application) chi.Router {
+	r := chi.NewRouter()
+	h := handlers{c}
+
+	r.NotFound(errorWrapper(func(w http.ResponseWriter, r *http.Request) error {
+		return resperr.NotFound(r)
+	}))
+	r.MethodNotAllowed(errorWrapper(func(w http.ResponseWriter, r *http.Request) error {
+		return resperr.New(http.StatusMethodNotAllowed, "method %s is not allowed for %s", r.Method, r.URL.Path)
+	}))
+
+	// The document is public, so that clients can be generated without an account.
+	r.Get("/openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(openAPIDocument)
+	})
+
+	r.Group(func(r chi.Router) {
+		r.Use(h.authenticate)
+
+		r.Route("/recipes", func(r chi.Router) {
+			r.Get("/", errorWrapper(h.listRecipes))
+			r.Post("/", errorWrapper(h.createRecipe))
+			r.Route("/{id}", func(r chi.Router) {
+				r.Get("/", errorWrapper(h.getRecipe))
+				r.Put("/", errorWrapper(h.updateRecipe))
+				r.Delete("/", errorWrapper(h.deleteRecipe))
+				r.Get("/steps", errorWrapper(h.listSteps))
+				r.Post("/steps", errorWrapper(h.createStep))
+				r.Put("/steps/order", errorWrapper(h.reorderSteps))
+				r.Route("/steps/{stepID}", func(r chi.Router) {
+					r.Get("/", errorWrapper(h.getStep))
+					r.Put("/", errorWrapper(h.updateStep))
+					r.Delete("/", errorWrapper(h.deleteStep))
+					r.Post("/move", errorWrapper(h.moveStep))
+					r.Get("/ingredients", errorWrapper(h.listStepIngredients))
+					r.Post("/ingredients", errorWrapper(h.addStepIngredient))
+					r.Put("/ingredients/order", errorWrapper(h.reorderStepIngredients))
+					r.Get("/ingredients/{ingredientID}", errorWrapper(h.getStepIngredient))
+					r.Put("/ingredients/{ingredientID}", errorWrapper(h.updateStepIngredient))
+					r.Delete("/ingredients/{ingredientID}", errorWrapper(h.deleteStepIngredient))
+				})
+			})
+		})
+		r.Route("/ingredients", func(r chi.Router) {
+			r.Get("/", errorWrapper(h.listIngredients))
+			r.Post("/", errorWrapper(h.createIngredient))
+			r.Get("/{ingredientID}", errorWrapper(h.getIngredient))
+		})
+		r.Route("/units", func(r chi.Router) {
+			r.Get("/", errorWrapper(h.listUnits))
+			r.Post("/", errorWrapper(h.createUnit))
+		})
+		r.Route("/households", func(r chi.Router) {
+			r.Get("/", errorWrapper(h.listHouseholds))
+			r.Post("/", errorWrapper(h.createHousehold))
+			r.Route("/{householdID}", func(r chi.Router) {
+				r.Get("/", errorWrapper(h.getHousehold))
+				r.Delete("/", errorWrapper(h.deleteHousehold))
+				r.Put("/members/{userID}", errorWrapper(h.addHouseholdMember))
+				r.Delete("/members/{userID}", errorWrapper(h.removeHouseholdMember))
+			})
+		})
+		r.Get("/log/level", errorWrapper(h.getLogLevels))
+		r.Put("/log/level", errorWrapper(h.setLogLevel))
+	})
+
+	return r
+}
+
+// handlers is the struct that all API handlers attach to, like appWrapper for the HTML handlers.
+type handlers struct{ app *app.Application }
+
+// handlerFunc is a [http.HandlerFunc] that can return an error, see errorWrapper.
+type handlerFunc func(w http.ResponseWriter, r *http.Request) error
+
+// errorWrapper takes a handlerFunc and writes all errors as JSON. Like [app.HandleError], the status code
+// and message are taken from the error with [resperr]. Unexpected errors are logged without exposing them.
+func errorWrapper(fn handlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := fn(w, r); err != nil {
+			writeError(w, r, err)
+		}
+	}
+}
+
+// writeError writes err as JSON. Unexpected errors are logged with their details.
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	code := resperr.StatusCode(err)
+	if code == http.StatusInternalServerError {
+		zaphelper.FromRequest(r).Error("unexpected error", zap.Error(err))
+	} else {
+		zaphelper.FromRequest(r).Info("error during request", zap.Error(err))
+	}
+
+	writeJSON(w, r, code, errorBody{Error: errorDetails{
+		Status:  code,
+		Message: resperr.UserMessage(err),
+	}})
+}
+
+// errorBody is the body of all responses with an error status code.
+type errorBody struct {
+	Error errorDetails `json:"error"`
+}
+
+type errorDetails struct {
+	Status  int    `json:"status"`
+	Message string `json:"message"`
+}
+
+// writeJSON writes v as JSON with the given status code.
+func writeJSON(w http.ResponseWriter, r *http.Request, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		zaphelper.FromRequest(r).Warn("writing JSON response failed", zap.Error(err))
+	}
+}
+
+// readJSON decodes the JSON body of r into v. Unknown fields and trailing data are rejected,
+// so that typos in field names don't go unnoticed.
+func readJSON(w http.ResponseWriter, r *http.Request, v any) error {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(v); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return resperr.WithCodeAndMessage(err, http.StatusRequestEntityTooLarge, "request body is too large")
+		}
+		return resperr.WithUserMessagef(err, "invalid JSON body: %s", err.Error())
+	}
+	if err := dec.Decode(&struct{}{}); !errors.Is(err, io.EOF) {
+		return resperr.WithUserMessage(err, "invalid JSON body: only a single value is allowed")
+	}
+	return nil
+}
+
+// created writes v with the status code 201 and the location of the new resource.
+func created(w http.ResponseWriter, r *http.Request, location string, v any) {
+	w.Header().Set("Location", Prefix+location)
+	writeJSON(w, r, http.StatusCreated, v)
+}
+
+// noContent writes an empty response with the status code 204.
+func noContent(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func requiredField(name string) error {
+	return resperr.WithUserMessagef(nil, "the field %q is required", name)
+}
@@ -0,0 +1,237 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"codeberg.org/mahlzeit/mahlzeit/internal/app"
+	"github.com/alecthomas/assert/v2"
+	"github.com/go-chi/chi/v5"
+)
+
+// TestOpenAPIDocument ensures that the OpenAPI document describes exactly the registered handlers.
+func TestOpenAPIDocument(t *testing.T) {
+	t.Parallel()
+
+	var doc struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	assert.NoError(t, json.Unmarshal(openAPIDocument, &doc))
+
+	var documented []string
+	for path, item := range doc.Paths {
+		for method := range item {
+			if method == "parameters" {
+				continue
+			}
+			documented = append(documented, strings.ToUpper(method)+" "+path)
+		}
+	}
+
+	var registered []string
+	err := chi.Walk(Routes(nil), func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		if route != "/" {
+			route = strings.TrimSuffix(route, "/")
+		}
+		registered = append(registered, method+" "+route)
+		return nil
+	})
+	assert.NoError(t, err)
+
+	sort.Strings(documented)
+	sort.Strings(registered)
+	assert.Equal(t, registered, documented)
+}
+
+func TestErrorBody(t *testing.T) {
+	t.Parallel()
+
+	// None of these requests reach the application, so it can be nil. The user is logged in with a session.
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		body        string
+		wantStatus  int
+		wantMessage string
+	}{
+		{
+			name:        "unknown path",
+			method:      http.MethodGet,
+			path:        "/does-not-exist",
+			wantStatus:  http.StatusNotFound,
+			wantMessage: `could not find path "/does-not-exist"`,
+		},
+		{
+			name:        "method not allowed",
+			method:      http.MethodPatch,
+			path:        "/recipes",
+			wantStatus:  http.StatusMethodNotAllowed,
+			wantMessage: "Method Not Allowed",
+		},
+		{
+			name:        "invalid ID",
+			method:      http.MethodGet,
+			path:        "/recipes/abc",
+			wantStatus:  http.StatusBadRequest,
+			wantMessage: `invalid ID in parameter "id" provided`,
+		},
+		{
+			name:        "invalid limit",
+			method:      http.MethodGet,
+			path:        "/recipes?limit=1000",
+			wantStatus:  http.StatusBadRequest,
+			wantMessage: "limit must be a number between 1 and 200",
+		},
+		{
+			name:        "unknown field",
+			method:      http.MethodPost,
+			path:        "/recipes",
+			body:        `{"title": "Pizza"}`,
+			wantStatus:  http.StatusBadRequest,
+			wantMessage: `invalid JSON body: json: unknown field "title"`,
+		},
+		{
+			name:        "multiple values",
+			method:      http.MethodPost,
+			path:        "/units",
+			body:        `{"name": "g"} {"name": "kg"}`,
+			wantStatus:  http.StatusBadRequest,
+			wantMessage: "invalid JSON body: only a single value is allowed",
+		},
+		{
+			name:        "missing field",
+			method:      http.MethodPost,
+			path:        "/recipes",
+			body:        `{"servings": 2}`,
+			wantStatus:  http.StatusBadRequest,
+			wantMessage: `the field "name" is required`,
+		},
+		{
+			name:        "invalid servings",
+			method:      http.MethodPost,
+			path:        "/recipes",
+			body:        `{"name": "Pizza", "servings": 0}`,
+			wantStatus:  http.StatusBadRequest,
+			wantMessage: "servings must be at least 1",
+		},
+		{
+			name:        "missing log level",
+			method:      http.MethodPut,
+			path:        "/log/level",
+			body:        `{"logger": "http"}`,
+			wantStatus:  http.StatusBadRequest,
+			wantMessage: `the field "level" is required`,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			r = r.WithContext(app.WithUserID(r.Context(), 1))
+			Routes(nil).ServeHTTP(w, r)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+			var body errorBody
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			assert.Equal(t, errorBody{Error: errorDetails{Status: tt.wantStatus, Message: tt.wantMessage}}, body)
+		})
+	}
+}
+
+func TestAuthentication(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		path          string
+		authorization string
+		wantStatus    int
+	}{
+		{name: "without authentication", path: "/recipes", wantStatus: http.StatusUnauthorized},
+		{name: "basic authentication", path: "/recipes", authorization: "Basic dXNlcjpwYXNz", wantStatus: http.StatusUnauthorized},
+		{name: "OpenAPI document", path: "/openapi.json", wantStatus: http.StatusOK},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.authorization != "" {
+				r.Header.Set("Authorization", tt.authorization)
+			}
+			Routes(nil).ServeHTTP(w, r)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantStatus == http.StatusUnauthorized {
+				assert.Contains(t, w.Header().Get("WWW-Authenticate"), "Bearer")
+			}
+		})
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	t.Parallel()
+
+	items := []int{1, 2, 3, 4, 5}
+	tests := []struct {
+		name string
+		page page
+		want []int
+	}{
+		{name: "all", page: page{Limit: 10}, want: items},
+		{name: "first page", page: page{Limit: 2}, want: []int{1, 2}},
+		{name: "last page", page: page{Limit: 2, Offset: 4}, want: []int{5}},
+		{name: "offset too large", page: page{Limit: 2, Offset: 5}, want: []int{}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := paginate(items, tt.page)
+			assert.Equal(t, list[int]{Items: tt.want, Total: 5, Limit: tt.page.Limit, Offset: tt.page.Offset}, got)
+		})
+	}
+}
+
+func TestParsePage(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		query   string
+		want    page
+		wantErr bool
+	}{
+		{query: "", want: page{Limit: defaultLimit}},
+		{query: "limit=10&offset=20", want: page{Limit: 10, Offset: 20}},
+		{query: "limit=0", wantErr: true},
+		{query: "limit=abc", wantErr: true},
+		{query: "offset=-1", wantErr: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.query, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := parsePage(httptest.NewRequest(http.MethodGet, "/?"+tt.query, nil))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
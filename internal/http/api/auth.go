@@ -0,0 +1,51 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"codeberg.org/mahlzeit/mahlzeit/internal/app"
+	"codeberg.org/mahlzeit/mahlzeit/internal/http/httpreq"
+	"github.com/carlmjohnson/resperr"
+)
+
+// authenticate requires either an API token in the Authorization header or a session of a logged-in user,
+// which is set up by the HTML routes in front of the API. Tokens with the scope [app.ScopeRead] may only
+// perform requests that don't change anything.
+func (h handlers) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Authorization")
+		if header == "" {
+			if app.UserIDFromContext(r.Context()) == 0 {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="mahlzeit"`)
+				writeError(w, r, resperr.WithCodeAndMessage(nil, http.StatusUnauthorized, "authentication required"))
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		token, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="mahlzeit"`)
+			writeError(w, r, resperr.WithCodeAndMessage(nil, http.StatusUnauthorized, "only bearer tokens are supported"))
+			return
+		}
+
+		userID, scope, err := h.app.AuthenticateAPIToken(r.Context(), strings.TrimSpace(token))
+		if err != nil {
+			if resperr.StatusCode(err) == http.StatusUnauthorized {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="mahlzeit", error="invalid_token"`)
+			}
+			writeError(w, r, err)
+			return
+		}
+		if scope != app.ScopeWrite && !httpreq.IsSafeMethod(r.Method) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="mahlzeit", error="insufficient_scope"`)
+			writeError(w, r, resperr.WithCodeAndMessage(nil, http.StatusForbidden, "the API token may only read"))
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(app.WithUserID(r.Context(), userID)))
+	})
+}
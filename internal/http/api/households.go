@@ -0,0 +1,112 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"codeberg.org/mahlzeit/mahlzeit/internal/http/httpreq"
+)
+
+func (h handlers) listHouseholds(w http.ResponseWriter, r *http.Request) error {
+	p, err := parsePage(r)
+	if err != nil {
+		return err
+	}
+
+	households, err := h.app.GetAllHouseholds(r.Context())
+	if err != nil {
+		return err
+	}
+
+	writeJSON(w, r, http.StatusOK, paginateFunc(households, p, fromHousehold))
+	return nil
+}
+
+func (h handlers) createHousehold(w http.ResponseWriter, r *http.Request) error {
+	var in nameInput
+	if err := readJSON(w, r, &in); err != nil {
+		return err
+	}
+	if in.Name == "" {
+		return requiredField("name")
+	}
+
+	res, err := h.app.AddHousehold(r.Context(), in.Name)
+	if err != nil {
+		return err
+	}
+
+	created(w, r, "/households/"+strconv.Itoa(res.ID), fromHousehold(res))
+	return nil
+}
+
+func (h handlers) getHousehold(w http.ResponseWriter, r *http.Request) error {
+	id, err := httpreq.StrictIDParam(r, "householdID")
+	if err != nil {
+		return err
+	}
+
+	res, err := h.app.GetHousehold(r.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	writeJSON(w, r, http.StatusOK, fromHousehold(res))
+	return nil
+}
+
+func (h handlers) deleteHousehold(w http.ResponseWriter, r *http.Request) error {
+	id, err := httpreq.StrictIDParam(r, "householdID")
+	if err != nil {
+		return err
+	}
+
+	if err := h.app.DeleteHousehold(r.Context(), id); err != nil {
+		return err
+	}
+
+	noContent(w)
+	return nil
+}
+
+// addHouseholdMember adds a user to a household. Adding an existing member succeeds without changes.
+func (h handlers) addHouseholdMember(w http.ResponseWriter, r *http.Request) error {
+	householdID, userID, err := memberParams(r)
+	if err != nil {
+		return err
+	}
+
+	if err := h.app.AddHouseholdMember(r.Context(), householdID, userID); err != nil {
+		return err
+	}
+
+	noContent(w)
+	return nil
+}
+
+// removeHouseholdMember removes a user from a household. Removing a user who is no member succeeds as well.
+func (h handlers) removeHouseholdMember(w http.ResponseWriter, r *http.Request) error {
+	householdID, userID, err := memberParams(r)
+	if err != nil {
+		return err
+	}
+
+	if err := h.app.RemoveHouseholdMember(r.Context(), householdID, userID); err != nil {
+		return err
+	}
+
+	noContent(w)
+	return nil
+}
+
+func memberParams(r *http.Request) (householdID, userID int, err error) {
+	householdID, err = httpreq.StrictIDParam(r, "householdID")
+	if err != nil {
+		return 0, 0, err
+	}
+	userID, err = httpreq.StrictIDParam(r, "userID")
+	if err != nil {
+		return 0, 0, err
+	}
+	return householdID, userID, nil
+}
@@ -0,0 +1,34 @@
+package api
+
+import (
+	"net/http"
+)
+
+// The levels of the log are only available to superusers, see [app.Application.SetLogLevel].
+
+func (h handlers) getLogLevels(w http.ResponseWriter, r *http.Request) error {
+	level, named, err := h.app.GetLogLevels(r.Context())
+	if err != nil {
+		return err
+	}
+
+	writeJSON(w, r, http.StatusOK, logLevels{Level: level, Levels: named})
+	return nil
+}
+
+// setLogLevel changes the level of a named logger, or the default level without a logger. It returns all levels.
+func (h handlers) setLogLevel(w http.ResponseWriter, r *http.Request) error {
+	var in logLevelInput
+	if err := readJSON(w, r, &in); err != nil {
+		return err
+	}
+	if in.Level == "" {
+		return requiredField("level")
+	}
+
+	if err := h.app.SetLogLevel(r.Context(), in.Logger, in.Level); err != nil {
+		return err
+	}
+
+	return h.getLogLevels(w, r)
+}
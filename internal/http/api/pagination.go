@@ -0,0 +1,82 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/carlmjohnson/resperr"
+)
+
+const (
+	defaultLimit = 50
+	maxLimit     = 200
+)
+
+// page is the requested part of a list, given by the query parameters "limit" and "offset".
+type page struct {
+	Limit  int
+	Offset int
+}
+
+// parsePage returns the page requested by r. Without parameters, the first defaultLimit items are requested.
+func parsePage(r *http.Request) (page, error) {
+	p := page{Limit: defaultLimit}
+
+	query := r.URL.Query()
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxLimit {
+			return page{}, resperr.WithUserMessagef(err, "limit must be a number between 1 and %d", maxLimit)
+		}
+		p.Limit = limit
+	}
+	if v := query.Get("offset"); v != "" {
+		offset, err := strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			return page{}, resperr.WithUserMessage(err, "offset must be a number not less than 0")
+		}
+		p.Offset = offset
+	}
+
+	return p, nil
+}
+
+// list is the body of all responses that contain a list of items.
+type list[T any] struct {
+	Items  []T `json:"items"`
+	Total  int `json:"total"` // number of all items, regardless of the page
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+}
+
+// paginate returns the items of the page p. Lists are small enough to be loaded completely, so the
+// pagination is done in memory instead of the database.
+func paginate[T any](items []T, p page) list[T] {
+	res := list[T]{
+		Items:  []T{},
+		Total:  len(items),
+		Limit:  p.Limit,
+		Offset: p.Offset,
+	}
+	if p.Offset >= len(items) {
+		return res
+	}
+
+	end := p.Offset + p.Limit
+	if end > len(items) {
+		end = len(items)
+	}
+	res.Items = items[p.Offset:end]
+	return res
+}
+
+// paginateFunc is like paginate, but converts the items of the page with fn.
+func paginateFunc[S, T any](items []S, p page, fn func(S) T) list[T] {
+	page := paginate(items, p)
+	return list[T]{
+		Items:  convert(page.Items, fn),
+		Total:  page.Total,
+		Limit:  page.Limit,
+		Offset: page.Offset,
+	}
+}
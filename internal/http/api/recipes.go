@@ -0,0 +1,134 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"codeberg.org/mahlzeit/mahlzeit/internal/http/httpreq"
+	"codeberg.org/mahlzeit/mahlzeit/internal/i18n"
+	"github.com/carlmjohnson/resperr"
+)
+
+func (h handlers) listRecipes(w http.ResponseWriter, r *http.Request) error {
+	p, err := parsePage(r)
+	if err != nil {
+		return err
+	}
+
+	recipes, err := h.app.GetAllRecipes(r.Context())
+	if err != nil {
+		return err
+	}
+	if locale := r.URL.Query().Get("locale"); locale != "" {
+		if !i18n.IsSupported(locale) {
+			return resperr.WithUserMessagef(nil, "unsupported language %q", locale)
+		}
+		if err := h.app.TranslateRecipeNames(r.Context(), recipes, locale); err != nil {
+			return err
+		}
+	}
+
+	writeJSON(w, r, http.StatusOK, paginateFunc(recipes, p, fromListEntry))
+	return nil
+}
+
+// getRecipe returns a single recipe. The query parameter "servings" recalculates the amounts
+// and "locale" translates the texts, both like the HTML page of a recipe.
+func (h handlers) getRecipe(w http.ResponseWriter, r *http.Request) error {
+	id, err := httpreq.StrictIDParam(r, "id")
+	if err != nil {
+		return err
+	}
+
+	res, err := h.app.GetSingleRecipe(r.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	query := r.URL.Query()
+	if v := query.Get("servings"); v != "" {
+		servings, err := strconv.Atoi(v)
+		if err != nil || servings < 1 {
+			return resperr.WithUserMessage(err, "servings must be a number of at least 1")
+		}
+		res.WithServings(servings)
+	}
+	if locale := query.Get("locale"); locale != "" {
+		if !i18n.IsSupported(locale) {
+			return resperr.WithUserMessagef(nil, "unsupported language %q", locale)
+		}
+		if err := h.app.TranslateRecipe(r.Context(), res, locale); err != nil {
+			return err
+		}
+	}
+
+	writeJSON(w, r, http.StatusOK, fromRecipe(res))
+	return nil
+}
+
+func (h handlers) createRecipe(w http.ResponseWriter, r *http.Request) error {
+	var in recipeInput
+	if err := readJSON(w, r, &in); err != nil {
+		return err
+	}
+	if err := in.validate(); err != nil {
+		return err
+	}
+
+	recipe := in.toRecipe(0)
+	if err := h.app.AddRecipe(r.Context(), recipe); err != nil {
+		return err
+	}
+
+	res, err := h.app.GetSingleRecipe(r.Context(), recipe.ID)
+	if err != nil {
+		return err
+	}
+	created(w, r, "/recipes/"+strconv.Itoa(res.ID), fromRecipe(res))
+	return nil
+}
+
+// updateRecipe replaces the basic information of a recipe. Steps and images are changed with their own endpoints.
+func (h handlers) updateRecipe(w http.ResponseWriter, r *http.Request) error {
+	id, err := httpreq.StrictIDParam(r, "id")
+	if err != nil {
+		return err
+	}
+
+	var in recipeInput
+	if err := readJSON(w, r, &in); err != nil {
+		return err
+	}
+	if err := in.validate(); err != nil {
+		return err
+	}
+
+	// UpdateRecipe doesn't notice missing recipes, so their existence is checked first.
+	if _, err := h.app.GetSingleRecipe(r.Context(), id); err != nil {
+		return err
+	}
+	if err := h.app.UpdateRecipe(r.Context(), in.toRecipe(id)); err != nil {
+		return err
+	}
+
+	res, err := h.app.GetSingleRecipe(r.Context(), id)
+	if err != nil {
+		return err
+	}
+	writeJSON(w, r, http.StatusOK, fromRecipe(res))
+	return nil
+}
+
+func (h handlers) deleteRecipe(w http.ResponseWriter, r *http.Request) error {
+	id, err := httpreq.StrictIDParam(r, "id")
+	if err != nil {
+		return err
+	}
+
+	if err := h.app.DeleteRecipe(r.Context(), id); err != nil {
+		return err
+	}
+
+	noContent(w)
+	return nil
+}
@@ -0,0 +1,342 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"codeberg.org/mahlzeit/mahlzeit/internal/app"
+	"codeberg.org/mahlzeit/mahlzeit/internal/http/httpreq"
+	"github.com/carlmjohnson/resperr"
+)
+
+// recipeStep returns the step of the URL parameters "id" and "stepID". It ensures that the step belongs to the
+// recipe of the URL before its request body is read.
+func (h handlers) recipeStep(r *http.Request) (app.Step, error) {
+	recipeID, err := httpreq.StrictIDParam(r, "id")
+	if err != nil {
+		return app.Step{}, err
+	}
+	stepID, err := httpreq.StrictIDParam(r, "stepID")
+	if err != nil {
+		return app.Step{}, err
+	}
+
+	step, err := h.app.GetStepByID(r.Context(), stepID)
+	if err != nil {
+		return app.Step{}, err
+	}
+	if step.RecipeID != recipeID {
+		return app.Step{}, resperr.New(http.StatusNotFound, "step %d is not part of recipe %d", stepID, recipeID)
+	}
+	return step, nil
+}
+
+func (h handlers) listSteps(w http.ResponseWriter, r *http.Request) error {
+	id, err := httpreq.StrictIDParam(r, "id")
+	if err != nil {
+		return err
+	}
+	p, err := parsePage(r)
+	if err != nil {
+		return err
+	}
+
+	recipe, err := h.app.GetSingleRecipe(r.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	writeJSON(w, r, http.StatusOK, paginateFunc(recipe.Steps, p, fromStep))
+	return nil
+}
+
+// createStep adds a step at the end of a recipe, or after the step given in the field "after".
+func (h handlers) createStep(w http.ResponseWriter, r *http.Request) error {
+	recipeID, err := httpreq.StrictIDParam(r, "id")
+	if err != nil {
+		return err
+	}
+
+	var in stepInput
+	if err := readJSON(w, r, &in); err != nil {
+		return err
+	}
+	if err := in.validate(); err != nil {
+		return err
+	}
+
+	s := &app.Step{
+		Instruction: in.Instruction,
+		Time:        time.Duration(in.TimeSeconds) * time.Second,
+	}
+	if in.After == 0 {
+		if _, err := h.app.GetSingleRecipe(r.Context(), recipeID); err != nil {
+			return err
+		}
+		err = h.app.AddStepToRecipe(r.Context(), recipeID, s)
+	} else {
+		err = h.app.InsertStepAfter(r.Context(), recipeID, in.After, s)
+	}
+	if err != nil {
+		return err
+	}
+
+	res, err := h.app.GetStepByID(r.Context(), s.ID)
+	if err != nil {
+		return err
+	}
+	created(w, r, fmt.Sprintf("/recipes/%d/steps/%d", recipeID, s.ID), fromStep(res))
+	return nil
+}
+
+func (h handlers) getStep(w http.ResponseWriter, r *http.Request) error {
+	step, err := h.recipeStep(r)
+	if err != nil {
+		return err
+	}
+
+	writeJSON(w, r, http.StatusOK, fromStep(step))
+	return nil
+}
+
+func (h handlers) updateStep(w http.ResponseWriter, r *http.Request) error {
+	step, err := h.recipeStep(r)
+	if err != nil {
+		return err
+	}
+
+	var in stepInput
+	if err := readJSON(w, r, &in); err != nil {
+		return err
+	}
+	if err := in.validate(); err != nil {
+		return err
+	}
+	if in.After != 0 {
+		return resperr.WithUserMessage(nil, `the field "after" is only allowed for new steps, use the endpoint "move" instead`)
+	}
+
+	step.Instruction = in.Instruction
+	step.Time = time.Duration(in.TimeSeconds) * time.Second
+	if err := h.app.UpdateStep(r.Context(), step); err != nil {
+		return err
+	}
+
+	writeJSON(w, r, http.StatusOK, fromStep(step))
+	return nil
+}
+
+func (h handlers) deleteStep(w http.ResponseWriter, r *http.Request) error {
+	step, err := h.recipeStep(r)
+	if err != nil {
+		return err
+	}
+
+	if err := h.app.DeleteRecipeStepByID(r.Context(), step.RecipeID, step.ID); err != nil {
+		return err
+	}
+
+	noContent(w)
+	return nil
+}
+
+func (h handlers) moveStep(w http.ResponseWriter, r *http.Request) error {
+	step, err := h.recipeStep(r)
+	if err != nil {
+		return err
+	}
+
+	var in moveInput
+	if err := readJSON(w, r, &in); err != nil {
+		return err
+	}
+	if in.Position < 1 {
+		return resperr.WithUserMessage(nil, "position must be at least 1")
+	}
+
+	if err := h.app.MoveStep(r.Context(), step.RecipeID, step.ID, in.Position); err != nil {
+		return err
+	}
+
+	noContent(w)
+	return nil
+}
+
+// reorderSteps sets the order of all steps of a recipe at once.
+func (h handlers) reorderSteps(w http.ResponseWriter, r *http.Request) error {
+	recipeID, err := httpreq.StrictIDParam(r, "id")
+	if err != nil {
+		return err
+	}
+
+	var in stepOrderInput
+	if err := readJSON(w, r, &in); err != nil {
+		return err
+	}
+	if in.StepIDs == nil {
+		return requiredField("stepIds")
+	}
+
+	if _, err := h.app.GetSingleRecipe(r.Context(), recipeID); err != nil {
+		return err
+	}
+	if err := h.app.ReorderSteps(r.Context(), recipeID, in.StepIDs); err != nil {
+		return err
+	}
+
+	noContent(w)
+	return nil
+}
+
+func (h handlers) listStepIngredients(w http.ResponseWriter, r *http.Request) error {
+	step, err := h.recipeStep(r)
+	if err != nil {
+		return err
+	}
+	p, err := parsePage(r)
+	if err != nil {
+		return err
+	}
+
+	writeJSON(w, r, http.StatusOK, paginateFunc(step.Ingredients, p, fromIngredient))
+	return nil
+}
+
+func (h handlers) addStepIngredient(w http.ResponseWriter, r *http.Request) error {
+	step, err := h.recipeStep(r)
+	if err != nil {
+		return err
+	}
+
+	var in stepIngredientInput
+	if err := readJSON(w, r, &in); err != nil {
+		return err
+	}
+	if err := in.validate(); err != nil {
+		return err
+	}
+	if in.IngredientID <= 0 {
+		return requiredField("ingredientId")
+	}
+
+	if err := h.app.AddIngredientToStep(r.Context(), app.AddIngredientToStepParams{
+		StepID:       step.ID,
+		IngredientID: in.IngredientID,
+		UnitID:       in.UnitID,
+		Amount:       in.Amount,
+		Note:         in.Note,
+	}); err != nil {
+		return err
+	}
+
+	res, err := h.app.GetStepIngredient(r.Context(), step.ID, in.IngredientID)
+	if err != nil {
+		return err
+	}
+	created(w, r, fmt.Sprintf("/recipes/%d/steps/%d/ingredients/%d", step.RecipeID, step.ID, res.ID), fromIngredient(res))
+	return nil
+}
+
+func (h handlers) getStepIngredient(w http.ResponseWriter, r *http.Request) error {
+	step, err := h.recipeStep(r)
+	if err != nil {
+		return err
+	}
+	ingredientID, err := httpreq.StrictIDParam(r, "ingredientID")
+	if err != nil {
+		return err
+	}
+
+	res, err := h.app.GetStepIngredient(r.Context(), step.ID, ingredientID)
+	if err != nil {
+		return err
+	}
+
+	writeJSON(w, r, http.StatusOK, fromIngredient(res))
+	return nil
+}
+
+func (h handlers) updateStepIngredient(w http.ResponseWriter, r *http.Request) error {
+	step, err := h.recipeStep(r)
+	if err != nil {
+		return err
+	}
+	ingredientID, err := httpreq.StrictIDParam(r, "ingredientID")
+	if err != nil {
+		return err
+	}
+
+	var in stepIngredientInput
+	if err := readJSON(w, r, &in); err != nil {
+		return err
+	}
+	if err := in.validate(); err != nil {
+		return err
+	}
+	if in.IngredientID != 0 && in.IngredientID != ingredientID {
+		return resperr.WithUserMessage(nil, "the ingredient of a step can't be changed, delete and add it instead")
+	}
+
+	if err := h.app.UpdateStepIngredient(r.Context(), app.UpdateStepIngredientParams{
+		RecipeID:     step.RecipeID,
+		StepID:       step.ID,
+		IngredientID: ingredientID,
+		UnitID:       in.UnitID,
+		Amount:       in.Amount,
+		Note:         in.Note,
+	}); err != nil {
+		return err
+	}
+
+	res, err := h.app.GetStepIngredient(r.Context(), step.ID, ingredientID)
+	if err != nil {
+		return err
+	}
+	writeJSON(w, r, http.StatusOK, fromIngredient(res))
+	return nil
+}
+
+func (h handlers) deleteStepIngredient(w http.ResponseWriter, r *http.Request) error {
+	step, err := h.recipeStep(r)
+	if err != nil {
+		return err
+	}
+	ingredientID, err := httpreq.StrictIDParam(r, "ingredientID")
+	if err != nil {
+		return err
+	}
+
+	if err := h.app.DeleteIngredientFromStep(r.Context(), app.DeleteIngredientFromStepParams{
+		StepID:       step.ID,
+		IngredientID: ingredientID,
+	}); err != nil {
+		return err
+	}
+
+	noContent(w)
+	return nil
+}
+
+// reorderStepIngredients sets the order of all ingredients of a step at once.
+func (h handlers) reorderStepIngredients(w http.ResponseWriter, r *http.Request) error {
+	step, err := h.recipeStep(r)
+	if err != nil {
+		return err
+	}
+
+	var in ingredientOrderInput
+	if err := readJSON(w, r, &in); err != nil {
+		return err
+	}
+	if in.IngredientIDs == nil {
+		return requiredField("ingredientIds")
+	}
+
+	if err := h.app.ReorderStepIngredients(r.Context(), step.RecipeID, step.ID, in.IngredientIDs); err != nil {
+		return err
+	}
+
+	noContent(w)
+	return nil
+}
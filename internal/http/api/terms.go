@@ -0,0 +1,104 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"codeberg.org/mahlzeit/mahlzeit/internal/app"
+	"codeberg.org/mahlzeit/mahlzeit/internal/http/httpreq"
+)
+
+// Ingredients and units are shared by all recipes. They can be listed and added,
+// but they are only changed by the recipes that use them.
+
+func fromIngredientTerm(i app.Ingredient) term {
+	return term{ID: i.ID, Name: i.Name}
+}
+
+func fromUnit(u app.Unit) term {
+	return term{ID: u.ID, Name: u.Name}
+}
+
+func (h handlers) listIngredients(w http.ResponseWriter, r *http.Request) error {
+	p, err := parsePage(r)
+	if err != nil {
+		return err
+	}
+
+	ingredients, err := h.app.GetAllIngredients(r.Context())
+	if err != nil {
+		return err
+	}
+
+	writeJSON(w, r, http.StatusOK, paginateFunc(ingredients, p, fromIngredientTerm))
+	return nil
+}
+
+// createIngredient adds an ingredient. If one with the same name exists already, it is returned instead.
+func (h handlers) createIngredient(w http.ResponseWriter, r *http.Request) error {
+	var in nameInput
+	if err := readJSON(w, r, &in); err != nil {
+		return err
+	}
+	if in.Name == "" {
+		return requiredField("name")
+	}
+
+	res, err := h.app.AddIngredient(r.Context(), in.Name)
+	if err != nil {
+		return err
+	}
+
+	created(w, r, "/ingredients/"+strconv.Itoa(res.ID), fromIngredientTerm(res))
+	return nil
+}
+
+func (h handlers) getIngredient(w http.ResponseWriter, r *http.Request) error {
+	id, err := httpreq.StrictIDParam(r, "ingredientID")
+	if err != nil {
+		return err
+	}
+
+	res, err := h.app.GetIngredient(r.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	writeJSON(w, r, http.StatusOK, fromIngredientTerm(res))
+	return nil
+}
+
+func (h handlers) listUnits(w http.ResponseWriter, r *http.Request) error {
+	p, err := parsePage(r)
+	if err != nil {
+		return err
+	}
+
+	units, err := h.app.GetAllUnits(r.Context())
+	if err != nil {
+		return err
+	}
+
+	writeJSON(w, r, http.StatusOK, paginateFunc(units, p, fromUnit))
+	return nil
+}
+
+// createUnit adds a unit. If one with the same name exists already, it is returned instead.
+func (h handlers) createUnit(w http.ResponseWriter, r *http.Request) error {
+	var in nameInput
+	if err := readJSON(w, r, &in); err != nil {
+		return err
+	}
+	if in.Name == "" {
+		return requiredField("name")
+	}
+
+	res, err := h.app.AddUnit(r.Context(), in.Name)
+	if err != nil {
+		return err
+	}
+
+	// There is no endpoint for single units, so the list is the location.
+	created(w, r, "/units", fromUnit(res))
+	return nil
+}
@@ -0,0 +1,279 @@
+package api
+
+import (
+	"time"
+
+	"codeberg.org/mahlzeit/mahlzeit/internal/app"
+	"github.com/carlmjohnson/resperr"
+)
+
+// The types in this file define the JSON representation of the API. They are separate from the types
+// of package app, so that internal changes don't break clients. Durations are given in whole seconds.
+
+type recipeListEntry struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	CoverURL string `json:"coverUrl,omitempty"`
+}
+
+func fromListEntry(e app.ListEntry) recipeListEntry {
+	res := recipeListEntry{ID: e.ID, Name: e.Name}
+	if e.Cover != nil {
+		res.CoverURL = e.Cover.Src()
+	}
+	return res
+}
+
+type recipe struct {
+	ID                  int               `json:"id"`
+	Name                string            `json:"name"`
+	Description         string            `json:"description"`
+	Language            string            `json:"language"`
+	Servings            int               `json:"servings"`
+	ServingsDescription string            `json:"servingsDescription"`
+	WorkingTimeSeconds  int64             `json:"workingTimeSeconds"`
+	WaitingTimeSeconds  int64             `json:"waitingTimeSeconds"`
+	TotalTimeSeconds    int64             `json:"totalTimeSeconds"`
+	Source              string            `json:"source"`
+	CreatedAt           time.Time         `json:"createdAt"`
+	UpdatedAt           time.Time         `json:"updatedAt"`
+	ForkedFrom          *recipeListEntry  `json:"forkedFrom"`
+	Variants            []recipeListEntry `json:"variants"`
+	Ingredients         []stepIngredient  `json:"ingredients"` // total amounts of all steps
+	Steps               []step            `json:"steps"`
+	Images              []image           `json:"images"`
+}
+
+func fromRecipe(r *app.Recipe) recipe {
+	res := recipe{
+		ID:                  r.ID,
+		Name:                r.Name,
+		Description:         r.Description,
+		Language:            r.Language,
+		Servings:            r.Servings,
+		ServingsDescription: r.ServingsDescription,
+		WorkingTimeSeconds:  seconds(r.WorkingTime),
+		WaitingTimeSeconds:  seconds(r.WaitingTime),
+		TotalTimeSeconds:    seconds(r.TotalTime()),
+		Source:              r.Source,
+		CreatedAt:           r.CreatedAt,
+		UpdatedAt:           r.UpdatedAt,
+		Variants:            convert(r.Variants, fromListEntry),
+		Ingredients:         convert(r.Ingredients, fromIngredient),
+		Steps:               convert(r.Steps, fromStep),
+		Images:              convert(r.Images, fromImage),
+	}
+	if r.ForkedFrom != nil {
+		e := fromListEntry(*r.ForkedFrom)
+		res.ForkedFrom = &e
+	}
+	return res
+}
+
+// recipeInput is the body to create or update a recipe.
+type recipeInput struct {
+	Name                string `json:"name"`
+	Description         string `json:"description"`
+	Language            string `json:"language"` // optional, defaults to the language of the instance or keeps the current one
+	Servings            int    `json:"servings"`
+	ServingsDescription string `json:"servingsDescription"`
+	WorkingTimeSeconds  int64  `json:"workingTimeSeconds"`
+	WaitingTimeSeconds  int64  `json:"waitingTimeSeconds"`
+	Source              string `json:"source"`
+}
+
+func (in recipeInput) validate() error {
+	if in.Name == "" {
+		return requiredField("name")
+	}
+	if in.Servings < 1 {
+		return resperr.WithUserMessage(nil, "servings must be at least 1")
+	}
+	if in.WorkingTimeSeconds < 0 || in.WaitingTimeSeconds < 0 {
+		return resperr.WithUserMessage(nil, "times must not be negative")
+	}
+	return nil
+}
+
+func (in recipeInput) toRecipe(id int) *app.Recipe {
+	return &app.Recipe{
+		ID:                  id,
+		Name:                in.Name,
+		Description:         in.Description,
+		Language:            in.Language,
+		Servings:            in.Servings,
+		ServingsDescription: in.ServingsDescription,
+		WorkingTime:         time.Duration(in.WorkingTimeSeconds) * time.Second,
+		WaitingTime:         time.Duration(in.WaitingTimeSeconds) * time.Second,
+		Source:              in.Source,
+	}
+}
+
+type step struct {
+	ID          int              `json:"id"`
+	RecipeID    int              `json:"recipeId"`
+	Instruction string           `json:"instruction"`
+	TimeSeconds int64            `json:"timeSeconds"`
+	Ingredients []stepIngredient `json:"ingredients"`
+	Images      []image          `json:"images"`
+}
+
+func fromStep(s app.Step) step {
+	return step{
+		ID:          s.ID,
+		RecipeID:    s.RecipeID,
+		Instruction: s.Instruction,
+		TimeSeconds: seconds(s.Time),
+		Ingredients: convert(s.Ingredients, fromIngredient),
+		Images:      convert(s.Images, fromImage),
+	}
+}
+
+// stepInput is the body to create or update a step.
+type stepInput struct {
+	Instruction string `json:"instruction"`
+	TimeSeconds int64  `json:"timeSeconds"`
+	After       int    `json:"after,omitempty"` // only for new steps: the ID of the step to insert the new one after
+}
+
+func (in stepInput) validate() error {
+	if in.Instruction == "" {
+		return requiredField("instruction")
+	}
+	if in.TimeSeconds < 0 {
+		return resperr.WithUserMessage(nil, "time must not be negative")
+	}
+	if in.After < 0 {
+		return resperr.WithUserMessage(nil, "invalid ID of the previous step")
+	}
+	return nil
+}
+
+type stepIngredient struct {
+	ID       int     `json:"id"`
+	Name     string  `json:"name"`
+	Amount   float64 `json:"amount"`
+	UnitID   *int    `json:"unitId"`
+	UnitName string  `json:"unitName"`
+	Note     string  `json:"note"`
+}
+
+func fromIngredient(i app.Ingredient) stepIngredient {
+	res := stepIngredient{
+		ID:       i.ID,
+		Name:     i.Name,
+		Amount:   i.Amount,
+		UnitName: i.UnitName,
+		Note:     i.Note,
+	}
+	if i.UnitID != 0 {
+		unitID := i.UnitID
+		res.UnitID = &unitID
+	}
+	return res
+}
+
+// stepIngredientInput is the body to add an ingredient to a step or to update it.
+type stepIngredientInput struct {
+	IngredientID int     `json:"ingredientId"` // only used when adding an ingredient
+	Amount       float64 `json:"amount"`
+	UnitID       *int    `json:"unitId"`
+	Note         string  `json:"note"`
+}
+
+func (in stepIngredientInput) validate() error {
+	if in.Amount < 0 {
+		return resperr.WithUserMessage(nil, "amount must not be negative")
+	}
+	if in.UnitID != nil && *in.UnitID <= 0 {
+		return resperr.WithUserMessage(nil, "invalid ID of the unit")
+	}
+	return nil
+}
+
+type stepOrderInput struct {
+	StepIDs []int `json:"stepIds"`
+}
+
+type ingredientOrderInput struct {
+	IngredientIDs []int `json:"ingredientIds"`
+}
+
+type moveInput struct {
+	Position int `json:"position"` // starting at 1
+}
+
+// term is an ingredient or unit, independent of any recipe.
+type term struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// nameInput is the body to create anything that only has a name.
+type nameInput struct {
+	Name string `json:"name"`
+}
+
+type household struct {
+	ID      int    `json:"id"`
+	Name    string `json:"name"`
+	Members []user `json:"members,omitempty"` // only included for single households
+}
+
+func fromHousehold(h app.Household) household {
+	return household{
+		ID:      h.ID,
+		Name:    h.Name,
+		Members: convert(h.Members, fromUser),
+	}
+}
+
+type user struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func fromUser(u app.User) user {
+	return user{ID: u.ID, Name: u.Name}
+}
+
+type logLevels struct {
+	Level  string            `json:"level"`
+	Levels map[string]string `json:"levels"` // of the named loggers
+}
+
+type logLevelInput struct {
+	Logger string `json:"logger"` // empty for the default level
+	Level  string `json:"level"`
+}
+
+type image struct {
+	ID      int    `json:"id"`
+	URL     string `json:"url"`
+	Width   int    `json:"width"`
+	Height  int    `json:"height"`
+	AltText string `json:"altText"`
+}
+
+func fromImage(i app.Image) image {
+	return image{
+		ID:      i.ID,
+		URL:     i.Src(),
+		Width:   i.Width,
+		Height:  i.Height,
+		AltText: i.AltText,
+	}
+}
+
+// convert applies fn to all items. The result is never nil, so that empty lists are encoded as [] instead of null.
+func convert[S, T any](items []S, fn func(S) T) []T {
+	res := make([]T, 0, len(items))
+	for _, i := range items {
+		res = append(res, fn(i))
+	}
+	return res
+}
+
+func seconds(d time.Duration) int64 {
+	return int64(d / time.Second)
+}
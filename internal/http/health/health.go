@@ -0,0 +1,73 @@
+// Package health provides the endpoints for the probes of container orchestrators. /healthz reports whether the
+// process is alive, and /readyz whether it's able to serve requests, i.e. whether its dependencies are available.
+package health
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	LivePath  = "/healthz"
+	ReadyPath = "/readyz"
+)
+
+// checkTimeout limits the duration of all checks, since probes usually time out after a few seconds.
+const checkTimeout = 3 * time.Second
+
+// Check returns an error if a dependency isn't available.
+type Check func(ctx context.Context) error
+
+// Handler serves the probes and passes all other requests to next. They're handled before next, so that they
+// neither require the middlewares of next, like the authentication, nor show up in its logs. Failed checks are
+// logged, but their errors aren't exposed, since they may contain internals like the address of the database.
+func Handler(next http.Handler, logger *zap.Logger, checks map[string]Check) http.Handler {
+	names := make([]string, 0, len(checks))
+	for name := range checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case LivePath:
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			_, _ = fmt.Fprintln(w, "ok")
+		case ReadyPath:
+			ready(w, r, logger, names, checks)
+		default:
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// ready runs all checks and responds with 503 Service Unavailable if any of them failed. The body lists the
+// result of each check, e.g. "database: ok".
+func ready(w http.ResponseWriter, r *http.Request, logger *zap.Logger, names []string, checks map[string]Check) {
+	ctx, cancel := context.WithTimeout(r.Context(), checkTimeout)
+	defer cancel()
+
+	status := http.StatusOK
+	results := make([]string, 0, len(names))
+	for _, name := range names {
+		result := "ok"
+		if err := checks[name](ctx); err != nil {
+			logger.Warn("readiness check failed", zap.String("check", name), zap.Error(err))
+			status = http.StatusServiceUnavailable
+			result = "failed"
+		}
+		results = append(results, name+": "+result)
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	for _, res := range results {
+		_, _ = fmt.Fprintln(w, res)
+	}
+}
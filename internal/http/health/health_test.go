@@ -0,0 +1,52 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+	"go.uber.org/zap"
+)
+
+func TestHandler(t *testing.T) {
+	t.Parallel()
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	ok := func(ctx context.Context) error { return nil }
+	failing := func(ctx context.Context) error { return errors.New("2 pending migrations") }
+
+	tests := []struct {
+		name       string
+		path       string
+		checks     map[string]Check
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "alive", path: "/healthz", checks: map[string]Check{"migrations": failing}, wantStatus: http.StatusOK, wantBody: "ok\n"},
+		{name: "ready", path: "/readyz", checks: map[string]Check{"migrations": ok, "database": ok}, wantStatus: http.StatusOK, wantBody: "database: ok\nmigrations: ok\n"},
+		{
+			name:       "not ready",
+			path:       "/readyz",
+			checks:     map[string]Check{"migrations": failing, "database": ok},
+			wantStatus: http.StatusServiceUnavailable,
+			wantBody:   "database: ok\nmigrations: failed\n",
+		},
+		{name: "other paths", path: "/recipes", wantStatus: http.StatusTeapot},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			rec := httptest.NewRecorder()
+			Handler(next, zap.NewNop(), tt.checks).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Equal(t, tt.wantBody, rec.Body.String())
+		})
+	}
+}
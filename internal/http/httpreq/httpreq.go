@@ -41,3 +41,12 @@ func MustIDParam(r *http.Request, name string) int {
 	}
 	return res
 }
+
+// IsSafeMethod reports whether method doesn't change anything, as defined by RFC 9110.
+func IsSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,137 @@
+package routes
+
+import (
+	"net/http"
+
+	"codeberg.org/mahlzeit/mahlzeit/internal/app"
+	"codeberg.org/mahlzeit/mahlzeit/internal/zaphelper"
+	"github.com/carlmjohnson/resperr"
+	"go.uber.org/zap"
+)
+
+type registerPage struct {
+	Name              string
+	Email             string
+	Error             string // key of the translated error message
+	Registered        bool
+	MinPasswordLength int
+}
+
+func (a appWrapper) getRegister(w http.ResponseWriter, r *http.Request) error {
+	return a.app.Templates.RenderPage(r.Context(), w, "account/register.tmpl", registerPage{
+		MinPasswordLength: app.MinPasswordLength,
+	})
+}
+
+func (a appWrapper) postRegister(w http.ResponseWriter, r *http.Request) error {
+	page := registerPage{
+		Name:              r.FormValue("Name"),
+		Email:             r.FormValue("Email"),
+		MinPasswordLength: app.MinPasswordLength,
+	}
+
+	pw := r.FormValue("Password")
+	if len([]rune(pw)) < app.MinPasswordLength {
+		page.Error = "account.password_too_short"
+		w.WriteHeader(http.StatusBadRequest)
+		return a.app.Templates.RenderPage(r.Context(), w, "account/register.tmpl", page)
+	}
+
+	_, err := a.app.Register(r.Context(), app.AddUserParams{Name: page.Name, Email: page.Email, Password: pw})
+	if err != nil {
+		code := resperr.StatusCode(err)
+		switch code {
+		case http.StatusConflict:
+			page.Error = "account.email_taken"
+		case http.StatusBadRequest:
+			page.Error = "account.invalid_input"
+		default:
+			return err
+		}
+
+		w.WriteHeader(code)
+		return a.app.Templates.RenderPage(r.Context(), w, "account/register.tmpl", page)
+	}
+
+	page.Registered = true
+	return a.app.Templates.RenderPage(r.Context(), w, "account/register.tmpl", page)
+}
+
+type verifyEmailPage struct {
+	Error string // key of the translated error message
+}
+
+// getVerifyEmail is opened with the link in the email of the registration. Mail clients may open links in advance
+// to check them, which activates the user as well, but that's harmless since it only happens if the email arrived.
+func (a appWrapper) getVerifyEmail(w http.ResponseWriter, r *http.Request) error {
+	var page verifyEmailPage
+	if err := a.app.VerifyEmail(r.Context(), r.URL.Query().Get("token")); err != nil {
+		if resperr.StatusCode(err) != http.StatusBadRequest {
+			return err
+		}
+		page.Error = "account.link_invalid"
+		w.WriteHeader(http.StatusBadRequest)
+	}
+	return a.app.Templates.RenderPage(r.Context(), w, "account/verify-email.tmpl", page)
+}
+
+type forgotPasswordPage struct {
+	Email string
+	Sent  bool
+}
+
+func (a appWrapper) getForgotPassword(w http.ResponseWriter, r *http.Request) error {
+	return a.app.Templates.RenderPage(r.Context(), w, "account/forgot-password.tmpl", forgotPasswordPage{})
+}
+
+func (a appWrapper) postForgotPassword(w http.ResponseWriter, r *http.Request) error {
+	page := forgotPasswordPage{Email: r.FormValue("Email"), Sent: true}
+	if err := a.app.RequestPasswordReset(r.Context(), page.Email); err != nil {
+		// The page looks the same for unknown email addresses, so errors are not shown to the user either.
+		zaphelper.FromRequest(r).Error("could not send email to reset password", zap.Error(err))
+	}
+	return a.app.Templates.RenderPage(r.Context(), w, "account/forgot-password.tmpl", page)
+}
+
+type resetPasswordPage struct {
+	Token             string
+	Error             string // key of the translated error message
+	Done              bool
+	MinPasswordLength int
+}
+
+// getResetPassword only shows the form, so that opening the link in advance doesn't use up the token.
+func (a appWrapper) getResetPassword(w http.ResponseWriter, r *http.Request) error {
+	return a.app.Templates.RenderPage(r.Context(), w, "account/reset-password.tmpl", resetPasswordPage{
+		Token:             r.URL.Query().Get("token"),
+		MinPasswordLength: app.MinPasswordLength,
+	})
+}
+
+func (a appWrapper) postResetPassword(w http.ResponseWriter, r *http.Request) error {
+	page := resetPasswordPage{
+		Token:             r.FormValue("Token"),
+		MinPasswordLength: app.MinPasswordLength,
+	}
+
+	pw := r.FormValue("Password")
+	if len([]rune(pw)) < app.MinPasswordLength {
+		page.Error = "account.password_too_short"
+		w.WriteHeader(http.StatusBadRequest)
+		return a.app.Templates.RenderPage(r.Context(), w, "account/reset-password.tmpl", page)
+	}
+
+	if err := a.app.ResetPassword(r.Context(), page.Token, pw); err != nil {
+		if resperr.StatusCode(err) != http.StatusBadRequest {
+			return err
+		}
+		page.Error = "account.link_invalid"
+		w.WriteHeader(http.StatusBadRequest)
+		return a.app.Templates.RenderPage(r.Context(), w, "account/reset-password.tmpl", page)
+	}
+
+	// All sessions were ended, including the current one.
+	clearSessionCookie(w, r)
+	page.Done = true
+	return a.app.Templates.RenderPage(r.Context(), w, "account/reset-password.tmpl", page)
+}
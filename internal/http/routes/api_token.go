@@ -0,0 +1,74 @@
+package routes
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"codeberg.org/mahlzeit/mahlzeit/internal/app"
+	"codeberg.org/mahlzeit/mahlzeit/internal/http/httpreq"
+	"github.com/carlmjohnson/resperr"
+)
+
+// tokenExpiryDays are the choices for the validity of a new API token. Zero means that it never expires.
+var tokenExpiryDays = []int{30, 90, 365, 0}
+
+type apiTokensPage struct {
+	Tokens     []app.APIToken
+	Scopes     []app.TokenScope
+	ExpiryDays []int
+
+	// only set after a token was created, since it can't be shown later
+	NewToken string
+}
+
+func (a appWrapper) getAPITokens(w http.ResponseWriter, r *http.Request) error {
+	return a.renderAPITokens(w, r, "")
+}
+
+func (a appWrapper) postAPIToken(w http.ResponseWriter, r *http.Request) error {
+	params := app.CreateAPITokenParams{
+		Name:  r.FormValue("Name"),
+		Scope: app.TokenScope(r.FormValue("Scope")),
+	}
+
+	days, err := strconv.Atoi(r.FormValue("ExpiresInDays"))
+	if err != nil || days < 0 {
+		return resperr.WithUserMessage(err, "invalid expiry provided")
+	}
+	if days > 0 {
+		params.ExpiresAt = time.Now().AddDate(0, 0, days)
+	}
+
+	token, _, err := a.app.CreateAPIToken(r.Context(), params)
+	if err != nil {
+		return err
+	}
+
+	// The page is rendered directly instead of redirecting, so that the token can be shown once.
+	w.Header().Set("Cache-Control", "no-store")
+	return a.renderAPITokens(w, r, token)
+}
+
+func (a appWrapper) postDeleteAPIToken(w http.ResponseWriter, r *http.Request) error {
+	if err := a.app.DeleteAPIToken(r.Context(), httpreq.MustIDParam(r, "tokenID")); err != nil {
+		return err
+	}
+
+	http.Redirect(w, r, "/settings/tokens", http.StatusSeeOther)
+	return nil
+}
+
+func (a appWrapper) renderAPITokens(w http.ResponseWriter, r *http.Request, newToken string) error {
+	tokens, err := a.app.GetAPITokens(r.Context())
+	if err != nil {
+		return err
+	}
+
+	return a.app.Templates.RenderPage(r.Context(), w, "settings/tokens.tmpl", apiTokensPage{
+		Tokens:     tokens,
+		Scopes:     app.APITokenScopes,
+		ExpiryDays: tokenExpiryDays,
+		NewToken:   newToken,
+	})
+}
@@ -0,0 +1,159 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"codeberg.org/mahlzeit/mahlzeit/internal/app"
+	"codeberg.org/mahlzeit/mahlzeit/internal/templates"
+	"codeberg.org/mahlzeit/mahlzeit/internal/zaphelper"
+	"github.com/carlmjohnson/resperr"
+	"go.uber.org/zap"
+)
+
+// sessionCookie contains the token of the session of a logged-in user.
+const sessionCookie = "session"
+
+// authenticate looks up the user of the session cookie and stores it in the request context, see [app.WithUserID].
+// Requests without a valid session are passed on without a user. Other ways to authenticate,
+// like the API tokens of package api, are handled by their routes.
+func (a appWrapper) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := r.Cookie(sessionCookie)
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		user, err := a.app.GetSessionUser(r.Context(), c.Value)
+		if err != nil {
+			if resperr.StatusCode(err) == http.StatusUnauthorized {
+				// The session is expired, so the cookie is of no use anymore.
+				clearSessionCookie(w, r)
+			} else {
+				zaphelper.FromRequest(r).Warn("could not get user of session", zap.Error(err))
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx := app.WithUserID(r.Context(), user.ID)
+		ctx = templates.WithUserName(ctx, user.Name)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// requireLogin redirects visitors to the login page. After logging in, they are sent back to the requested page.
+func requireLogin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if app.UserIDFromContext(r.Context()) == 0 {
+			http.Redirect(w, r, "/login?next="+url.QueryEscape(r.URL.RequestURI()), http.StatusSeeOther)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+type loginPage struct {
+	Email string
+	Next  string
+	Error string // key of the translated error message
+	OIDC  bool   // whether the login with an identity provider is offered
+}
+
+func (a appWrapper) getLogin(w http.ResponseWriter, r *http.Request) error {
+	return a.app.Templates.RenderPage(r.Context(), w, "login.tmpl", loginPage{
+		Next: localPath(r.URL.Query().Get("next")),
+		OIDC: a.app.OIDC != nil,
+	})
+}
+
+func (a appWrapper) postLogin(w http.ResponseWriter, r *http.Request) error {
+	page := loginPage{
+		Email: r.FormValue("Email"),
+		Next:  localPath(r.FormValue("Next")),
+		OIDC:  a.app.OIDC != nil,
+	}
+
+	user, err := a.app.Authenticate(r.Context(), page.Email, r.FormValue("Password"))
+	if err != nil {
+		code := resperr.StatusCode(err)
+		switch code {
+		case http.StatusUnauthorized:
+			page.Error = "auth.invalid"
+		case http.StatusForbidden:
+			page.Error = "auth.not_activated"
+		default:
+			return err
+		}
+
+		zaphelper.FromRequest(r).Info("login failed", zap.Error(err))
+		w.WriteHeader(code)
+		return a.app.Templates.RenderPage(r.Context(), w, "login.tmpl", page)
+	}
+
+	return a.startSession(w, r, user.ID, page.Next)
+}
+
+// startSession logs in the user by setting the session cookie, and redirects to the local path next.
+func (a appWrapper) startSession(w http.ResponseWriter, r *http.Request, userID int, next string) error {
+	token, expires, err := a.app.CreateSession(r.Context(), userID)
+	if err != nil {
+		return err
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    token,
+		Path:     "/",
+		Expires:  expires,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, next, http.StatusSeeOther)
+	return nil
+}
+
+func (a appWrapper) postLogout(w http.ResponseWriter, r *http.Request) error {
+	c, err := r.Cookie(sessionCookie)
+	if err != nil && !errors.Is(err, http.ErrNoCookie) {
+		return err
+	}
+	if c != nil {
+		if err := a.app.DeleteSession(r.Context(), c.Value); err != nil {
+			return err
+		}
+	}
+
+	clearSessionCookie(w, r)
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+	return nil
+}
+
+func clearSessionCookie(w http.ResponseWriter, r *http.Request) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Path:     "/",
+		Expires:  time.Unix(0, 0),
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// localPath returns p if it's a path on this host. Otherwise, the root path is returned,
+// so that no open redirect is possible.
+func localPath(p string) string {
+	u, err := url.Parse(p)
+	// A path starting with two slashes would be interpreted by browsers as a different host.
+	if err != nil || u.Scheme != "" || u.Host != "" || !strings.HasPrefix(u.Path, "/") ||
+		strings.HasPrefix(p, "//") || strings.HasPrefix(p, `/\`) {
+		return "/"
+	}
+	return u.RequestURI()
+}
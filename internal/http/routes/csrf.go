@@ -0,0 +1,129 @@
+package routes
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"mime"
+	"net/http"
+	"strings"
+
+	"codeberg.org/mahlzeit/mahlzeit/internal/app"
+	"codeberg.org/mahlzeit/mahlzeit/internal/http/httpreq"
+	"codeberg.org/mahlzeit/mahlzeit/internal/templates"
+	"github.com/carlmjohnson/resperr"
+)
+
+const (
+	// csrfCookie contains the secret of the browser, which the tokens in forms are derived from.
+	csrfCookie = "csrf"
+	// csrfField is the name of the hidden form field with the token, see the partial "csrf".
+	csrfField = "csrf_token"
+	// csrfHeader carries the token of HTMX requests. It's set with hx-headers in the base template.
+	csrfHeader = "X-CSRF-Token"
+
+	csrfSecretLength = 32
+)
+
+// protectCSRF rejects state-changing requests without a valid CSRF token. The token is bound to a random
+// secret in a cookie, which other sites can neither read nor set, so they can't forge it.
+//
+// Requests with a bearer token are exempt, because browsers never add it on their own. API requests that
+// are authenticated with the session cookie have to send the token, too.
+func protectCSRF(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		secret := csrfSecret(r)
+		if secret == nil {
+			secret = make([]byte, csrfSecretLength)
+			if _, err := rand.Read(secret); err != nil {
+				app.HandleError(w, r, err)
+				return
+			}
+			http.SetCookie(w, &http.Cookie{
+				Name:     csrfCookie,
+				Value:    base64.RawURLEncoding.EncodeToString(secret),
+				Path:     "/",
+				HttpOnly: true,
+				Secure:   r.TLS != nil,
+				SameSite: http.SameSiteLaxMode,
+			})
+		}
+
+		if !httpreq.IsSafeMethod(r.Method) && !hasBearerToken(r) {
+			token, err := csrfToken(w, r)
+			if err != nil {
+				app.HandleError(w, r, err)
+				return
+			}
+			if !validCSRFToken(token, secret) {
+				app.HandleError(w, r, resperr.WithCodeAndMessage(nil, http.StatusForbidden,
+					"the form has expired or was sent from another website, please reload the page and try again"))
+				return
+			}
+		}
+
+		ctx := templates.WithCSRFToken(r.Context(), maskCSRFSecret(secret))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// csrfSecret returns the secret of the cookie, or nil if there is no valid one.
+func csrfSecret(r *http.Request) []byte {
+	c, err := r.Cookie(csrfCookie)
+	if err != nil {
+		return nil
+	}
+	secret, err := base64.RawURLEncoding.DecodeString(c.Value)
+	if err != nil || len(secret) != csrfSecretLength {
+		return nil
+	}
+	return secret
+}
+
+// csrfToken returns the token of the request, either from the header or the form. Multipart forms are
+// parsed like uploads, so that their size is limited before the handler is reached.
+func csrfToken(w http.ResponseWriter, r *http.Request) (string, error) {
+	if token := r.Header.Get(csrfHeader); token != "" {
+		return token, nil
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		if err := parseUploadForm(w, r); err != nil {
+			return "", err
+		}
+	}
+	return r.PostFormValue(csrfField), nil
+}
+
+// maskCSRFSecret returns a new token for the secret. Each token is masked with a random pad, so the tokens in
+// responses differ and the secret can't be guessed from compressed responses, see the BREACH attack.
+func maskCSRFSecret(secret []byte) string {
+	token := make([]byte, 2*len(secret))
+	pad := token[:len(secret)]
+	_, _ = rand.Read(pad) // errors are ignored, because the secret could only be created without them
+
+	for i := range secret {
+		token[len(secret)+i] = pad[i] ^ secret[i]
+	}
+	return base64.RawURLEncoding.EncodeToString(token)
+}
+
+func validCSRFToken(token string, secret []byte) bool {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(b) != 2*len(secret) {
+		return false
+	}
+
+	pad, masked := b[:len(secret)], b[len(secret):]
+	unmasked := make([]byte, len(secret))
+	for i := range secret {
+		unmasked[i] = pad[i] ^ masked[i]
+	}
+	return subtle.ConstantTimeCompare(unmasked, secret) == 1
+}
+
+func hasBearerToken(r *http.Request) bool {
+	scheme, _, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+	return strings.EqualFold(scheme, "Bearer")
+}
@@ -0,0 +1,106 @@
+package routes
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+)
+
+func TestProtectCSRF(t *testing.T) {
+	t.Parallel()
+
+	handler := protectCSRF(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	// A first request without cookie issues the secret, which the tokens of the following requests are derived from.
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	cookies := rec.Result().Cookies()
+	assert.Equal(t, 1, len(cookies))
+	cookie := cookies[0]
+	assert.Equal(t, csrfCookie, cookie.Name)
+	assert.True(t, cookie.HttpOnly)
+
+	secret := csrfSecret(&http.Request{Header: http.Header{"Cookie": {cookie.String()}}})
+	assert.NotZero(t, secret)
+	token := maskCSRFSecret(secret)
+	assert.NotEqual(t, token, maskCSRFSecret(secret))
+
+	otherSecret := bytes.Repeat([]byte{1}, csrfSecretLength)
+
+	multipartBody := func(token string) (string, string) {
+		var buf bytes.Buffer
+		mw := multipart.NewWriter(&buf)
+		assert.NoError(t, mw.WriteField(csrfField, token))
+		assert.NoError(t, mw.Close())
+		return buf.String(), mw.FormDataContentType()
+	}
+	validMultipart, validMultipartType := multipartBody(token)
+
+	tests := []struct {
+		name        string
+		method      string
+		header      http.Header
+		body        string
+		withCookie  bool
+		wantStatus  int
+		wantMessage string
+	}{
+		{name: "safe method", method: http.MethodGet, wantStatus: http.StatusNoContent},
+		{name: "without token", method: http.MethodPost, withCookie: true, wantStatus: http.StatusForbidden, wantMessage: "reload the page"},
+		{
+			name:       "token in form",
+			method:     http.MethodPost,
+			header:     http.Header{"Content-Type": {"application/x-www-form-urlencoded"}},
+			body:       url.Values{csrfField: {token}}.Encode(),
+			withCookie: true,
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name:       "token in multipart form",
+			method:     http.MethodPost,
+			header:     http.Header{"Content-Type": {validMultipartType}},
+			body:       validMultipart,
+			withCookie: true,
+			wantStatus: http.StatusNoContent,
+		},
+		{name: "token in header", method: http.MethodDelete, header: http.Header{csrfHeader: {token}}, withCookie: true, wantStatus: http.StatusNoContent},
+		{name: "token without cookie", method: http.MethodPut, header: http.Header{csrfHeader: {token}}, wantStatus: http.StatusForbidden},
+		{
+			name:       "token of another secret",
+			method:     http.MethodPut,
+			header:     http.Header{csrfHeader: {maskCSRFSecret(otherSecret)}},
+			withCookie: true,
+			wantStatus: http.StatusForbidden,
+		},
+		{name: "invalid token", method: http.MethodPut, header: http.Header{csrfHeader: {"invalid"}}, withCookie: true, wantStatus: http.StatusForbidden},
+		{name: "bearer token", method: http.MethodPost, header: http.Header{"Authorization": {"Bearer mzt_abc"}}, wantStatus: http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(tt.method, "/", strings.NewReader(tt.body))
+			for k, v := range tt.header {
+				req.Header.Set(k, v[0])
+			}
+			if tt.withCookie {
+				req.AddCookie(cookie)
+			}
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Contains(t, rec.Body.String(), tt.wantMessage)
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package routes
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"codeberg.org/mahlzeit/mahlzeit/internal/app"
+	"codeberg.org/mahlzeit/mahlzeit/internal/http/htmx"
+	"github.com/carlmjohnson/resperr"
+)
+
+// editPage is the data of the template "recipes/edit.tmpl".
+//
+// With HTMX, single parts of the editor like the form of a step are swapped into the page. Without JavaScript,
+// the routes of these parts redirect to the edit page instead, which then renders the same parts into the full
+// page. Which parts are shown is kept in the query parameters of the page, see [editorURL].
+type editPage struct {
+	*app.Recipe
+	formErrors           // of the recipe form
+	EditStep   *stepForm // optional, the step that is shown as form, with the ID 0 for a new step
+}
+
+// stepForm is the data of the template "single_step_edit".
+type stepForm struct {
+	app.Step
+	formErrors
+	EditIngredient *ingredientForm    // optional, the ingredient of the step that is shown as form
+	NewIngredient  *newIngredientForm // optional, the form to add an ingredient to the step
+}
+
+// ingredientForm is the data of the template "ingredient_edit".
+type ingredientForm struct {
+	app.Ingredient
+	formErrors
+	Units []app.Unit
+}
+
+// newIngredientForm is the data of the template "new_ingredient".
+type newIngredientForm struct {
+	formErrors
+	Ingredients []app.Ingredient
+	Units       []app.Unit
+	RecipeID    int
+	StepID      int
+}
+
+// formErrors are the errors of a failed submission of a form, which are shown within the form, see
+// [appWrapper.renderInvalidForm].
+type formErrors struct {
+	Error  string          // message that doesn't belong to a single field
+	Errors app.FieldErrors // translation keys of the messages of invalid fields
+	Input  url.Values      // the submitted values, to show those that couldn't be parsed
+}
+
+// Submitted returns the submitted value of the form field with the name.
+func (f formErrors) Submitted(name string) string {
+	return f.Input.Get(name)
+}
+
+// The query parameters of the edit page that describe the state of the editor.
+const (
+	editStepParam       = "step"           // ID of the step that is shown as form, 0 for a new step
+	insertAfterParam    = "after"          // ID of the step after which the new step is inserted
+	editIngredientParam = "ingredient"     // ID of the ingredient of the step that is shown as form
+	addIngredientParam  = "add_ingredient" // if set, the form to add an ingredient to the step is shown
+)
+
+// editStepState returns the state of the editor in which the step is shown as form.
+func editStepState(stepID int) url.Values {
+	return url.Values{editStepParam: {strconv.Itoa(stepID)}}
+}
+
+// editorURL returns the URL of the edit page of the recipe with the state of the editor.
+// If stepID is set, the page scrolls to the step.
+func editorURL(recipeID int, state url.Values, stepID int) string {
+	u := "/recipes/" + strconv.Itoa(recipeID) + "/edit"
+	if len(state) > 0 {
+		u += "?" + state.Encode()
+	}
+	if stepID != 0 {
+		u += "#step-" + strconv.Itoa(stepID)
+	}
+	return u
+}
+
+// redirectToEditor is the response of the routes of the editor for requests without HTMX.
+func redirectToEditor(w http.ResponseWriter, r *http.Request, recipeID int, state url.Values, stepID int) {
+	http.Redirect(w, r, editorURL(recipeID, state, stepID), http.StatusFound)
+}
+
+// loadEditPage returns the data of the edit page of the recipe in the given state of the editor.
+func (a appWrapper) loadEditPage(ctx context.Context, recipeID int, state url.Values) (editPage, error) {
+	recipe, err := a.app.GetSingleRecipe(ctx, recipeID)
+	if err != nil {
+		return editPage{}, err
+	}
+
+	page := editPage{Recipe: recipe}
+	if !state.Has(editStepParam) {
+		return page, nil
+	}
+
+	form := &stepForm{Step: app.Step{RecipeID: recipeID, InsertAfter: parseIntWithDefault(state.Get(insertAfterParam))}}
+	if stepID := parseIntWithDefault(state.Get(editStepParam)); stepID != 0 {
+		found := false
+		for _, s := range recipe.Steps {
+			if s.ID == stepID {
+				form.Step, found = s, true
+			}
+		}
+		if !found {
+			return editPage{}, resperr.New(http.StatusNotFound, "step %d is not part of recipe %d", stepID, recipeID)
+		}
+
+		if ingredientID := parseIntWithDefault(state.Get(editIngredientParam)); ingredientID != 0 {
+			if form.EditIngredient, err = a.ingredientForm(ctx, form.Step, ingredientID); err != nil {
+				return editPage{}, err
+			}
+		}
+		if state.Has(addIngredientParam) {
+			if form.NewIngredient, err = a.newIngredientForm(ctx, recipeID, stepID); err != nil {
+				return editPage{}, err
+			}
+		}
+	}
+
+	page.EditStep = form
+	return page, nil
+}
+
+// ingredientForm returns the form of an ingredient of the step.
+func (a appWrapper) ingredientForm(ctx context.Context, step app.Step, ingredientID int) (*ingredientForm, error) {
+	units, err := a.app.GetAllUnits(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, i := range step.Ingredients {
+		if i.ID == ingredientID {
+			return &ingredientForm{Ingredient: i, Units: units}, nil
+		}
+	}
+	return nil, resperr.New(http.StatusNotFound, "ingredient %d is not part of step %d", ingredientID, step.ID)
+}
+
+// newIngredientForm returns the form to add an ingredient to the step.
+func (a appWrapper) newIngredientForm(ctx context.Context, recipeID, stepID int) (*newIngredientForm, error) {
+	ingredients, err := a.app.GetAllIngredients(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	units, err := a.app.GetAllUnits(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &newIngredientForm{Ingredients: ingredients, Units: units, RecipeID: recipeID, StepID: stepID}, nil
+}
+
+// renderInvalidForm renders a form of the edit page again after its submission failed with err, so that the
+// submitted values can be corrected. The messages of a [app.ValidationError] are shown next to their fields,
+// other errors above the form. HTMX requests only get the template of the form with its data, others the full
+// page. Errors of the server are returned as they are.
+func (a appWrapper) renderInvalidForm(w http.ResponseWriter, r *http.Request, page *editPage, form *formErrors, name string, data any, err error) error {
+	code := resperr.StatusCode(err)
+	if code >= http.StatusInternalServerError {
+		return err
+	}
+
+	var verr *app.ValidationError
+	if errors.As(err, &verr) {
+		form.Errors = verr.Fields
+	} else {
+		form.Error = resperr.UserMessage(err)
+	}
+	form.Input = r.PostForm
+
+	if !htmx.IsHTMXRequest(r) {
+		w.WriteHeader(code)
+		return a.app.Templates.RenderPage(r.Context(), w, "recipes/edit.tmpl", page)
+	}
+
+	// HTMX doesn't swap the responses of errors, except for this status, see app.js.
+	w.WriteHeader(http.StatusUnprocessableEntity)
+	return a.app.Templates.RenderTemplate(r.Context(), w, "recipes/edit.tmpl", name, data)
+}
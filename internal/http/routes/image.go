@@ -0,0 +1,129 @@
+package routes
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+
+	"codeberg.org/mahlzeit/mahlzeit/internal/app"
+	"codeberg.org/mahlzeit/mahlzeit/internal/blob"
+	"codeberg.org/mahlzeit/mahlzeit/internal/http/htmx"
+	"codeberg.org/mahlzeit/mahlzeit/internal/http/httpreq"
+	"github.com/carlmjohnson/resperr"
+	"github.com/go-chi/chi/v5"
+)
+
+// maxUploadSize is the maximum size of a single image upload.
+const maxUploadSize = 20 << 20
+
+func (a appWrapper) getImage(w http.ResponseWriter, r *http.Request) error {
+	key := chi.URLParam(r, "*")
+
+	content, err := a.app.Blobs.Get(r.Context(), key)
+	if err != nil {
+		// Keys are part of the URL, so invalid ones are just another kind of image that doesn't exist.
+		if errors.Is(err, blob.ErrNotFound) || errors.Is(err, blob.ErrInvalidKey) {
+			return resperr.NotFound(r)
+		}
+		return err
+	}
+	defer content.Close()
+
+	w.Header().Set("Content-Type", mime.TypeByExtension(path.Ext(key)))
+	// The keys of all images contain a random UUID, so the content behind a URL never changes.
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	_, _ = io.Copy(w, content)
+	return nil
+}
+
+func (a appWrapper) postRecipeImage(w http.ResponseWriter, r *http.Request) error {
+	recipeID := httpreq.MustIDParam(r, "id")
+	stepID, _ := httpreq.IDParam(r, "stepID") // optional, only set for step images
+
+	img, err := func() (app.Image, error) {
+		if err := parseUploadForm(w, r); err != nil {
+			return app.Image{}, err
+		}
+
+		file, _, err := r.FormFile("Image")
+		if err != nil {
+			return app.Image{}, resperr.WithCodeAndMessage(err, http.StatusBadRequest, "no image provided")
+		}
+		defer file.Close()
+
+		return a.app.AddImage(r.Context(), app.AddImageParams{
+			RecipeID: recipeID,
+			StepID:   stepID,
+			AltText:  r.PostFormValue("AltText"),
+			Data:     file,
+		})
+	}()
+	if err != nil {
+		if htmx.IsHTMXRequest(r) {
+			return err
+		}
+
+		// Images of a step are uploaded within the form of the step, which is shown again with the error.
+		var state url.Values
+		if stepID != 0 {
+			state = editStepState(stepID)
+		}
+		page, loadErr := a.loadEditPage(r.Context(), recipeID, state)
+		if loadErr != nil {
+			return loadErr
+		}
+		form := &page.formErrors
+		if page.EditStep != nil {
+			form = &page.EditStep.formErrors
+		}
+		return a.renderInvalidForm(w, r, &page, form, "", nil, err)
+	}
+
+	if htmx.IsHTMXRequest(r) {
+		if err := a.app.Templates.RenderTemplate(r.Context(), w, "recipes/edit.tmpl", "image", img); err != nil {
+			return err
+		}
+	} else {
+		redirectToEditor(w, r, recipeID, nil, stepID)
+	}
+	return nil
+}
+
+// parseUploadForm parses a multipart form with files up to maxUploadSize. Parsing it again is a no-op.
+func parseUploadForm(w http.ResponseWriter, r *http.Request) error {
+	if r.MultipartForm != nil {
+		return nil
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxUploadSize)
+	if err := r.ParseMultipartForm(maxUploadSize); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return resperr.WithCodeAndMessage(err, http.StatusRequestEntityTooLarge, "image file is too large")
+		}
+		return resperr.WithCodeAndMessage(err, http.StatusBadRequest, "invalid upload")
+	}
+	return nil
+}
+
+func (a appWrapper) deleteRecipeImage(w http.ResponseWriter, r *http.Request) error {
+	recipeID := httpreq.MustIDParam(r, "id")
+	imageID, err := httpreq.StrictIDParam(r, "imageID")
+	if err != nil {
+		return err
+	}
+
+	if err := a.app.DeleteImage(r.Context(), recipeID, imageID); err != nil {
+		return err
+	}
+
+	if htmx.IsHTMXRequest(r) {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		redirectToEditor(w, r, recipeID, nil, 0)
+	}
+	return nil
+}
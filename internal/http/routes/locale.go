@@ -0,0 +1,81 @@
+package routes
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"codeberg.org/mahlzeit/mahlzeit/internal/app"
+	"codeberg.org/mahlzeit/mahlzeit/internal/i18n"
+	"codeberg.org/mahlzeit/mahlzeit/internal/zaphelper"
+	"github.com/carlmjohnson/resperr"
+	"go.uber.org/zap"
+)
+
+// localeCookie stores the locale that was chosen by a visitor without an account.
+const localeCookie = "locale"
+
+// localize negotiates the locale of the request and stores the matching [i18n.Localizer] in the
+// request context. The preference of the user takes precedence, followed by the locale cookie and
+// the Accept-Language header.
+func (a appWrapper) localize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var preferences []string
+		if userID := app.UserIDFromContext(r.Context()); userID != 0 {
+			locale, err := a.app.GetUserLocale(r.Context(), userID)
+			if err != nil {
+				zaphelper.FromContext(r.Context()).Warn("could not get locale of user", zap.Int("user_id", userID), zap.Error(err))
+			}
+			preferences = append(preferences, locale)
+		}
+		if c, err := r.Cookie(localeCookie); err == nil {
+			preferences = append(preferences, c.Value)
+		}
+		preferences = append(preferences, r.Header.Get("Accept-Language"))
+
+		locale := i18n.Negotiate(preferences...)
+		w.Header().Set("Content-Language", locale)
+		w.Header().Add("Vary", "Accept-Language")
+
+		next.ServeHTTP(w, r.WithContext(i18n.WithLocalizer(r.Context(), i18n.New(locale))))
+	})
+}
+
+// postLocale changes the locale of the user interface and redirects back to the previous page.
+func (a appWrapper) postLocale(w http.ResponseWriter, r *http.Request) error {
+	locale := r.FormValue("Locale")
+	if !i18n.IsSupported(locale) {
+		return resperr.WithUserMessagef(nil, "unsupported locale %q", locale)
+	}
+
+	if userID := app.UserIDFromContext(r.Context()); userID != 0 {
+		if err := a.app.SetUserLocale(r.Context(), userID, locale); err != nil {
+			return err
+		}
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     localeCookie,
+		Value:    locale,
+		Path:     "/",
+		MaxAge:   int((365 * 24 * time.Hour).Seconds()),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, sameHostReferer(r), http.StatusSeeOther)
+	return nil
+}
+
+// sameHostReferer returns the path of the Referer header, if it points to the same host.
+// Otherwise, the root path is returned, so that no open redirect is possible.
+func sameHostReferer(r *http.Request) string {
+	ref, err := url.Parse(r.Referer())
+	// A path starting with two slashes would be interpreted by browsers as a different host.
+	if err != nil || ref.Host != r.Host || !strings.HasPrefix(ref.Path, "/") || strings.HasPrefix(ref.Path, "//") {
+		return "/"
+	}
+
+	res := url.URL{Path: ref.Path, RawQuery: ref.RawQuery}
+	return res.String()
+}
@@ -0,0 +1,127 @@
+package routes
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"codeberg.org/mahlzeit/mahlzeit/internal/oidc"
+	"codeberg.org/mahlzeit/mahlzeit/internal/zaphelper"
+	"github.com/carlmjohnson/resperr"
+	"go.uber.org/zap"
+)
+
+// oidcCookie keeps the [oidc.AuthRequest] while the user logs in at the identity provider.
+const oidcCookie = "oidc_login"
+
+// oidcLoginTimeout is the time the user has for the login at the identity provider.
+const oidcLoginTimeout = 10 * time.Minute
+
+type oidcLogin struct {
+	oidc.AuthRequest
+	Next string
+}
+
+// getOIDCLogin redirects to the identity provider. It redirects back to getOIDCCallback afterwards.
+func (a appWrapper) getOIDCLogin(w http.ResponseWriter, r *http.Request) error {
+	req, err := oidc.NewAuthRequest()
+	if err != nil {
+		return err
+	}
+
+	value, err := json.Marshal(oidcLogin{AuthRequest: req, Next: localPath(r.URL.Query().Get("next"))})
+	if err != nil {
+		return err
+	}
+	// The cookie must be sent with the redirect of the identity provider, so SameSite must be Lax.
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcCookie,
+		Value:    base64.RawURLEncoding.EncodeToString(value),
+		Path:     "/login/oidc",
+		MaxAge:   int(oidcLoginTimeout.Seconds()),
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	http.Redirect(w, r, a.app.OIDC.AuthCodeURL(req), http.StatusSeeOther)
+	return nil
+}
+
+func (a appWrapper) getOIDCCallback(w http.ResponseWriter, r *http.Request) error {
+	login, err := readOIDCCookie(r)
+	if err != nil {
+		return err
+	}
+	// Each login can only be finished once.
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcCookie,
+		Path:     "/login/oidc",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	query := r.URL.Query()
+	if subtle.ConstantTimeCompare([]byte(query.Get("state")), []byte(login.State)) != 1 {
+		return resperr.WithUserMessage(nil, "the login is invalid, please try again")
+	}
+
+	page := loginPage{Next: login.Next, OIDC: true}
+	if e := query.Get("error"); e != "" {
+		// E.g. the user denied the access at the identity provider.
+		zaphelper.FromRequest(r).Info("identity provider returned an error",
+			zap.String("error", e), zap.String("description", query.Get("error_description")))
+		page.Error = "auth.oidc_failed"
+		w.WriteHeader(http.StatusUnauthorized)
+		return a.app.Templates.RenderPage(r.Context(), w, "login.tmpl", page)
+	}
+
+	identity, err := a.app.OIDC.Exchange(r.Context(), login.AuthRequest, query.Get("code"))
+	if errors.Is(err, oidc.ErrLoginFailed) {
+		zaphelper.FromRequest(r).Warn("login with identity provider failed", zap.Error(err))
+		page.Error = "auth.oidc_failed"
+		w.WriteHeader(http.StatusUnauthorized)
+		return a.app.Templates.RenderPage(r.Context(), w, "login.tmpl", page)
+	}
+	if err != nil {
+		return err
+	}
+
+	user, err := a.app.LoginWithIdentity(r.Context(), identity)
+	if resperr.StatusCode(err) == http.StatusForbidden {
+		zaphelper.FromRequest(r).Info("login with identity provider denied", zap.Error(err))
+		page.Error = "auth.oidc_denied"
+		w.WriteHeader(http.StatusForbidden)
+		return a.app.Templates.RenderPage(r.Context(), w, "login.tmpl", page)
+	}
+	if err != nil {
+		return err
+	}
+
+	return a.startSession(w, r, user.ID, login.Next)
+}
+
+func readOIDCCookie(r *http.Request) (oidcLogin, error) {
+	invalid := resperr.WithUserMessage(nil, "the login has expired, please try again")
+
+	c, err := r.Cookie(oidcCookie)
+	if err != nil {
+		return oidcLogin{}, invalid
+	}
+	value, err := base64.RawURLEncoding.DecodeString(c.Value)
+	if err != nil {
+		return oidcLogin{}, invalid
+	}
+
+	var login oidcLogin
+	if err := json.Unmarshal(value, &login); err != nil || login.State == "" {
+		return oidcLogin{}, invalid
+	}
+	login.Next = localPath(login.Next)
+	return login, nil
+}
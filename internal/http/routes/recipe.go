@@ -4,12 +4,13 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
-	"time"
 
 	"codeberg.org/mahlzeit/mahlzeit/internal/app"
+	"codeberg.org/mahlzeit/mahlzeit/internal/duration"
 	"codeberg.org/mahlzeit/mahlzeit/internal/http/htmx"
 	"codeberg.org/mahlzeit/mahlzeit/internal/http/httpreq"
-	"github.com/go-chi/chi/v5"
+	"codeberg.org/mahlzeit/mahlzeit/internal/i18n"
+	"github.com/carlmjohnson/resperr"
 	"github.com/robfig/bind"
 )
 
@@ -18,7 +19,10 @@ func (a appWrapper) getAllRecipes(w http.ResponseWriter, r *http.Request) error
 	if err != nil {
 		return err
 	}
-	if err := a.app.Templates.RenderPage(w, "recipes/index.tmpl", recipes); err != nil {
+	if err := a.app.TranslateRecipeNames(r.Context(), recipes, i18n.FromContext(r.Context()).Locale()); err != nil {
+		return err
+	}
+	if err := a.app.Templates.RenderPage(r.Context(), w, "recipes/index.tmpl", recipes); err != nil {
 		return err
 	}
 	return nil
@@ -37,21 +41,23 @@ func (a appWrapper) getSingleRecipe(w http.ResponseWriter, r *http.Request) erro
 		res.WithServings(p)
 	}
 
-	if err := a.app.Templates.RenderPage(w, "recipes/single.tmpl", res); err != nil {
+	if err := a.app.TranslateRecipe(r.Context(), res, i18n.FromContext(r.Context()).Locale()); err != nil {
+		return err
+	}
+
+	if err := a.app.Templates.RenderPage(r.Context(), w, "recipes/single.tmpl", res); err != nil {
 		return err
 	}
 	return nil
 }
 
 func (a appWrapper) getEditSingleRecipe(w http.ResponseWriter, r *http.Request) error {
-	id := httpreq.MustIDParam(r, "id")
-
-	res, err := a.app.GetSingleRecipe(r.Context(), id)
+	page, err := a.loadEditPage(r.Context(), httpreq.MustIDParam(r, "id"), r.URL.Query())
 	if err != nil {
 		return err
 	}
 
-	if err := a.app.Templates.RenderPage(w, "recipes/edit.tmpl", res); err != nil {
+	if err := a.app.Templates.RenderPage(r.Context(), w, "recipes/edit.tmpl", page); err != nil {
 		return err
 	}
 	return nil
@@ -68,33 +74,83 @@ func (a appWrapper) postEditSingleRecipe(w http.ResponseWriter, r *http.Request)
 		Servings            int
 		ServingsDescription string
 		Description         string
+		WorkingTime         string
+		WaitingTime         string
+		Source              string
+		Language            string
 	}{}
 	if err := bind.Request(r).All(&data); err != nil {
 		return err
 	}
 
-	if err := a.app.UpdateRecipe(r.Context(), &app.Recipe{
+	recipe := &app.Recipe{
 		ID:                  id,
 		Name:                data.Name,
 		Description:         data.Description,
+		Source:              data.Source,
 		BaseServings:        data.Servings,
 		Servings:            data.Servings,
 		ServingsDescription: data.ServingsDescription,
-	}); err != nil {
-		return err
+		Language:            data.Language,
+	}
+	err := func() error {
+		invalid := app.FieldErrors{}
+		var err error
+		if recipe.WorkingTime, err = duration.Parse(data.WorkingTime); err != nil {
+			invalid["WorkingTime"] = "validation.invalid_duration"
+		}
+		if recipe.WaitingTime, err = duration.Parse(data.WaitingTime); err != nil {
+			invalid["WaitingTime"] = "validation.invalid_duration"
+		}
+		if err := invalid.Err(); err != nil {
+			return err
+		}
+		return a.app.UpdateRecipe(r.Context(), recipe)
+	}()
+	if err != nil {
+		page, loadErr := a.loadEditPage(r.Context(), id, nil)
+		if loadErr != nil {
+			return loadErr
+		}
+
+		// The submitted values are shown instead of the stored ones, so that they can be corrected.
+		page.Name, page.Description, page.Source = recipe.Name, recipe.Description, recipe.Source
+		page.BaseServings, page.Servings = recipe.BaseServings, recipe.Servings
+		page.ServingsDescription, page.Language = recipe.ServingsDescription, recipe.Language
+		page.WorkingTime, page.WaitingTime = recipe.WorkingTime, recipe.WaitingTime
+		return a.renderInvalidForm(w, r, &page, &page.formErrors, "", nil, err)
 	}
 
 	http.Redirect(w, r, "/recipes/"+strconv.Itoa(id), http.StatusFound)
 	return nil
 }
 
+func (a appWrapper) postForkRecipe(w http.ResponseWriter, r *http.Request) error {
+	if err := r.ParseForm(); err != nil {
+		return err
+	}
+
+	id, err := a.app.ForkRecipe(r.Context(), app.ForkRecipeParams{
+		RecipeID: httpreq.MustIDParam(r, "id"),
+		Name:     r.PostFormValue("Name"),
+	})
+	if err != nil {
+		return err
+	}
+
+	http.Redirect(w, r, "/recipes/"+strconv.Itoa(id)+"/edit", http.StatusFound)
+	return nil
+}
+
 func (a appWrapper) getAddStepToRecipe(w http.ResponseWriter, r *http.Request) error {
+	recipeID := httpreq.MustIDParam(r, "id")
 	if !htmx.IsHTMXRequest(r) {
-		panic("progressive enhancement is not implemented yet")
+		redirectToEditor(w, r, recipeID, editStepState(0), 0)
+		return nil
 	}
 
-	if err := a.app.Templates.RenderTemplate(w, "recipes/edit.tmpl", "single_step_edit", app.Step{
-		RecipeID: httpreq.MustIDParam(r, "id"),
+	if err := a.app.Templates.RenderTemplate(r.Context(), w, "recipes/edit.tmpl", "single_step_edit", stepForm{
+		Step: app.Step{RecipeID: recipeID},
 	}); err != nil {
 		return err
 	}
@@ -102,8 +158,117 @@ func (a appWrapper) getAddStepToRecipe(w http.ResponseWriter, r *http.Request) e
 	return nil
 }
 
+func (a appWrapper) getAddStepAfter(w http.ResponseWriter, r *http.Request) error {
+	recipeID := httpreq.MustIDParam(r, "id")
+	stepID := httpreq.MustIDParam(r, "stepID")
+	after, err := a.app.GetStepByID(r.Context(), stepID)
+	if err != nil {
+		return err
+	}
+	if after.RecipeID != recipeID {
+		return resperr.New(http.StatusNotFound, "step %d is not part of recipe %d", stepID, recipeID)
+	}
+
+	if !htmx.IsHTMXRequest(r) {
+		state := editStepState(0)
+		state.Set(insertAfterParam, strconv.Itoa(stepID))
+		redirectToEditor(w, r, recipeID, state, 0)
+		return nil
+	}
+
+	if err := a.app.Templates.RenderTemplate(r.Context(), w, "recipes/edit.tmpl", "single_step_edit", stepForm{
+		Step: app.Step{RecipeID: recipeID, InsertAfter: stepID},
+	}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (a appWrapper) postReorderSteps(w http.ResponseWriter, r *http.Request) error {
+	recipeID := httpreq.MustIDParam(r, "id")
+	if err := r.ParseForm(); err != nil {
+		return err
+	}
+
+	var stepIDs []int
+	for _, v := range r.PostForm["StepOrder"] {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			return resperr.WithCodeAndMessage(err, http.StatusBadRequest, "invalid step id")
+		}
+		stepIDs = append(stepIDs, id)
+	}
+
+	if err := a.app.ReorderSteps(r.Context(), recipeID, stepIDs); err != nil {
+		return err
+	}
+
+	if htmx.IsHTMXRequest(r) {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		redirectToEditor(w, r, recipeID, nil, 0)
+	}
+	return nil
+}
+
+func (a appWrapper) postMoveStep(w http.ResponseWriter, r *http.Request) error {
+	recipeID := httpreq.MustIDParam(r, "id")
+	stepID := httpreq.MustIDParam(r, "stepID")
+	if err := r.ParseForm(); err != nil {
+		return err
+	}
+
+	recipe, err := a.app.GetSingleRecipe(r.Context(), recipeID)
+	if err != nil {
+		return err
+	}
+
+	// The position is determined by the current order, because only relative movements are supported.
+	position := 0
+	for i, s := range recipe.Steps {
+		if s.ID == stepID {
+			position = i + 1
+		}
+	}
+	if position == 0 {
+		return resperr.New(http.StatusNotFound, "step %d is not part of recipe %d", stepID, recipeID)
+	}
+
+	switch r.PostFormValue("Direction") {
+	case "up":
+		position--
+	case "down":
+		position++
+	default:
+		return resperr.New(http.StatusBadRequest, "invalid direction %q", r.PostFormValue("Direction"))
+	}
+
+	if err := a.app.MoveStep(r.Context(), recipeID, stepID, position); err != nil {
+		return err
+	}
+
+	if !htmx.IsHTMXRequest(r) {
+		redirectToEditor(w, r, recipeID, nil, stepID)
+		return nil
+	}
+
+	recipe, err = a.app.GetSingleRecipe(r.Context(), recipeID)
+	if err != nil {
+		return err
+	}
+	if err := a.app.Templates.RenderTemplate(r.Context(), w, "recipes/edit.tmpl", "steps", editPage{Recipe: recipe}); err != nil {
+		return err
+	}
+	return nil
+}
+
 func (a appWrapper) getSingleStep(w http.ResponseWriter, r *http.Request) error {
 	stepID, _ := httpreq.IDParam(r, "stepID")
+	if !htmx.IsHTMXRequest(r) {
+		redirectToEditor(w, r, httpreq.MustIDParam(r, "id"), nil, stepID)
+		return nil
+	}
 
 	// If we invoke this route with step 0, we assume that the step hasn't been persisted yet.
 	// In this case, we return nothing, so that the HTML node is removed again.
@@ -117,24 +282,26 @@ func (a appWrapper) getSingleStep(w http.ResponseWriter, r *http.Request) error
 		return err
 	}
 
-	if err := a.app.Templates.RenderTemplate(w, "recipes/edit.tmpl", "single_step", step); err != nil {
+	if err := a.app.Templates.RenderTemplate(r.Context(), w, "recipes/edit.tmpl", "single_step", step); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func (a appWrapper) setStepToEditMode(w http.ResponseWriter, r *http.Request) error {
+func (a appWrapper) getEditRecipeStep(w http.ResponseWriter, r *http.Request) error {
+	stepID := httpreq.MustIDParam(r, "stepID")
 	if !htmx.IsHTMXRequest(r) {
-		panic("progressive enhancement is not implemented yet")
+		redirectToEditor(w, r, httpreq.MustIDParam(r, "id"), editStepState(stepID), stepID)
+		return nil
 	}
 
-	step, err := a.app.GetStepByID(r.Context(), httpreq.MustIDParam(r, "stepID"))
+	step, err := a.app.GetStepByID(r.Context(), stepID)
 	if err != nil {
 		return err
 	}
 
-	if err := a.app.Templates.RenderTemplate(w, "recipes/edit.tmpl", "single_step_edit", step); err != nil {
+	if err := a.app.Templates.RenderTemplate(r.Context(), w, "recipes/edit.tmpl", "single_step_edit", stepForm{Step: step}); err != nil {
 		return err
 	}
 
@@ -151,6 +318,7 @@ func (a appWrapper) updateRecipeStep(w http.ResponseWriter, r *http.Request) err
 	data := struct {
 		Instruction string
 		Time        string
+		After       int
 	}{}
 	if err := bind.Request(r).Field(&data.Instruction, "instruction"); err != nil {
 		return err
@@ -158,89 +326,106 @@ func (a appWrapper) updateRecipeStep(w http.ResponseWriter, r *http.Request) err
 	if err := bind.Request(r).Field(&data.Time, "time"); err != nil {
 		return err
 	}
+	data.After = parseIntWithDefault(r.PostFormValue("after"))
 
-	dur, _ := time.ParseDuration(data.Time)
 	step := &app.Step{
 		ID:          stepID,
 		RecipeID:    recipeID,
 		Instruction: data.Instruction,
-		Time:        dur,
 	}
-	if stepID != 0 {
-		if err := a.app.UpdateStep(r.Context(), *step); err != nil {
-			return fmt.Errorf("updating step %d: %w", stepID, err)
+	err := func() (err error) {
+		if step.Time, err = duration.Parse(data.Time); err != nil {
+			return app.FieldErrors{"Time": "validation.invalid_duration"}.Err()
 		}
-	} else {
-		if err := a.app.AddStepToRecipe(r.Context(), recipeID, step); err != nil {
-			return fmt.Errorf("adding step to recipe %d: %w", recipeID, err)
+
+		switch {
+		case stepID != 0:
+			if err := a.app.UpdateStep(r.Context(), *step); err != nil {
+				return fmt.Errorf("updating step %d: %w", stepID, err)
+			}
+		case data.After != 0:
+			if err := a.app.InsertStepAfter(r.Context(), recipeID, data.After, step); err != nil {
+				return fmt.Errorf("inserting step after step %d: %w", data.After, err)
+			}
+		default:
+			if err := a.app.AddStepToRecipe(r.Context(), recipeID, step); err != nil {
+				return fmt.Errorf("adding step to recipe %d: %w", recipeID, err)
+			}
+		}
+		return nil
+	}()
+	if resperr.StatusCode(err) == http.StatusNotFound {
+		// The step isn't part of the recipe, so there's no form to show the error in.
+		return err
+	}
+	if err != nil {
+		state := editStepState(stepID)
+		if data.After != 0 {
+			state.Set(insertAfterParam, strconv.Itoa(data.After))
 		}
+		page, loadErr := a.loadEditPage(r.Context(), recipeID, state)
+		if loadErr != nil {
+			return loadErr
+		}
+
+		// The submitted values are shown instead of the stored ones, so that they can be corrected.
+		page.EditStep.Instruction, page.EditStep.Time = step.Instruction, step.Time
+		return a.renderInvalidForm(w, r, &page, &page.EditStep.formErrors, "single_step_edit", page.EditStep, err)
 	}
 
 	if htmx.IsHTMXRequest(r) {
-		if err := a.app.Templates.RenderTemplate(w, "recipes/edit.tmpl", "single_step", step); err != nil {
+		if err := a.app.Templates.RenderTemplate(r.Context(), w, "recipes/edit.tmpl", "single_step", step); err != nil {
 			return err
 		}
 	} else {
-		http.Redirect(w, r, "/recipes/"+chi.URLParam(r, "id"), http.StatusFound)
+		redirectToEditor(w, r, recipeID, nil, step.ID)
 	}
 	return nil
 }
 
 func (a appWrapper) deleteRecipeStep(w http.ResponseWriter, r *http.Request) error {
+	recipeID := httpreq.MustIDParam(r, "id")
 	id := httpreq.MustIDParam(r, "stepID")
-	if err := a.app.DeleteRecipeStepByID(r.Context(), id); err != nil {
+	if err := a.app.DeleteRecipeStepByID(r.Context(), recipeID, id); err != nil {
 		return err
 	}
 
-	w.WriteHeader(http.StatusOK)
+	if htmx.IsHTMXRequest(r) {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		redirectToEditor(w, r, recipeID, nil, 0)
+	}
 	return nil
 }
 
 func (a appWrapper) postAddNewRecipeStepIngredient(w http.ResponseWriter, r *http.Request) error {
-	data := struct {
-		Ingredients []app.Ingredient
-		Units       []app.Unit
-		RecipeID    int
-		StepID      int
-	}{}
-
-	ingredients, err := a.app.GetAllIngredients(r.Context())
+	stepID, err := httpreq.StrictIDParam(r, "stepID")
 	if err != nil {
 		return err
 	}
-
-	units, err := a.app.GetAllUnits(r.Context())
+	recipeID, err := httpreq.StrictIDParam(r, "id")
 	if err != nil {
 		return err
 	}
 
-	data.Ingredients = ingredients
-	data.Units = units
+	if !htmx.IsHTMXRequest(r) {
+		state := editStepState(stepID)
+		state.Set(addIngredientParam, "1")
+		redirectToEditor(w, r, recipeID, state, stepID)
+		return nil
+	}
 
-	stepID, err := httpreq.StrictIDParam(r, "stepID")
+	form, err := a.newIngredientForm(r.Context(), recipeID, stepID)
 	if err != nil {
 		return err
 	}
-	recipeID, err := httpreq.StrictIDParam(r, "id")
-	if err != nil {
+	if err := a.app.Templates.RenderTemplate(r.Context(), w, "recipes/edit.tmpl", "new_ingredient", form); err != nil {
 		return err
 	}
-
-	data.RecipeID = recipeID
-	data.StepID = stepID
-
-	if htmx.IsHTMXRequest(r) {
-		if err := a.app.Templates.RenderTemplate(w, "recipes/edit.tmpl", "new_ingredient", data); err != nil {
-			return err
-		}
-	} else {
-		panic("progressive enhancement not yet implemented")
-	}
 	return nil
 }
 
 func (a appWrapper) postAddRecipeStepIngredient(w http.ResponseWriter, r *http.Request) error {
-	recipeID := httpreq.MustIDParam(r, "id")
 	stepID, err := httpreq.StrictIDParam(r, "stepID")
 	if err != nil {
 		return err
@@ -253,7 +438,6 @@ func (a appWrapper) postAddRecipeStepIngredient(w http.ResponseWriter, r *http.R
 	params := app.AddIngredientToStepParams{
 		StepID:       stepID,
 		IngredientID: parseIntWithDefault(r.PostFormValue("Ingredient")),
-		Amount:       float64(parseIntWithDefault(r.PostFormValue("Amount"))),
 		Note:         r.PostFormValue("Note"),
 	}
 
@@ -261,32 +445,180 @@ func (a appWrapper) postAddRecipeStepIngredient(w http.ResponseWriter, r *http.R
 		params.UnitID = &unit
 	}
 
-	if err := a.app.AddIngredientToStep(r.Context(), params); err != nil {
+	err = func() error {
+		amount, err := parseAmount(r.PostFormValue("Amount"))
+		if err != nil {
+			return err
+		}
+		params.Amount = amount
+		return a.app.AddIngredientToStep(r.Context(), params)
+	}()
+	if err != nil {
+		state := editStepState(stepID)
+		state.Set(addIngredientParam, "1")
+		page, loadErr := a.loadEditPage(r.Context(), httpreq.MustIDParam(r, "id"), state)
+		if loadErr != nil {
+			return loadErr
+		}
+		form := page.EditStep.NewIngredient
+		return a.renderInvalidForm(w, r, &page, &form.formErrors, "new_ingredient", form, err)
+	}
+
+	if !htmx.IsHTMXRequest(r) {
+		redirectToEditor(w, r, httpreq.MustIDParam(r, "id"), editStepState(stepID), stepID)
+		return nil
+	}
+
+	ingredient, err := a.app.GetStepIngredient(r.Context(), stepID, params.IngredientID)
+	if err != nil {
+		return err
+	}
+	if err := a.app.Templates.RenderTemplate(r.Context(), w, "recipes/edit.tmpl", "ingredient", ingredient); err != nil {
 		return err
 	}
+	return nil
+}
 
-	ingredient, err := a.app.GetIngredient(r.Context(), params.IngredientID)
+func (a appWrapper) getRecipeStepIngredient(w http.ResponseWriter, r *http.Request) error {
+	stepID := httpreq.MustIDParam(r, "stepID")
+	ingredientID := httpreq.MustIDParam(r, "ingredientID")
+
+	if !htmx.IsHTMXRequest(r) {
+		redirectToEditor(w, r, httpreq.MustIDParam(r, "id"), editStepState(stepID), stepID)
+		return nil
+	}
+
+	ingredient, err := a.app.GetStepIngredient(r.Context(), stepID, ingredientID)
 	if err != nil {
 		return err
 	}
 
-	if htmx.IsHTMXRequest(r) {
-		if err := a.app.Templates.RenderTemplate(w, "recipes/edit.tmpl", "ingredient", app.Ingredient{
-			Name:     ingredient.Name,
-			Amount:   params.Amount,
-			Note:     params.Note,
-			StepID:   stepID,
-			RecipeID: recipeID,
-		}); err != nil {
+	if err := a.app.Templates.RenderTemplate(r.Context(), w, "recipes/edit.tmpl", "ingredient", ingredient); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (a appWrapper) getEditRecipeStepIngredient(w http.ResponseWriter, r *http.Request) error {
+	stepID := httpreq.MustIDParam(r, "stepID")
+	ingredientID := httpreq.MustIDParam(r, "ingredientID")
+
+	if !htmx.IsHTMXRequest(r) {
+		state := editStepState(stepID)
+		state.Set(editIngredientParam, strconv.Itoa(ingredientID))
+		redirectToEditor(w, r, httpreq.MustIDParam(r, "id"), state, stepID)
+		return nil
+	}
+
+	ingredient, err := a.app.GetStepIngredient(r.Context(), stepID, ingredientID)
+	if err != nil {
+		return err
+	}
+
+	units, err := a.app.GetAllUnits(r.Context())
+	if err != nil {
+		return err
+	}
+
+	data := ingredientForm{
+		Ingredient: ingredient,
+		Units:      units,
+	}
+	if err := a.app.Templates.RenderTemplate(r.Context(), w, "recipes/edit.tmpl", "ingredient_edit", data); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (a appWrapper) updateRecipeStepIngredient(w http.ResponseWriter, r *http.Request) error {
+	recipeID := httpreq.MustIDParam(r, "id")
+	stepID := httpreq.MustIDParam(r, "stepID")
+	ingredientID := httpreq.MustIDParam(r, "ingredientID")
+
+	if err := r.ParseForm(); err != nil {
+		return err
+	}
+
+	params := app.UpdateStepIngredientParams{
+		RecipeID:     recipeID,
+		StepID:       stepID,
+		IngredientID: ingredientID,
+		Note:         r.PostFormValue("Note"),
+	}
+	if unit := parseIntWithDefault(r.PostFormValue("Unit")); unit > 0 {
+		params.UnitID = &unit
+	}
+	err := func() error {
+		amount, err := parseAmount(r.PostFormValue("Amount"))
+		if err != nil {
 			return err
 		}
+		params.Amount = amount
+		return a.app.UpdateStepIngredient(r.Context(), params)
+	}()
+	if resperr.StatusCode(err) == http.StatusNotFound {
+		// The step or the ingredient isn't part of the recipe, so there's no form to show the error in.
+		return err
+	}
+	if err != nil {
+		state := editStepState(stepID)
+		state.Set(editIngredientParam, strconv.Itoa(ingredientID))
+		page, loadErr := a.loadEditPage(r.Context(), recipeID, state)
+		if loadErr != nil {
+			return loadErr
+		}
+
+		// The submitted values are shown instead of the stored ones, so that they can be corrected.
+		form := page.EditStep.EditIngredient
+		form.Note, form.Amount = params.Note, params.Amount
+		form.UnitID = parseIntWithDefault(r.PostFormValue("Unit"))
+		return a.renderInvalidForm(w, r, &page, &form.formErrors, "ingredient_edit", form, err)
+	}
+
+	if !htmx.IsHTMXRequest(r) {
+		redirectToEditor(w, r, recipeID, editStepState(stepID), stepID)
+		return nil
+	}
+
+	ingredient, err := a.app.GetStepIngredient(r.Context(), stepID, ingredientID)
+	if err != nil {
+		return err
+	}
+	if err := a.app.Templates.RenderTemplate(r.Context(), w, "recipes/edit.tmpl", "ingredient", ingredient); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (a appWrapper) postReorderRecipeStepIngredients(w http.ResponseWriter, r *http.Request) error {
+	recipeID := httpreq.MustIDParam(r, "id")
+	stepID := httpreq.MustIDParam(r, "stepID")
+	if err := r.ParseForm(); err != nil {
+		return err
+	}
+
+	var ingredientIDs []int
+	for _, v := range r.PostForm["IngredientOrder"] {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			return resperr.WithCodeAndMessage(err, http.StatusBadRequest, "invalid ingredient id")
+		}
+		ingredientIDs = append(ingredientIDs, id)
+	}
+
+	if err := a.app.ReorderStepIngredients(r.Context(), recipeID, stepID, ingredientIDs); err != nil {
+		return err
+	}
+
+	if htmx.IsHTMXRequest(r) {
+		w.WriteHeader(http.StatusOK)
 	} else {
-		panic("progressive enhancement not yet implemented")
+		redirectToEditor(w, r, recipeID, editStepState(stepID), stepID)
 	}
 	return nil
 }
 
-func (a appWrapper) deleteRecipeStepIngredient(_ http.ResponseWriter, r *http.Request) error {
+func (a appWrapper) deleteRecipeStepIngredient(w http.ResponseWriter, r *http.Request) error {
 	stepID, err := httpreq.StrictIDParam(r, "stepID")
 	if err != nil {
 		return err
@@ -304,6 +636,9 @@ func (a appWrapper) deleteRecipeStepIngredient(_ http.ResponseWriter, r *http.Re
 		return err
 	}
 
+	if !htmx.IsHTMXRequest(r) {
+		redirectToEditor(w, r, httpreq.MustIDParam(r, "id"), editStepState(stepID), stepID)
+	}
 	return nil
 }
 
@@ -314,3 +649,12 @@ func parseIntWithDefault(s string) int {
 	}
 	return i
 }
+
+// parseAmount parses the amount of an ingredient, which must not be negative.
+func parseAmount(s string) (float64, error) {
+	amount, err := strconv.ParseFloat(s, 64)
+	if err != nil || amount < 0 {
+		return 0, app.FieldErrors{"Amount": "validation.invalid_amount"}.Err()
+	}
+	return amount, nil
+}
@@ -0,0 +1,53 @@
+package routes
+
+import (
+	"net/http"
+	"strconv"
+
+	"codeberg.org/mahlzeit/mahlzeit/internal/http/httpreq"
+	"codeberg.org/mahlzeit/mahlzeit/internal/i18n"
+)
+
+func (a appWrapper) getRecipeHistory(w http.ResponseWriter, r *http.Request) error {
+	history, err := a.app.GetRecipeHistory(r.Context(), httpreq.MustIDParam(r, "id"))
+	if err != nil {
+		return err
+	}
+
+	if err := a.app.Templates.RenderPage(r.Context(), w, "recipes/history.tmpl", history); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (a appWrapper) getRecipeRevision(w http.ResponseWriter, r *http.Request) error {
+	revisionID, err := httpreq.StrictIDParam(r, "revisionID")
+	if err != nil {
+		return err
+	}
+
+	revision, err := a.app.GetRevision(r.Context(), httpreq.MustIDParam(r, "id"), revisionID, i18n.FromContext(r.Context()).Locale())
+	if err != nil {
+		return err
+	}
+
+	if err := a.app.Templates.RenderPage(r.Context(), w, "recipes/revision.tmpl", revision); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (a appWrapper) postRestoreRevision(w http.ResponseWriter, r *http.Request) error {
+	recipeID := httpreq.MustIDParam(r, "id")
+	revisionID, err := httpreq.StrictIDParam(r, "revisionID")
+	if err != nil {
+		return err
+	}
+
+	if err := a.app.RestoreRevision(r.Context(), recipeID, revisionID); err != nil {
+		return err
+	}
+
+	http.Redirect(w, r, "/recipes/"+strconv.Itoa(recipeID), http.StatusFound)
+	return nil
+}
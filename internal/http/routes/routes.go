@@ -5,7 +5,10 @@ import (
 	"time"
 
 	"codeberg.org/mahlzeit/mahlzeit/internal/app"
+	"codeberg.org/mahlzeit/mahlzeit/internal/http/api"
 	"codeberg.org/mahlzeit/mahlzeit/internal/http/httpreq"
+	"codeberg.org/mahlzeit/mahlzeit/internal/oidc"
+	"codeberg.org/mahlzeit/mahlzeit/internal/tracing"
 	"codeberg.org/mahlzeit/mahlzeit/internal/zaphelper"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -14,48 +17,104 @@ import (
 // All returns the [chi.Mux] that is going to be used for our HTTP handlers.
 // It's extracted into this function to see quickly which routes exist and where
 // they are registered.
-func All(c *app.Application) *chi.Mux {
+//
+// The compiled assets are served below "/assets/".
+func All(c *app.Application, assets http.Handler) *chi.Mux {
 	r := chi.NewMux()
 
 	// The default middleware stack
 	r.Use(
 		middleware.RequestID,
 		middleware.RealIP,
-		zaphelper.InjectLogger(c.Logger),
-		zaphelper.RequestLogger(),
+		tracing.Middleware,
+		zaphelper.InjectLogger(c.Logger.Named("http")),
+		zaphelper.RequestLogger(c.Metrics.ObserveRequest),
 		middleware.Recoverer,
 		middleware.CleanPath,
 		stripMultipleQueryParameters,
 	)
 
+	w := appWrapper{c}
+	r.Use(w.authenticate, w.localize)
+	// All routes that change state, including the API, require a CSRF token, see protectCSRF.
+	r.Use(protectCSRF)
+
 	// Set a timeout value on the request context (ctx), that will signal
 	// through ctx.Done() that the request has timed out and further
 	// processing should be stopped.
 	r.Use(middleware.Timeout(60 * time.Second))
 
-	// Add a file server for the compiled assets.
-	// In the future, those should be embedded into the binary to simplify the deployment.
-	assetsServer := http.FileServer(http.Dir("./assets/"))
-	r.Handle("/assets/*", http.StripPrefix("/assets", assetsServer))
+	r.Handle("/assets/*", assets)
+
+	r.Mount(api.Prefix, api.Routes(c))
+
+	r.Post("/locale", errorWrapper(w.postLocale))
+	r.Get("/login", errorWrapper(w.getLogin))
+	r.Post("/login", errorWrapper(w.postLogin))
+	r.Post("/logout", errorWrapper(w.postLogout))
+	r.Get("/register", errorWrapper(w.getRegister))
+	r.Post("/register", errorWrapper(w.postRegister))
+	r.Get("/verify-email", errorWrapper(w.getVerifyEmail))
+	r.Get("/forgot-password", errorWrapper(w.getForgotPassword))
+	r.Post("/forgot-password", errorWrapper(w.postForgotPassword))
+	r.Get("/reset-password", errorWrapper(w.getResetPassword))
+	r.Post("/reset-password", errorWrapper(w.postResetPassword))
+	if c.OIDC != nil {
+		r.Get("/login/oidc", errorWrapper(w.getOIDCLogin))
+		r.Get(oidc.CallbackPath, errorWrapper(w.getOIDCCallback))
+	}
+	r.Route("/settings", func(r chi.Router) {
+		r.Use(requireLogin)
 
-	w := appWrapper{c}
+		r.Get("/tokens", errorWrapper(w.getAPITokens))
+		r.Post("/tokens", errorWrapper(w.postAPIToken))
+		r.With(validateID("tokenID")).Post("/tokens/{tokenID}/delete", errorWrapper(w.postDeleteAPIToken))
+	})
+	r.Get("/images/*", errorWrapper(w.getImage))
 	r.Route("/recipes", func(r chi.Router) {
 		r.Get("/", errorWrapper(w.getAllRecipes))
 		r.Route("/{id}", func(r chi.Router) {
 			r.Use(validateID("id"))
 
 			r.Get("/", errorWrapper(w.getSingleRecipe))
-			r.Get("/edit", errorWrapper(w.getEditSingleRecipe))
-			r.Post("/edit", errorWrapper(w.postEditSingleRecipe))
-			r.Get("/edit/add_step", errorWrapper(w.getAddStepToRecipe))
-			r.Route("/steps/{stepID}", func(r chi.Router) {
-				r.Get("/", errorWrapper(w.getSingleStep))
-				r.Post("/", errorWrapper(w.setStepToEditMode))
-				r.Put("/", errorWrapper(w.updateRecipeStep))
-				r.Delete("/", errorWrapper(w.deleteRecipeStep))
-				r.Post("/add_ingredient", errorWrapper(w.postAddNewRecipeStepIngredient))
-				r.Post("/ingredients", errorWrapper(w.postAddRecipeStepIngredient))
-				r.Delete("/ingredients/{ingredientID}", errorWrapper(w.deleteRecipeStepIngredient))
+			r.Get("/history", errorWrapper(w.getRecipeHistory))
+			r.Get("/history/{revisionID}", errorWrapper(w.getRecipeRevision))
+
+			// Only logged-in users may edit recipes, like in the API, so that all revisions have an author.
+			r.Group(func(r chi.Router) {
+				r.Use(requireLogin)
+
+				r.Get("/edit", errorWrapper(w.getEditSingleRecipe))
+				r.Post("/edit", errorWrapper(w.postEditSingleRecipe))
+				r.Get("/edit/add_step", errorWrapper(w.getAddStepToRecipe))
+				r.Post("/fork", errorWrapper(w.postForkRecipe))
+				r.Post("/history/{revisionID}/restore", errorWrapper(w.postRestoreRevision))
+				r.Post("/images", errorWrapper(w.postRecipeImage))
+				r.Delete("/images/{imageID}", errorWrapper(w.deleteRecipeImage))
+				r.Post("/images/{imageID}/delete", errorWrapper(w.deleteRecipeImage))
+				r.Post("/steps/order", errorWrapper(w.postReorderSteps))
+				r.Get("/translations/{locale}", errorWrapper(w.getRecipeTranslation))
+				r.Post("/translations/{locale}", errorWrapper(w.postRecipeTranslation))
+				r.Route("/steps/{stepID}", func(r chi.Router) {
+					r.Get("/", errorWrapper(w.getSingleStep))
+					r.Put("/", errorWrapper(w.updateRecipeStep))
+					r.Post("/", errorWrapper(w.updateRecipeStep))
+					r.Delete("/", errorWrapper(w.deleteRecipeStep))
+					r.Post("/delete", errorWrapper(w.deleteRecipeStep))
+					r.Get("/edit", errorWrapper(w.getEditRecipeStep))
+					r.Get("/add_after", errorWrapper(w.getAddStepAfter))
+					r.Post("/move", errorWrapper(w.postMoveStep))
+					r.Post("/add_ingredient", errorWrapper(w.postAddNewRecipeStepIngredient))
+					r.Post("/ingredients", errorWrapper(w.postAddRecipeStepIngredient))
+					r.Post("/ingredients/order", errorWrapper(w.postReorderRecipeStepIngredients))
+					r.Get("/ingredients/{ingredientID}", errorWrapper(w.getRecipeStepIngredient))
+					r.Get("/ingredients/{ingredientID}/edit", errorWrapper(w.getEditRecipeStepIngredient))
+					r.Put("/ingredients/{ingredientID}", errorWrapper(w.updateRecipeStepIngredient))
+					r.Post("/ingredients/{ingredientID}", errorWrapper(w.updateRecipeStepIngredient))
+					r.Delete("/ingredients/{ingredientID}", errorWrapper(w.deleteRecipeStepIngredient))
+					r.Post("/ingredients/{ingredientID}/delete", errorWrapper(w.deleteRecipeStepIngredient))
+					r.Post("/images", errorWrapper(w.postRecipeImage))
+				})
 			})
 		})
 	})
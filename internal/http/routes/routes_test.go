@@ -0,0 +1,85 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"codeberg.org/mahlzeit/mahlzeit/internal/app"
+	"codeberg.org/mahlzeit/mahlzeit/internal/blob"
+	"github.com/alecthomas/assert/v2"
+	"go.uber.org/zap"
+)
+
+// TestAll_RequireLogin ensures that visitors can't edit recipes. None of these requests reach the database.
+func TestAll_RequireLogin(t *testing.T) {
+	t.Parallel()
+
+	handler := All(&app.Application{Logger: zap.NewNop()}, http.NotFoundHandler())
+	secret := bytes.Repeat([]byte{1}, csrfSecretLength)
+
+	tests := []struct {
+		method string
+		path   string
+	}{
+		{method: http.MethodGet, path: "/recipes/1/edit"},
+		{method: http.MethodPost, path: "/recipes/1/edit"},
+		{method: http.MethodPost, path: "/recipes/1/fork"},
+		{method: http.MethodPost, path: "/recipes/1/history/2/restore"},
+		{method: http.MethodDelete, path: "/recipes/1/images/2"},
+		{method: http.MethodPost, path: "/recipes/1/translations/en"},
+		{method: http.MethodPut, path: "/recipes/1/steps/2"},
+		{method: http.MethodDelete, path: "/recipes/1/steps/2"},
+		{method: http.MethodPost, path: "/recipes/1/steps/2/ingredients/order"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			t.Parallel()
+
+			// The request has a valid CSRF token, so that only the missing login can reject it.
+			r := httptest.NewRequest(tt.method, tt.path, nil)
+			r.AddCookie(&http.Cookie{Name: csrfCookie, Value: base64.RawURLEncoding.EncodeToString(secret)})
+			r.Header.Set(csrfHeader, maskCSRFSecret(secret))
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, r)
+
+			assert.Equal(t, http.StatusSeeOther, rec.Code)
+			assert.Equal(t, "/login?next="+url.QueryEscape(tt.path), rec.Header().Get("Location"))
+		})
+	}
+}
+
+func TestAll_GetImage(t *testing.T) {
+	t.Parallel()
+
+	blobs, err := blob.NewFilesystem(t.TempDir())
+	assert.NoError(t, err)
+	assert.NoError(t, blobs.Put(context.Background(), "recipes/1/320.jpg", strings.NewReader("image"), "image/jpeg"))
+	handler := All(&app.Application{Logger: zap.NewNop(), Blobs: blobs}, http.NotFoundHandler())
+
+	tests := []struct {
+		path     string
+		wantCode int
+	}{
+		{path: "/images/recipes/1/320.jpg", wantCode: http.StatusOK},
+		{path: "/images/recipes/1/800.jpg", wantCode: http.StatusNotFound},
+		{path: "/images/recipes//320.jpg", wantCode: http.StatusNotFound},
+		{path: "/images/recipes/1/..%2F..%2F320.jpg", wantCode: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.path, func(t *testing.T) {
+			t.Parallel()
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			assert.Equal(t, tt.wantCode, rec.Code)
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package routes
+
+import (
+	"net/http"
+	"strconv"
+
+	"codeberg.org/mahlzeit/mahlzeit/internal/app"
+	"codeberg.org/mahlzeit/mahlzeit/internal/http/httpreq"
+	"github.com/carlmjohnson/resperr"
+	"github.com/go-chi/chi/v5"
+)
+
+func (a appWrapper) getRecipeTranslation(w http.ResponseWriter, r *http.Request) error {
+	translation, err := a.app.GetRecipeTranslation(r.Context(), httpreq.MustIDParam(r, "id"), chi.URLParam(r, "locale"))
+	if err != nil {
+		return err
+	}
+
+	if err := a.app.Templates.RenderPage(r.Context(), w, "recipes/translation.tmpl", translation); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (a appWrapper) postRecipeTranslation(w http.ResponseWriter, r *http.Request) error {
+	recipeID := httpreq.MustIDParam(r, "id")
+	locale := chi.URLParam(r, "locale")
+	if err := r.ParseForm(); err != nil {
+		return err
+	}
+
+	t := &app.RecipeTranslation{
+		RecipeID:    recipeID,
+		Locale:      locale,
+		Name:        r.PostForm.Get("Name"),
+		Description: r.PostForm.Get("Description"),
+	}
+
+	stepIDs, err := formIDs(r, "StepID")
+	if err != nil {
+		return err
+	}
+	instructions := r.PostForm["Instruction"]
+	if len(instructions) != len(stepIDs) {
+		return resperr.WithUserMessage(nil, "each step needs an instruction")
+	}
+	for i, id := range stepIDs {
+		t.Steps = append(t.Steps, app.StepTranslation{StepID: id, Instruction: instructions[i]})
+	}
+
+	t.Ingredients, err = formTerms(r, "IngredientID", "IngredientName")
+	if err != nil {
+		return err
+	}
+	t.Units, err = formTerms(r, "UnitID", "UnitName")
+	if err != nil {
+		return err
+	}
+
+	if err := a.app.SaveRecipeTranslation(r.Context(), t); err != nil {
+		return err
+	}
+
+	http.Redirect(w, r, "/recipes/"+strconv.Itoa(recipeID)+"/translations/"+locale, http.StatusFound)
+	return nil
+}
+
+// formIDs returns all IDs of the form field with the given name.
+func formIDs(r *http.Request, field string) ([]int, error) {
+	var res []int
+	for _, v := range r.PostForm[field] {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, resperr.WithCodeAndMessage(err, http.StatusBadRequest, "invalid "+field)
+		}
+		res = append(res, id)
+	}
+	return res, nil
+}
+
+// formTerms returns the translations of ingredient or unit names, which are sent as pairs of an ID
+// and a name field.
+func formTerms(r *http.Request, idField, nameField string) ([]app.TermTranslation, error) {
+	ids, err := formIDs(r, idField)
+	if err != nil {
+		return nil, err
+	}
+	names := r.PostForm[nameField]
+	if len(names) != len(ids) {
+		return nil, resperr.WithUserMessagef(nil, "each %s needs a %s", idField, nameField)
+	}
+
+	res := make([]app.TermTranslation, 0, len(ids))
+	for i, id := range ids {
+		res = append(res, app.TermTranslation{ID: id, Name: names[i]})
+	}
+	return res, nil
+}
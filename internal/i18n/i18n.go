@@ -0,0 +1,298 @@
+// Package i18n translates the user interface. Messages are stored in a catalog per locale, which is
+// embedded into the binary. Besides translations, it negotiates the locale of a request and formats
+// numbers and dates according to the locale.
+package i18n
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"math"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"golang.org/x/text/language"
+)
+
+// DefaultLocale is used if no supported locale could be negotiated. Messages that are missing
+// in a catalog are taken from the catalog of the default locale.
+const DefaultLocale = "de"
+
+//go:embed locales/*.toml
+var localeFiles embed.FS
+
+// catalogs contains the messages by locale, e.g. "de".
+var catalogs = mustLoadCatalogs()
+
+// matcher is used to negotiate the best supported locale. The default locale is the first one,
+// so it's used as fallback by the matcher.
+var matcher = language.NewMatcher(supportedTags())
+
+// pluralCategories are the categories as defined by the Unicode CLDR. A catalog entry that only
+// consists of those keys is a message with plural forms.
+var pluralCategories = map[string]bool{"zero": true, "one": true, "two": true, "few": true, "many": true, "other": true}
+
+// pluralRules select the plural category for a number. Locales without a rule always use "other".
+var pluralRules = map[string]func(n float64) string{
+	"de": oneOrOther,
+	"en": oneOrOther,
+}
+
+func oneOrOther(n float64) string {
+	if n == 1 {
+		return "one"
+	}
+	return "other"
+}
+
+type format struct {
+	decimal, thousands string
+	date, dateTime     string
+}
+
+// formats contains the locale-specific formatting of numbers and dates.
+// Locales without an entry use the formatting of the DefaultLocale.
+var formats = map[string]format{
+	"de": {decimal: ",", thousands: ".", date: "02.01.2006", dateTime: "02.01.2006 15:04"},
+	"en": {decimal: ".", thousands: ",", date: "Jan 2, 2006", dateTime: "Jan 2, 2006, 3:04 PM"},
+}
+
+type message struct {
+	text   string
+	plural map[string]string // only set for messages with plural forms
+}
+
+type catalog map[string]message
+
+func mustLoadCatalogs() map[string]catalog {
+	files, err := localeFiles.ReadDir("locales")
+	if err != nil {
+		panic(fmt.Sprintf("reading locales: %v", err))
+	}
+
+	res := make(map[string]catalog, len(files))
+	for _, f := range files {
+		var raw map[string]any
+		if _, err := toml.DecodeFS(localeFiles, path.Join("locales", f.Name()), &raw); err != nil {
+			panic(fmt.Sprintf("decoding locale %s: %v", f.Name(), err))
+		}
+
+		c := catalog{}
+		flatten(c, "", raw)
+		res[strings.TrimSuffix(f.Name(), ".toml")] = c
+	}
+	return res
+}
+
+// flatten adds all messages of the nested tables in raw to c. The keys are joined with dots,
+// e.g. "recipe.edit" for the key "edit" in the table "recipe".
+func flatten(c catalog, prefix string, raw map[string]any) {
+	for k, v := range raw {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+
+		switch v := v.(type) {
+		case string:
+			c[key] = message{text: v}
+		case map[string]any:
+			if forms, ok := pluralForms(v); ok {
+				c[key] = message{text: forms["other"], plural: forms}
+				continue
+			}
+			flatten(c, key, v)
+		}
+	}
+}
+
+func pluralForms(raw map[string]any) (map[string]string, bool) {
+	if _, ok := raw["other"]; !ok {
+		return nil, false
+	}
+
+	forms := make(map[string]string, len(raw))
+	for k, v := range raw {
+		s, ok := v.(string)
+		if !ok || !pluralCategories[k] {
+			return nil, false
+		}
+		forms[k] = s
+	}
+	return forms, true
+}
+
+func supportedTags() []language.Tag {
+	tags := []language.Tag{language.MustParse(DefaultLocale)}
+	for _, l := range Supported() {
+		if l != DefaultLocale {
+			tags = append(tags, language.MustParse(l))
+		}
+	}
+	return tags
+}
+
+// Supported returns all locales that have a catalog, sorted by name.
+func Supported() []string {
+	res := make([]string, 0, len(catalogs))
+	for l := range catalogs {
+		res = append(res, l)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// IsSupported returns whether a catalog exists for the locale.
+func IsSupported(locale string) bool {
+	_, ok := catalogs[locale]
+	return ok
+}
+
+// Negotiate returns the best supported locale for the given preferences, which are checked in order.
+// Each preference can be a single locale, e.g. "en-GB", or the value of an Accept-Language header.
+// Empty or invalid preferences are skipped. If none matches, the DefaultLocale is returned.
+func Negotiate(preferences ...string) string {
+	for _, p := range preferences {
+		tags, _, err := language.ParseAcceptLanguage(p)
+		if err != nil || len(tags) == 0 {
+			continue
+		}
+
+		tag, _, confidence := matcher.Match(tags...)
+		if confidence == language.No {
+			continue
+		}
+		base, _ := tag.Base()
+		return base.String()
+	}
+	return DefaultLocale
+}
+
+// Localizer translates messages and formats values for a single locale.
+type Localizer struct {
+	locale string
+}
+
+// New returns a Localizer for the locale. Unsupported locales result in the DefaultLocale.
+func New(locale string) *Localizer {
+	if !IsSupported(locale) {
+		locale = DefaultLocale
+	}
+	return &Localizer{locale: locale}
+}
+
+// Locale returns the locale that is used by l, e.g. "de".
+func (l *Localizer) Locale() string {
+	return l.locale
+}
+
+// T returns the translated message for key. The arguments are formatted into the message like
+// with [fmt.Sprintf]. For messages with plural forms, the first argument must be the count, which
+// determines the form, e.g. "1 Portion" and "2 Portionen". If the message is missing in the catalog
+// of the locale, the one of the DefaultLocale is used. If it doesn't exist at all, the key is returned.
+func (l *Localizer) T(key string, args ...any) string {
+	msg, ok := catalogs[l.locale][key]
+	if !ok {
+		msg, ok = catalogs[DefaultLocale][key]
+		if !ok {
+			return key
+		}
+	}
+
+	text := msg.text
+	if msg.plural != nil && len(args) > 0 {
+		if n, ok := toFloat(args[0]); ok {
+			rule, ok := pluralRules[l.locale]
+			if !ok {
+				rule = func(float64) string { return "other" }
+			}
+			if form, ok := msg.plural[rule(n)]; ok {
+				text = form
+			}
+		}
+	}
+
+	// Messages without verbs, e.g. plural forms without the count, are returned as they are.
+	if len(args) == 0 || !strings.Contains(text, "%") {
+		return text
+	}
+	return fmt.Sprintf(text, args...)
+}
+
+// Number formats n with up to two decimal places, e.g. "1.234,5" for German.
+func (l *Localizer) Number(n float64) string {
+	f := l.format()
+
+	s := strconv.FormatFloat(math.Round(n*100)/100, 'f', -1, 64)
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	integer, fraction, _ := strings.Cut(s, ".")
+
+	var b strings.Builder
+	b.WriteString(sign)
+	for i, r := range integer {
+		if i > 0 && (len(integer)-i)%3 == 0 {
+			b.WriteString(f.thousands)
+		}
+		b.WriteRune(r)
+	}
+	if fraction != "" {
+		b.WriteString(f.decimal)
+		b.WriteString(fraction)
+	}
+	return b.String()
+}
+
+// Date formats the date of t, e.g. "19.10.2026" for German.
+func (l *Localizer) Date(t time.Time) string {
+	return t.Format(l.format().date)
+}
+
+// DateTime formats the date and time of t, e.g. "19.10.2026 14:30" for German.
+func (l *Localizer) DateTime(t time.Time) string {
+	return t.Format(l.format().dateTime)
+}
+
+func (l *Localizer) format() format {
+	if f, ok := formats[l.locale]; ok {
+		return f
+	}
+	return formats[DefaultLocale]
+}
+
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	default:
+		return 0, false
+	}
+}
+
+type localizerKey struct{}
+
+// WithLocalizer returns a copy of ctx that carries l.
+func WithLocalizer(ctx context.Context, l *Localizer) context.Context {
+	return context.WithValue(ctx, localizerKey{}, l)
+}
+
+// FromContext returns the Localizer of ctx, as set by [WithLocalizer].
+// If none is set, a Localizer for the DefaultLocale is returned.
+func FromContext(ctx context.Context) *Localizer {
+	if l, ok := ctx.Value(localizerKey{}).(*Localizer); ok {
+		return l
+	}
+	return New(DefaultLocale)
+}
@@ -0,0 +1,150 @@
+package i18n
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/alecthomas/assert/v2"
+)
+
+func TestLocalizer_T(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		locale string
+		key    string
+		args   []any
+		want   string
+	}{
+		{locale: "de", key: "common.edit", want: "Bearbeiten"},
+		{locale: "en", key: "common.edit", want: "Edit"},
+		{locale: "en", key: "recipe.edit_title", args: []any{"Pizza"}, want: "Edit Pizza"},
+		{locale: "de", key: "recipe.servings_unit", args: []any{1}, want: "Portion"},
+		{locale: "de", key: "recipe.servings_unit", args: []any{int32(2)}, want: "Portionen"},
+		{locale: "en", key: "recipe.servings_unit", args: []any{1}, want: "serving"},
+		{locale: "en", key: "recipe.servings_unit", args: []any{0.5}, want: "servings"},
+		{locale: "de", key: "history.count", args: []any{1}, want: "1 Version"},
+		{locale: "de", key: "history.count", args: []any{3}, want: "3 Versionen"},
+		{locale: "fr", key: "common.edit", want: "Bearbeiten"},
+		{locale: "de", key: "does.not.exist", want: "does.not.exist"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.locale+" "+tt.key, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, New(tt.locale).T(tt.key, tt.args...))
+		})
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		preferences []string
+		want        string
+	}{
+		{name: "no preferences", want: DefaultLocale},
+		{name: "accept-language", preferences: []string{"en-US,en;q=0.9,de;q=0.8"}, want: "en"},
+		{name: "weighted", preferences: []string{"de;q=0.5,en;q=0.9"}, want: "en"},
+		{name: "regional variant", preferences: []string{"de-AT"}, want: "de"},
+		{name: "unsupported", preferences: []string{"fr-FR"}, want: DefaultLocale},
+		{name: "first preference wins", preferences: []string{"en", "de"}, want: "en"},
+		{name: "empty preferences are skipped", preferences: []string{"", "en"}, want: "en"},
+		{name: "invalid preferences are skipped", preferences: []string{"???", "en"}, want: "en"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, Negotiate(tt.preferences...))
+		})
+	}
+}
+
+func TestLocalizer_Number(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		locale string
+		n      float64
+		want   string
+	}{
+		{locale: "de", n: 2, want: "2"},
+		{locale: "de", n: 0.5, want: "0,5"},
+		{locale: "de", n: 1234.567, want: "1.234,57"},
+		{locale: "en", n: 1234.567, want: "1,234.57"},
+		{locale: "en", n: -1234567, want: "-1,234,567"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, New(tt.locale).Number(tt.n))
+	}
+}
+
+func TestLocalizer_Date(t *testing.T) {
+	t.Parallel()
+
+	d := time.Date(2026, time.October, 19, 14, 30, 0, 0, time.UTC)
+	assert.Equal(t, "19.10.2026", New("de").Date(d))
+	assert.Equal(t, "19.10.2026 14:30", New("de").DateTime(d))
+	assert.Equal(t, "Oct 19, 2026", New("en").Date(d))
+	assert.Equal(t, "Oct 19, 2026, 2:30 PM", New("en").DateTime(d))
+}
+
+func TestFromContext(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, DefaultLocale, FromContext(context.Background()).Locale())
+
+	ctx := WithLocalizer(context.Background(), New("en"))
+	assert.Equal(t, "en", FromContext(ctx).Locale())
+}
+
+// TestCatalogs ensures that all catalogs contain the same messages, so that no locale silently falls back
+// to the DefaultLocale.
+func TestCatalogs(t *testing.T) {
+	t.Parallel()
+
+	want := keys(catalogs[DefaultLocale])
+	for _, locale := range Supported() {
+		assert.Equal(t, want, keys(catalogs[locale]), "messages of locale %q", locale)
+	}
+}
+
+// TestCatalogs_TemplateKeys ensures that all messages used in the templates exist.
+func TestCatalogs_TemplateKeys(t *testing.T) {
+	t.Parallel()
+
+	re := regexp.MustCompile(`\bt "([a-z_.]+)"`)
+	err := filepath.WalkDir("../../web/templates", func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		for _, m := range re.FindAllStringSubmatch(string(content), -1) {
+			_, ok := catalogs[DefaultLocale][m[1]]
+			assert.True(t, ok, "message %q used in %s is missing", m[1], path)
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+}
+
+func keys(c catalog) []string {
+	res := make([]string, 0, len(c))
+	for k := range c {
+		res = append(res, k)
+	}
+	sort.Strings(res)
+	return res
+}
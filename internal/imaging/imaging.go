@@ -0,0 +1,165 @@
+// Package imaging prepares uploaded images for the web. Each image is decoded, rotated upright,
+// scaled down to a fixed set of widths and encoded as JPEG and, if the binary is built with cgo, WebP.
+// Because the images are re-encoded, any metadata (EXIF, GPS positions, etc.) of the original file is stripped.
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io"
+
+	// Register the supported input formats.
+	_ "image/gif"
+	_ "image/png"
+
+	_ "golang.org/x/image/webp"
+
+	"golang.org/x/image/draw"
+)
+
+// MaxPixels is the maximum number of pixels that an uploaded image may have.
+// It protects us against decompression bombs, where a small file expands to gigabytes in memory.
+const MaxPixels = 50_000_000
+
+// jpegQuality is used for all JPEG variants.
+const jpegQuality = 85
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported image format")
+	ErrTooLarge          = errors.New("image dimensions are too large")
+)
+
+// Format is the file format of an image variant.
+type Format string
+
+const (
+	JPEG Format = "jpeg"
+	WebP Format = "webp"
+)
+
+// Extension returns the file extension for the format, without a leading dot.
+func (f Format) Extension() string {
+	if f == JPEG {
+		return "jpg"
+	}
+	return string(f)
+}
+
+// ContentType returns the MIME type of the format.
+func (f Format) ContentType() string {
+	return "image/" + string(f)
+}
+
+// standardWidths are the widths that are generated for each image. The smallest one is used as thumbnail,
+// the largest one is the maximum size that we store at all.
+var standardWidths = []int{320, 800, 1600}
+
+// ThumbnailWidth is the width of the smallest variant.
+var ThumbnailWidth = standardWidths[0]
+
+// Widths returns all variant widths for an image that is width pixels wide. Images are never upscaled,
+// so smaller images have fewer variants, with the original width as the largest one.
+func Widths(width int) []int {
+	var res []int
+	for _, w := range standardWidths {
+		if w >= width {
+			return append(res, width)
+		}
+		res = append(res, w)
+	}
+	return res
+}
+
+// Variant is a single encoded version of an image.
+type Variant struct {
+	Width  int
+	Height int
+	Format Format
+	Data   []byte
+}
+
+// Result contains the dimensions of the processed image and all variants.
+type Result struct {
+	// Width and Height are the dimensions of the largest variant.
+	Width    int
+	Height   int
+	Variants []Variant
+}
+
+// Process reads an image from r and generates all variants for it.
+// If the image can't be decoded, [ErrUnsupportedFormat] is returned.
+func Process(r io.Reader) (*Result, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("reading image: %w", err)
+	}
+
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > MaxPixels {
+		return nil, fmt.Errorf("%w: %dx%d", ErrTooLarge, cfg.Width, cfg.Height)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, err)
+	}
+	if format == "jpeg" {
+		img = applyOrientation(img, jpegOrientation(data))
+	}
+
+	bounds := img.Bounds()
+	res := &Result{}
+	for _, width := range Widths(bounds.Dx()) {
+		height := scaledHeight(bounds.Dx(), bounds.Dy(), width)
+		scaled := image.NewNRGBA(image.Rect(0, 0, width, height))
+		draw.CatmullRom.Scale(scaled, scaled.Rect, img, bounds, draw.Src, nil)
+
+		for _, f := range Formats {
+			var buf bytes.Buffer
+			if err := encode(&buf, scaled, f); err != nil {
+				return nil, fmt.Errorf("encoding %dpx variant as %s: %w", width, f, err)
+			}
+			res.Variants = append(res.Variants, Variant{
+				Width:  width,
+				Height: height,
+				Format: f,
+				Data:   buf.Bytes(),
+			})
+		}
+
+		res.Width, res.Height = width, height
+	}
+
+	return res, nil
+}
+
+func encode(w io.Writer, img *image.NRGBA, f Format) error {
+	switch f {
+	case JPEG:
+		// JPEG has no transparency, so transparent areas are put on a white background.
+		// Otherwise, they'd turn black.
+		flattened := image.NewRGBA(img.Rect)
+		draw.Draw(flattened, flattened.Rect, image.NewUniform(color.White), image.Point{}, draw.Src)
+		draw.Draw(flattened, flattened.Rect, img, image.Point{}, draw.Over)
+		return jpeg.Encode(w, flattened, &jpeg.Options{Quality: jpegQuality})
+	case WebP:
+		return EncodeWebP(w, img)
+	}
+	return fmt.Errorf("unknown format %q", f)
+}
+
+// scaledHeight returns the height for an image that's scaled from width to newWidth, keeping the aspect ratio.
+func scaledHeight(width, height, newWidth int) int {
+	h := (height*newWidth + width/2) / width
+	if h < 1 {
+		return 1
+	}
+	return h
+}
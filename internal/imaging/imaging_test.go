@@ -0,0 +1,145 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+)
+
+// photo returns an image that compresses like a photo, with smooth gradients, edges and some sensor noise.
+func photo(width, height int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	rnd := rand.New(rand.NewSource(1)) //nolint:gosec // deterministic test data
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			fx, fy := float64(x)/float64(width), float64(y)/float64(height)
+			r := 120 + 80*math.Sin(fx*7) + 30*fy
+			g := 100 + 60*math.Cos(fy*5+fx*3)
+			b := 90 + 70*fx*fy
+			if (x-width/2)*(x-width/2)+(y-height/2)*(y-height/2) < height*height/16 {
+				// A plate in the middle of the table.
+				r, g, b = 230-20*fy, 225-20*fy, 215-20*fy
+			}
+			n := rnd.Float64()*12 - 6
+			img.SetNRGBA(x, y, color.NRGBA{R: clampByte(r + n), G: clampByte(g + n), B: clampByte(b + n), A: 0xff})
+		}
+	}
+	return img
+}
+
+func clampByte(v float64) uint8 {
+	return uint8(math.Max(0, math.Min(255, v)))
+}
+
+func TestWidths(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, []int{200}, Widths(200))
+	assert.Equal(t, []int{320}, Widths(320))
+	assert.Equal(t, []int{320, 500}, Widths(500))
+	assert.Equal(t, []int{320, 800, 1600}, Widths(1600))
+	assert.Equal(t, []int{320, 800, 1600}, Widths(4000))
+}
+
+func TestProcess(t *testing.T) {
+	t.Parallel()
+
+	t.Run("variants are generated", func(t *testing.T) {
+		var buf bytes.Buffer
+		assert.NoError(t, png.Encode(&buf, image.NewNRGBA(image.Rect(0, 0, 2000, 1000))))
+
+		res, err := Process(&buf)
+		assert.NoError(t, err)
+		assert.Equal(t, 1600, res.Width)
+		assert.Equal(t, 800, res.Height)
+		assert.Equal(t, 3*len(Formats), len(res.Variants))
+
+		for _, v := range res.Variants {
+			cfg, format, err := image.DecodeConfig(bytes.NewReader(v.Data))
+			assert.NoError(t, err)
+			assert.Equal(t, string(v.Format), format)
+			assert.Equal(t, v.Width, cfg.Width)
+			assert.Equal(t, v.Height, cfg.Height)
+		}
+	})
+	t.Run("invalid data is rejected", func(t *testing.T) {
+		_, err := Process(bytes.NewReader([]byte("definitely not an image")))
+		assert.True(t, errors.Is(err, ErrUnsupportedFormat), "expected ErrUnsupportedFormat, got: %v", err)
+	})
+	t.Run("orientation is applied and metadata is stripped", func(t *testing.T) {
+		data := jpegWithOrientation(t, 60, 20, 6)
+
+		res, err := Process(bytes.NewReader(data))
+		assert.NoError(t, err)
+		assert.Equal(t, 20, res.Width)
+		assert.Equal(t, 60, res.Height)
+
+		for _, v := range res.Variants {
+			assert.False(t, bytes.Contains(v.Data, []byte("Exif")), "variant %s should not contain EXIF data", v.Format)
+		}
+	})
+}
+
+func TestJPEGOrientation(t *testing.T) {
+	t.Parallel()
+
+	for orientation := 1; orientation <= 8; orientation++ {
+		assert.Equal(t, orientation, jpegOrientation(jpegWithOrientation(t, 4, 2, orientation)))
+	}
+	assert.Equal(t, 1, jpegOrientation([]byte{0xff, 0xd8, 0xff}))
+	assert.Equal(t, 1, jpegOrientation(nil))
+}
+
+func TestApplyOrientation(t *testing.T) {
+	t.Parallel()
+
+	// A 2x1 image with a red pixel on the left and a blue one on the right.
+	red, blue := color.NRGBA{R: 0xff, A: 0xff}, color.NRGBA{B: 0xff, A: 0xff}
+	src := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	src.SetNRGBA(0, 0, red)
+	src.SetNRGBA(1, 0, blue)
+
+	// Orientation 6 means the camera was rotated, so the left pixel must end up on top.
+	rotated := applyOrientation(src, 6).(*image.NRGBA)
+	assert.Equal(t, image.Rect(0, 0, 1, 2), rotated.Rect)
+	assert.Equal(t, red, rotated.NRGBAAt(0, 0))
+	assert.Equal(t, blue, rotated.NRGBAAt(0, 1))
+
+	mirrored := applyOrientation(src, 2).(*image.NRGBA)
+	assert.Equal(t, blue, mirrored.NRGBAAt(0, 0))
+	assert.Equal(t, red, mirrored.NRGBAAt(1, 0))
+}
+
+// jpegWithOrientation returns a JPEG file with an EXIF segment that contains the given orientation.
+func jpegWithOrientation(t *testing.T, width, height, orientation int) []byte {
+	t.Helper()
+
+	var img bytes.Buffer
+	assert.NoError(t, jpeg.Encode(&img, image.NewGray(image.Rect(0, 0, width, height)), nil))
+
+	// A minimal little-endian TIFF structure with a single IFD entry.
+	tiff := []byte("II*\x00\x08\x00\x00\x00")
+	tiff = binary.LittleEndian.AppendUint16(tiff, 1)
+	tiff = binary.LittleEndian.AppendUint16(tiff, exifOrientationTag)
+	tiff = binary.LittleEndian.AppendUint16(tiff, 3) // SHORT
+	tiff = binary.LittleEndian.AppendUint32(tiff, 1)
+	tiff = binary.LittleEndian.AppendUint16(tiff, uint16(orientation))
+	tiff = append(tiff, 0, 0, 0, 0, 0, 0)
+
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	app1 := []byte{0xff, 0xe1}
+	app1 = binary.BigEndian.AppendUint16(app1, uint16(len(segment)+2))
+	app1 = append(app1, segment...)
+
+	data := img.Bytes()
+	return append(append(append([]byte{}, data[:2]...), app1...), data[2:]...)
+}
@@ -0,0 +1,43 @@
+//go:build cgo
+
+package imaging
+
+import (
+	"image"
+	"image/draw"
+	"io"
+
+	"github.com/chai2010/webp"
+)
+
+// webpQuality is used for all WebP variants. It's lower than jpegQuality, because WebP looks as good as
+// JPEG at a lower quality, which is why its files are smaller.
+const webpQuality = 75
+
+// Formats contains all formats that are generated for each variant, in the order of preference.
+var Formats = []Format{WebP, JPEG}
+
+// EncodeWebP writes img as lossy WebP to w, encoded by libwebp. Transparency is kept, but any metadata of
+// the source image is not part of the output.
+func EncodeWebP(w io.Writer, img image.Image) error {
+	nrgba, ok := img.(*image.NRGBA)
+	if !ok {
+		nrgba = image.NewNRGBA(img.Bounds())
+		draw.Draw(nrgba, nrgba.Rect, img, nrgba.Rect.Min, draw.Src)
+	}
+
+	var data []byte
+	var err error
+	if nrgba.Opaque() {
+		// Without an alpha channel, libwebp omits it from the file.
+		data, err = webp.EncodeRGB(nrgba, webpQuality)
+	} else {
+		// libwebp expects the colors without premultiplied alpha, which is the layout of NRGBA.
+		data, err = webp.EncodeRGBA(&image.RGBA{Pix: nrgba.Pix, Stride: nrgba.Stride, Rect: nrgba.Rect}, webpQuality)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
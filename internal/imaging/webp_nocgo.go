@@ -0,0 +1,21 @@
+//go:build !cgo
+
+package imaging
+
+import (
+	"errors"
+	"image"
+	"io"
+)
+
+// Formats contains all formats that are generated for each variant, in the order of preference.
+// The WebP encoder needs cgo, so binaries built without it only generate JPEG variants.
+var Formats = []Format{JPEG}
+
+// ErrWebPUnsupported is returned by [EncodeWebP] if the binary is built without cgo.
+var ErrWebPUnsupported = errors.New("WebP encoding requires a build with cgo")
+
+// EncodeWebP always returns [ErrWebPUnsupported], because libwebp can't be used without cgo.
+func EncodeWebP(io.Writer, image.Image) error {
+	return ErrWebPUnsupported
+}
@@ -0,0 +1,88 @@
+//go:build cgo
+
+package imaging
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math/rand"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+	"golang.org/x/image/webp"
+)
+
+func TestEncodeWebP(t *testing.T) {
+	t.Parallel()
+
+	noise := image.NewNRGBA(image.Rect(0, 0, 40, 33))
+	rnd := rand.New(rand.NewSource(1)) //nolint:gosec // deterministic test data
+	_, _ = rnd.Read(noise.Pix)
+
+	single := image.NewNRGBA(image.Rect(0, 0, 1, 1))
+	single.SetNRGBA(0, 0, color.NRGBA{R: 200, G: 100, B: 50, A: 0xff})
+
+	transparent := image.NewNRGBA(image.Rect(0, 0, 16, 16))
+	draw.Draw(transparent, image.Rect(0, 0, 8, 16), image.NewUniform(color.NRGBA{R: 0xff, A: 0xff}), image.Point{}, draw.Src)
+
+	tests := []struct {
+		name string
+		img  *image.NRGBA
+	}{
+		{name: "photo", img: photo(97, 61)},
+		{name: "noise with transparency", img: noise},
+		{name: "single pixel", img: single},
+		{name: "half transparent", img: transparent},
+		{name: "uniform", img: image.NewNRGBA(image.Rect(0, 0, 17, 3))},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+			assert.NoError(t, EncodeWebP(&buf, tt.img))
+
+			decoded, err := webp.Decode(&buf)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.img.Rect, decoded.Bounds())
+		})
+	}
+
+	t.Run("transparency is kept", func(t *testing.T) {
+		t.Parallel()
+
+		var buf bytes.Buffer
+		assert.NoError(t, EncodeWebP(&buf, transparent))
+		decoded, err := webp.Decode(&buf)
+		assert.NoError(t, err)
+		_, _, _, left := decoded.At(2, 8).RGBA()
+		_, _, _, right := decoded.At(13, 8).RGBA()
+		assert.Equal(t, uint32(0xffff), left)
+		assert.Equal(t, uint32(0), right)
+	})
+}
+
+func TestProcess_WebPIsSmallerThanJPEG(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, photo(800, 600)))
+	res, err := Process(&buf)
+	assert.NoError(t, err)
+
+	sizes := make(map[Format]map[int]int)
+	for _, v := range res.Variants {
+		if sizes[v.Format] == nil {
+			sizes[v.Format] = make(map[int]int)
+		}
+		sizes[v.Format][v.Width] = len(v.Data)
+	}
+	for width, jpegSize := range sizes[JPEG] {
+		webpSize := sizes[WebP][width]
+		assert.True(t, webpSize > 0 && webpSize <= jpegSize, "%dpx: WebP has %d bytes, JPEG %d bytes", width, webpSize, jpegSize)
+	}
+}
@@ -0,0 +1,102 @@
+// Package mail sends emails to users, e.g. to verify their email address. Emails are plain text
+// and are either delivered with SMTP or, during the development, written to the log.
+package mail
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Message is a plain text email to a single recipient.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender is implemented by all drivers.
+type Sender interface {
+	// Send delivers the message. It returns once the message is accepted, not when it's received.
+	Send(ctx context.Context, msg Message) error
+}
+
+// Ensure that all implementations satisfy the interface.
+var (
+	_ Sender = (*SMTP)(nil)
+	_ Sender = (*Log)(nil)
+)
+
+// Config contains the settings for sending emails.
+type Config struct {
+	// Driver is either "log" (default) or "smtp".
+	Driver string     `toml:"driver"`
+	SMTP   SMTPConfig `toml:"smtp"`
+}
+
+// Log writes emails to the log instead of sending them. It's meant for the development,
+// so that links in emails can be opened without a mail server.
+type Log struct {
+	logger *zap.Logger
+}
+
+// NewLog returns a driver that writes to logger.
+func NewLog(logger *zap.Logger) *Log {
+	return &Log{logger: logger}
+}
+
+func (l *Log) Send(_ context.Context, msg Message) error {
+	l.logger.Info("email is not sent, because the log driver is configured",
+		zap.String("to", msg.To),
+		zap.String("subject", msg.Subject),
+		zap.String("body", msg.Body),
+	)
+	return nil
+}
+
+// compose returns the message in the Internet Message Format of RFC 5322.
+func compose(from *mail.Address, msg Message, now time.Time) ([]byte, error) {
+	to, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return nil, fmt.Errorf("invalid recipient %q: %w", msg.To, err)
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, fmt.Errorf("generating message ID: %w", err)
+	}
+	_, domain, _ := strings.Cut(from.Address, "@")
+
+	var b strings.Builder
+	header := func(key, value string) {
+		b.WriteString(key + ": " + value + "\r\n")
+	}
+	header("From", from.String())
+	header("To", to.String())
+	header("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	header("Date", now.Format(time.RFC1123Z))
+	header("Message-ID", "<"+hex.EncodeToString(id)+"@"+domain+">")
+	header("MIME-Version", "1.0")
+	header("Content-Type", "text/plain; charset=utf-8")
+	header("Content-Transfer-Encoding", "quoted-printable")
+	b.WriteString("\r\n")
+
+	// Quoted-printable keeps the lines short and the body ASCII, which every server accepts.
+	w := quotedprintable.NewWriter(&b)
+	if _, err := w.Write([]byte(msg.Body)); err != nil {
+		return nil, fmt.Errorf("encoding body: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("encoding body: %w", err)
+	}
+
+	return []byte(b.String()), nil
+}
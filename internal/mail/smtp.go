@@ -0,0 +1,139 @@
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+// SMTPConfig contains the settings for a mail server.
+type SMTPConfig struct {
+	Host     string `toml:"host"`
+	Port     int    `toml:"port"` // defaults to 587, or 465 for implicit TLS
+	Username string `toml:"username"`
+	Password string `toml:"password" secret:"true"`
+
+	// From is the sender of all emails, e.g. "Mahlzeit <mahlzeit@example.com>".
+	From string `toml:"from"`
+
+	// TLS is either "starttls" (default), "tls" for implicit TLS or "none". Without TLS, the
+	// password is only sent to a server on localhost.
+	TLS string `toml:"tls"`
+}
+
+// SMTP delivers emails to a mail server, which sends them on.
+type SMTP struct {
+	cfg       SMTPConfig
+	from      *mail.Address
+	tlsConfig *tls.Config
+	now       func() time.Time
+}
+
+// NewSMTP returns a driver for the given configuration.
+func NewSMTP(cfg SMTPConfig) (*SMTP, error) {
+	if cfg.Host == "" {
+		return nil, fmt.Errorf("no SMTP host configured")
+	}
+	from, err := mail.ParseAddress(cfg.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sender address %q: %w", cfg.From, err)
+	}
+
+	switch cfg.TLS {
+	case "":
+		cfg.TLS = "starttls"
+	case "starttls", "tls", "none":
+	default:
+		return nil, fmt.Errorf("unknown SMTP TLS mode %q", cfg.TLS)
+	}
+	if cfg.Port == 0 {
+		cfg.Port = 587
+		if cfg.TLS == "tls" {
+			cfg.Port = 465
+		}
+	}
+
+	return &SMTP{
+		cfg:       cfg,
+		from:      from,
+		tlsConfig: &tls.Config{ServerName: cfg.Host, MinVersion: tls.VersionTLS12},
+		now:       time.Now,
+	}, nil
+}
+
+func (s *SMTP) Send(ctx context.Context, msg Message) error {
+	data, err := compose(s.from, msg, s.now())
+	if err != nil {
+		return err
+	}
+
+	c, err := s.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if s.cfg.TLS == "starttls" {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("the SMTP server %s doesn't support STARTTLS", s.cfg.Host)
+		}
+		if err := c.StartTLS(s.tlsConfig); err != nil {
+			return fmt.Errorf("starting TLS: %w", err)
+		}
+	}
+	if s.cfg.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.cfg.Host)); err != nil {
+			return fmt.Errorf("authenticating at SMTP server: %w", err)
+		}
+	}
+
+	if err := c.Mail(s.from.Address); err != nil {
+		return fmt.Errorf("setting sender: %w", err)
+	}
+	if err := c.Rcpt(msg.To); err != nil {
+		return fmt.Errorf("setting recipient: %w", err)
+	}
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("starting data: %w", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("writing message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("finishing message: %w", err)
+	}
+	return c.Quit()
+}
+
+// dial connects to the server. The deadline of ctx applies to the whole conversation.
+func (s *SMTP) dial(ctx context.Context) (*smtp.Client, error) {
+	addr := net.JoinHostPort(s.cfg.Host, strconv.Itoa(s.cfg.Port))
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to SMTP server: %w", err)
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(time.Minute)
+	}
+	_ = conn.SetDeadline(deadline)
+
+	if s.cfg.TLS == "tls" {
+		conn = tls.Client(conn, s.tlsConfig)
+	}
+
+	c, err := smtp.NewClient(conn, s.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("greeting SMTP server: %w", err)
+	}
+	return c, nil
+}
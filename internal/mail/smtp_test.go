@@ -0,0 +1,185 @@
+package mail
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alecthomas/assert/v2"
+)
+
+// fakeSMTPServer is a stand-in for a mail server. It accepts a single conversation and records the envelope and data.
+type fakeSMTPServer struct {
+	addr       *net.TCPAddr
+	extensions []string
+	done       chan fakeDelivery
+}
+
+type fakeDelivery struct {
+	auth string
+	from string
+	to   []string
+	data string
+}
+
+func newFakeSMTPServer(t *testing.T, extensions ...string) *fakeSMTPServer {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+
+	s := &fakeSMTPServer{addr: l.Addr().(*net.TCPAddr), extensions: extensions, done: make(chan fakeDelivery, 1)}
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		s.serve(textproto.NewConn(conn))
+	}()
+	return s
+}
+
+func (s *fakeSMTPServer) serve(c *textproto.Conn) {
+	var d fakeDelivery
+	_ = c.PrintfLine("220 localhost ESMTP fake")
+	for {
+		line, err := c.ReadLine()
+		if err != nil {
+			return
+		}
+		cmd, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(cmd) {
+		case "EHLO":
+			lines := append([]string{"localhost"}, s.extensions...)
+			for i, l := range lines {
+				sep := "-"
+				if i == len(lines)-1 {
+					sep = " "
+				}
+				_ = c.PrintfLine("250%s%s", sep, l)
+			}
+		case "AUTH":
+			d.auth = arg
+			_ = c.PrintfLine("235 authenticated")
+		case "MAIL":
+			d.from = arg
+			_ = c.PrintfLine("250 ok")
+		case "RCPT":
+			d.to = append(d.to, arg)
+			_ = c.PrintfLine("250 ok")
+		case "DATA":
+			_ = c.PrintfLine("354 go ahead")
+			data, err := io.ReadAll(c.DotReader())
+			if err != nil {
+				return
+			}
+			d.data = string(data)
+			_ = c.PrintfLine("250 queued")
+		case "QUIT":
+			_ = c.PrintfLine("221 bye")
+			s.done <- d
+			return
+		default:
+			_ = c.PrintfLine("502 not implemented")
+		}
+	}
+}
+
+func TestSMTP_Send(t *testing.T) {
+	t.Parallel()
+
+	server := newFakeSMTPServer(t, "AUTH PLAIN")
+	s, err := NewSMTP(SMTPConfig{
+		Host:     server.addr.IP.String(),
+		Port:     server.addr.Port,
+		Username: "mahlzeit",
+		Password: "secret",
+		From:     "Mahlzeit <mahlzeit@example.com>",
+		TLS:      "none",
+	})
+	assert.NoError(t, err)
+	s.now = func() time.Time { return time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC) }
+
+	err = s.Send(context.Background(), Message{
+		To:      "alice@example.com",
+		Subject: "Bestätige deine E-Mail-Adresse",
+		Body:    "Hallo Alice,\n\nbitte öffne den folgenden Link: https://mahlzeit.example.com/verify-email?token=abc\n",
+	})
+	assert.NoError(t, err)
+
+	d := <-server.done
+	assert.Equal(t, "PLAIN "+base64.StdEncoding.EncodeToString([]byte("\x00mahlzeit\x00secret")), d.auth)
+	assert.Equal(t, "FROM:<mahlzeit@example.com>", d.from)
+	assert.Equal(t, []string{"TO:<alice@example.com>"}, d.to)
+
+	msg, err := mail.ReadMessage(strings.NewReader(d.data))
+	assert.NoError(t, err)
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	assert.NoError(t, err)
+	assert.Equal(t, "Bestätige deine E-Mail-Adresse", subject)
+	assert.Equal(t, `"Mahlzeit" <mahlzeit@example.com>`, msg.Header.Get("From"))
+	assert.Equal(t, "<alice@example.com>", msg.Header.Get("To"))
+	assert.Equal(t, "Mon, 19 Oct 2026 12:00:00 +0000", msg.Header.Get("Date"))
+
+	body, err := io.ReadAll(quotedprintable.NewReader(msg.Body))
+	assert.NoError(t, err)
+	// The line endings are CRLF on the wire, but the reader of the fake server converts them back.
+	assert.Equal(t, "Hallo Alice,\n\nbitte öffne den folgenden Link: https://mahlzeit.example.com/verify-email?token=abc\n", string(body))
+}
+
+func TestSMTP_SendRequiresStartTLS(t *testing.T) {
+	t.Parallel()
+
+	server := newFakeSMTPServer(t)
+	s, err := NewSMTP(SMTPConfig{
+		Host: server.addr.IP.String(),
+		Port: server.addr.Port,
+		From: "mahlzeit@example.com",
+	})
+	assert.NoError(t, err)
+
+	err = s.Send(context.Background(), Message{To: "alice@example.com", Subject: "Test", Body: "Test"})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "doesn't support STARTTLS")
+}
+
+func TestNewSMTP(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		cfg      SMTPConfig
+		wantErr  bool
+		wantPort int
+	}{
+		{name: "defaults", cfg: SMTPConfig{Host: "mail.example.com", From: "a@example.com"}, wantPort: 587},
+		{name: "implicit TLS", cfg: SMTPConfig{Host: "mail.example.com", From: "a@example.com", TLS: "tls"}, wantPort: 465},
+		{name: "without host", cfg: SMTPConfig{From: "a@example.com"}, wantErr: true},
+		{name: "invalid sender", cfg: SMTPConfig{Host: "mail.example.com", From: "mahlzeit"}, wantErr: true},
+		{name: "unknown TLS mode", cfg: SMTPConfig{Host: "mail.example.com", From: "a@example.com", TLS: "ssl"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			s, err := NewSMTP(tt.cfg)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantPort, s.cfg.Port)
+		})
+	}
+}
@@ -0,0 +1,186 @@
+// Package metrics provides the metrics of the application for Prometheus.
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"codeberg.org/mahlzeit/mahlzeit/db/queries"
+	"codeberg.org/mahlzeit/mahlzeit/internal/templates"
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.uber.org/zap"
+)
+
+// Metrics are the metrics of the application. The methods ObserveRequest and ObserveTemplate can be called on
+// nil, in which case nothing is recorded, e.g. if the metrics are disabled.
+type Metrics struct {
+	Registry *prometheus.Registry
+
+	requests         *prometheus.CounterVec
+	requestDuration  *prometheus.HistogramVec
+	templateDuration *prometheus.HistogramVec
+	templateErrors   *prometheus.CounterVec
+}
+
+// New returns the metrics of requests, templates and the Go runtime. The ones of the database are added by
+// [Metrics.RegisterDB].
+func New() *Metrics {
+	m := &Metrics{
+		Registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "mahlzeit_http_requests_total",
+			Help: "Number of HTTP requests by method, route pattern and status code.",
+		}, []string{"method", "route", "status"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name: "mahlzeit_http_request_duration_seconds",
+			Help: "Duration of HTTP requests by method and route pattern.",
+		}, []string{"method", "route"}),
+		templateDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name: "mahlzeit_template_render_duration_seconds",
+			Help: "Duration of the rendering of templates by the page or mail.",
+		}, []string{"template"}),
+		templateErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "mahlzeit_template_errors_total",
+			Help: "Number of templates that failed to render by the page or mail.",
+		}, []string{"template"}),
+	}
+	m.Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requests,
+		m.requestDuration,
+		m.templateDuration,
+		m.templateErrors,
+	)
+	return m
+}
+
+// Handler returns the handler of the metrics endpoint. Metrics that can't be collected, e.g. because the database
+// is unavailable, are logged and omitted.
+func (m *Metrics) Handler(logger *zap.Logger) http.Handler {
+	// NewStdLogAt only fails for invalid levels.
+	errorLog, _ := zap.NewStdLogAt(logger, zap.WarnLevel)
+	return promhttp.HandlerFor(m.Registry, promhttp.HandlerOpts{
+		ErrorLog:      errorLog,
+		ErrorHandling: promhttp.ContinueOnError,
+	})
+}
+
+// ObserveRequest records a finished request. It's labeled by the route pattern, e.g. "/recipes/{id}/", instead of
+// the path, so that the number of series is limited. Requests without a route, e.g. 404s, are labeled "unmatched".
+func (m *Metrics) ObserveRequest(r *http.Request, status int, duration time.Duration) {
+	if m == nil {
+		return
+	}
+	route := "unmatched"
+	if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+		route = rctx.RoutePattern()
+	}
+	if status == 0 {
+		// Nothing was written, which net/http answers with 200 OK.
+		status = http.StatusOK
+	}
+	m.requests.WithLabelValues(r.Method, route, strconv.Itoa(status)).Inc()
+	m.requestDuration.WithLabelValues(r.Method, route).Observe(duration.Seconds())
+}
+
+// ObserveTemplate records the rendering of a template, see [templates.Observer].
+func (m *Metrics) ObserveTemplate(name string, duration time.Duration, err error) {
+	if m == nil {
+		return
+	}
+	m.templateDuration.WithLabelValues(name).Observe(duration.Seconds())
+	var renderErr templates.TemplateRenderingError
+	if errors.As(err, &renderErr) {
+		m.templateErrors.WithLabelValues(renderErr.TemplateName).Inc()
+	}
+}
+
+// RegisterDB adds the statistics of the connection pool and the number of recipes, users and households, which
+// are queried on every scrape.
+func (m *Metrics) RegisterDB(pool *pgxpool.Pool, q *queries.Queries) {
+	m.Registry.MustRegister(poolCollector{pool: pool}, statisticsCollector{queries: q})
+}
+
+var (
+	poolMaxConns = prometheus.NewDesc("mahlzeit_db_connections_max",
+		"Maximum number of connections of the pool.", nil, nil)
+	poolTotalConns = prometheus.NewDesc("mahlzeit_db_connections_total",
+		"Number of open connections of the pool.", nil, nil)
+	poolAcquiredConns = prometheus.NewDesc("mahlzeit_db_connections_acquired",
+		"Number of connections that are currently in use.", nil, nil)
+	poolIdleConns = prometheus.NewDesc("mahlzeit_db_connections_idle",
+		"Number of idle connections of the pool.", nil, nil)
+	poolAcquires = prometheus.NewDesc("mahlzeit_db_acquires_total",
+		"Number of connections acquired from the pool.", nil, nil)
+	poolAcquireDuration = prometheus.NewDesc("mahlzeit_db_acquire_duration_seconds_total",
+		"Total time spent waiting for connections.", nil, nil)
+	poolEmptyAcquires = prometheus.NewDesc("mahlzeit_db_acquires_empty_total",
+		"Number of acquires that had to wait for a connection.", nil, nil)
+	poolCanceledAcquires = prometheus.NewDesc("mahlzeit_db_acquires_canceled_total",
+		"Number of acquires that were canceled.", nil, nil)
+)
+
+// poolCollector collects the statistics of the database pool.
+type poolCollector struct {
+	pool *pgxpool.Pool
+}
+
+func (c poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	prometheus.DescribeByCollect(c, ch)
+}
+
+func (c poolCollector) Collect(ch chan<- prometheus.Metric) {
+	s := c.pool.Stat()
+	ch <- prometheus.MustNewConstMetric(poolMaxConns, prometheus.GaugeValue, float64(s.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(poolTotalConns, prometheus.GaugeValue, float64(s.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(poolAcquiredConns, prometheus.GaugeValue, float64(s.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(poolIdleConns, prometheus.GaugeValue, float64(s.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(poolAcquires, prometheus.CounterValue, float64(s.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(poolAcquireDuration, prometheus.CounterValue, s.AcquireDuration().Seconds())
+	ch <- prometheus.MustNewConstMetric(poolEmptyAcquires, prometheus.CounterValue, float64(s.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(poolCanceledAcquires, prometheus.CounterValue, float64(s.CanceledAcquireCount()))
+}
+
+// statisticsTimeout limits the duration of the queries of a scrape, since the collectors don't get its context.
+const statisticsTimeout = 5 * time.Second
+
+// statisticsCollector collects the number of recipes, users and households.
+type statisticsCollector struct {
+	queries *queries.Queries
+}
+
+var statistics = []struct {
+	desc  *prometheus.Desc
+	count func(q *queries.Queries, ctx context.Context) (int64, error)
+}{
+	{prometheus.NewDesc("mahlzeit_recipes", "Number of recipes.", nil, nil), (*queries.Queries).CountRecipes},
+	{prometheus.NewDesc("mahlzeit_users", "Number of users.", nil, nil), (*queries.Queries).CountUsers},
+	{prometheus.NewDesc("mahlzeit_households", "Number of households.", nil, nil), (*queries.Queries).CountHouseholds},
+}
+
+func (c statisticsCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, s := range statistics {
+		ch <- s.desc
+	}
+}
+
+func (c statisticsCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), statisticsTimeout)
+	defer cancel()
+	for _, s := range statistics {
+		n, err := s.count(c.queries, ctx)
+		if err != nil {
+			ch <- prometheus.NewInvalidMetric(s.desc, err)
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(s.desc, prometheus.GaugeValue, float64(n))
+	}
+}
@@ -0,0 +1,51 @@
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"codeberg.org/mahlzeit/mahlzeit/internal/templates"
+	"github.com/alecthomas/assert/v2"
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.uber.org/zap"
+)
+
+func TestMetrics(t *testing.T) {
+	t.Parallel()
+
+	m := New()
+	router := chi.NewRouter()
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r)
+			m.ObserveRequest(r, 0, 20*time.Millisecond)
+		})
+	})
+	router.Get("/recipes/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/recipes/1", nil))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/recipes/2", nil))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/missing", nil))
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.requests.WithLabelValues("GET", "/recipes/{id}", "200")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.requests.WithLabelValues("GET", "unmatched", "200")))
+
+	m.ObserveTemplate("recipes/index.tmpl", time.Millisecond, nil)
+	m.ObserveTemplate("recipes/edit.tmpl", time.Millisecond, fmt.Errorf("wrapped: %w", templates.TemplateRenderingError{TemplateName: "recipes/edit.tmpl"}))
+	assert.Equal(t, 2, testutil.CollectAndCount(m.templateDuration))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.templateErrors.WithLabelValues("recipes/edit.tmpl")))
+	assert.Equal(t, 1, testutil.CollectAndCount(m.templateErrors))
+
+	rec := httptest.NewRecorder()
+	m.Handler(zap.NewNop()).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `mahlzeit_http_request_duration_seconds_bucket{method="GET",route="/recipes/{id}",le="0.025"} 2`)
+
+	// Without metrics, nothing is recorded.
+	var disabled *Metrics
+	disabled.ObserveRequest(httptest.NewRequest(http.MethodGet, "/", nil), http.StatusOK, time.Second)
+	disabled.ObserveTemplate("recipes/index.tmpl", time.Second, nil)
+}
@@ -0,0 +1,283 @@
+// Package migrate applies the migrations of the database. It's compatible with dbmate
+// (https://github.com/amacneil/dbmate): the files have the same format and the applied versions are
+// recorded in the same table, so that both can be used interchangeably.
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// lockID is the key of the advisory lock, which prevents that multiple instances migrate at the same time.
+const lockID int64 = 0x6d61686c7a656974 // "mahlzeit"
+
+var (
+	// The names of migrations start with their version, e.g. "20230215165148_initial.sql".
+	versionPattern = regexp.MustCompile(`^(\d+).*\.sql$`)
+	// The sections of a migration are started by markers like "-- migrate:up transaction:false".
+	markerPattern = regexp.MustCompile(`(?m)^--\s*migrate:(up|down)(.*)$`)
+)
+
+// Migration is a single migration file.
+type Migration struct {
+	Version string
+	Name    string
+	up      section
+	down    section
+}
+
+// section is the up or down part of a migration.
+type section struct {
+	sql         string
+	transaction bool
+}
+
+// State is the state of a migration in the database.
+type State struct {
+	Version string
+	Name    string // the file name, empty if the migration was applied but its file is missing
+	Applied bool
+}
+
+// Migrator applies the migrations to the database.
+type Migrator struct {
+	db         *pgxpool.Pool
+	migrations []Migration // sorted by version
+}
+
+// New returns a migrator for the migrations, which are the "*.sql" files in the root of files.
+func New(db *pgxpool.Pool, files fs.FS) (*Migrator, error) {
+	names, err := fs.Glob(files, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := make([]Migration, 0, len(names))
+	for _, name := range names {
+		content, err := fs.ReadFile(files, name)
+		if err != nil {
+			return nil, err
+		}
+		m, err := parseMigration(name, string(content))
+		if err != nil {
+			return nil, fmt.Errorf("parsing migration %s: %w", name, err)
+		}
+		migrations = append(migrations, m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// parseMigration parses a migration in the format of dbmate.
+func parseMigration(name, content string) (Migration, error) {
+	match := versionPattern.FindStringSubmatch(path.Base(name))
+	if match == nil {
+		return Migration{}, errors.New("the name must start with the version")
+	}
+
+	m := Migration{Version: match[1], Name: path.Base(name)}
+	markers := markerPattern.FindAllStringSubmatchIndex(content, -1)
+	if len(markers) == 0 || content[markers[0][2]:markers[0][3]] != "up" {
+		return Migration{}, errors.New("the migration must start with \"-- migrate:up\"")
+	}
+	for i, marker := range markers {
+		end := len(content)
+		if i+1 < len(markers) {
+			end = markers[i+1][0]
+		}
+		s := section{sql: strings.TrimSpace(content[marker[1]:end]), transaction: true}
+		for _, option := range strings.Fields(content[marker[4]:marker[5]]) {
+			switch option {
+			case "transaction:true":
+			case "transaction:false":
+				s.transaction = false
+			default:
+				return Migration{}, fmt.Errorf("unknown option %q", option)
+			}
+		}
+
+		if content[marker[2]:marker[3]] == "up" {
+			m.up = s
+		} else {
+			m.down = s
+		}
+	}
+	return m, nil
+}
+
+// Up applies all pending migrations and returns them.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+	err := m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		versions, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, migration := range m.migrations {
+			if versions[migration.Version] {
+				continue
+			}
+			if err := apply(ctx, conn, migration.up, "insert into schema_migrations (version) values ($1)", migration.Version); err != nil {
+				return fmt.Errorf("applying %s: %w", migration.Name, err)
+			}
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down rolls back the most recently applied migration and returns it.
+func (m *Migrator) Down(ctx context.Context) (Migration, error) {
+	var rolledBack Migration
+	err := m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		var version string
+		err := conn.QueryRow(ctx, "select version from schema_migrations order by version desc limit 1").Scan(&version)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errors.New("no migration has been applied")
+		} else if err != nil {
+			return fmt.Errorf("querying latest version: %w", err)
+		}
+
+		migration, ok := m.find(version)
+		if !ok {
+			return fmt.Errorf("no migration found for the latest version %s", version)
+		}
+		if err := apply(ctx, conn, migration.down, "delete from schema_migrations where version = $1", version); err != nil {
+			return fmt.Errorf("rolling back %s: %w", migration.Name, err)
+		}
+		rolledBack = migration
+		return nil
+	})
+	return rolledBack, err
+}
+
+// Status returns the state of all migrations, sorted by their version. Applied versions without a migration
+// are included, too.
+func (m *Migrator) Status(ctx context.Context) ([]State, error) {
+	var states []State
+	err := m.db.AcquireFunc(ctx, func(conn *pgxpool.Conn) error {
+		versions, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, migration := range m.migrations {
+			states = append(states, State{Version: migration.Version, Name: migration.Name, Applied: versions[migration.Version]})
+			delete(versions, migration.Version)
+		}
+		for version := range versions {
+			states = append(states, State{Version: version, Applied: true})
+		}
+		return nil
+	})
+	sort.Slice(states, func(i, j int) bool { return states[i].Version < states[j].Version })
+	return states, err
+}
+
+// Pending returns the migrations that haven't been applied yet. Unlike [Migrator.Up], it doesn't wait for
+// the lock, so that it can be used to check whether the database is up-to-date.
+func (m *Migrator) Pending(ctx context.Context) ([]Migration, error) {
+	var pending []Migration
+	err := m.db.AcquireFunc(ctx, func(conn *pgxpool.Conn) error {
+		versions, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, migration := range m.migrations {
+			if !versions[migration.Version] {
+				pending = append(pending, migration)
+			}
+		}
+		return nil
+	})
+	return pending, err
+}
+
+// find returns the migration with the version.
+func (m *Migrator) find(version string) (Migration, bool) {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return migration, true
+		}
+	}
+	return Migration{}, false
+}
+
+// withLock calls f with a connection, which holds the advisory lock of the migrations.
+func (m *Migrator) withLock(ctx context.Context, f func(conn *pgxpool.Conn) error) error {
+	return m.db.AcquireFunc(ctx, func(conn *pgxpool.Conn) error {
+		if _, err := conn.Exec(ctx, "select pg_advisory_lock($1)", lockID); err != nil {
+			return fmt.Errorf("acquiring lock: %w", err)
+		}
+		defer func() {
+			// The lock belongs to the session, so the connection must not be reused if it's still held.
+			if _, err := conn.Exec(context.Background(), "select pg_advisory_unlock($1)", lockID); err != nil {
+				_ = conn.Conn().Close(context.Background())
+			}
+		}()
+
+		if _, err := conn.Exec(ctx, "create table if not exists schema_migrations (version varchar(255) primary key)"); err != nil {
+			return fmt.Errorf("creating table schema_migrations: %w", err)
+		}
+		return f(conn)
+	})
+}
+
+// appliedVersions returns the versions of all applied migrations.
+func appliedVersions(ctx context.Context, conn *pgxpool.Conn) (map[string]bool, error) {
+	var exists bool
+	if err := conn.QueryRow(ctx, "select to_regclass('schema_migrations') is not null").Scan(&exists); err != nil {
+		return nil, fmt.Errorf("querying table schema_migrations: %w", err)
+	}
+	versions := make(map[string]bool)
+	if !exists {
+		return versions, nil
+	}
+
+	rows, err := conn.Query(ctx, "select version from schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("querying applied versions: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var version string
+		if err := rows.Scan(&version); err != nil {
+			return nil, err
+		}
+		versions[version] = true
+	}
+	return versions, rows.Err()
+}
+
+// apply executes the section and records the change of the version with the statement, within a transaction
+// unless it's disabled for the section.
+func apply(ctx context.Context, conn *pgxpool.Conn, s section, record string, version string) error {
+	if !s.transaction {
+		if s.sql != "" {
+			if _, err := conn.Exec(ctx, s.sql); err != nil {
+				return err
+			}
+		}
+		_, err := conn.Exec(ctx, record, version)
+		return err
+	}
+
+	return conn.BeginFunc(ctx, func(tx pgx.Tx) error {
+		if s.sql != "" {
+			if _, err := tx.Exec(ctx, s.sql); err != nil {
+				return err
+			}
+		}
+		_, err := tx.Exec(ctx, record, version)
+		return err
+	})
+}
@@ -0,0 +1,166 @@
+package migrate
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"codeberg.org/mahlzeit/mahlzeit/db"
+	"codeberg.org/mahlzeit/mahlzeit/internal/testhelper"
+	"github.com/alecthomas/assert/v2"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+func TestParseMigration(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		content string
+		want    Migration
+		wantErr string
+	}{
+		{
+			name:    "20230215165148_initial.sql",
+			content: "-- migrate:up\ncreate table a (id int);\n\n-- migrate:down\ndrop table a;\n",
+			want: Migration{
+				Version: "20230215165148",
+				Name:    "20230215165148_initial.sql",
+				up:      section{sql: "create table a (id int);", transaction: true},
+				down:    section{sql: "drop table a;", transaction: true},
+			},
+		},
+		{
+			name:    "1_concurrent-index.sql",
+			content: "-- migrate:up transaction:false\ncreate index concurrently i on a (id);\n-- migrate:down\n",
+			want: Migration{
+				Version: "1",
+				Name:    "1_concurrent-index.sql",
+				up:      section{sql: "create index concurrently i on a (id);"},
+				down:    section{transaction: true},
+			},
+		},
+		{name: "initial.sql", content: "-- migrate:up\n", wantErr: "version"},
+		{name: "1_initial.sql", content: "create table a (id int);", wantErr: "migrate:up"},
+		{name: "1_initial.sql", content: "-- migrate:down\n-- migrate:up\n", wantErr: "migrate:up"},
+		{name: "1_initial.sql", content: "-- migrate:up transaction:maybe\n", wantErr: "unknown option"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := parseMigration(tt.name, tt.content)
+			if tt.wantErr != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestNew_EmbeddedMigrations(t *testing.T) {
+	t.Parallel()
+
+	files, err := fs.Sub(db.Migrations, "migrations")
+	assert.NoError(t, err)
+	m, err := New(nil, files)
+	assert.NoError(t, err)
+	assert.NotEqual(t, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		assert.NotEqual(t, "", migration.up.sql, migration.Name)
+	}
+}
+
+func TestMigrator(t *testing.T) {
+	ctx := context.Background()
+	pool := testSchema(t)
+
+	m, err := New(pool, fstest.MapFS{
+		"1_a.sql": {Data: []byte("-- migrate:up\ncreate table a (id int);\n-- migrate:down\ndrop table a;\n")},
+		"2_b.sql": {Data: []byte("-- migrate:up transaction:false\ncreate table b (id int);\n-- migrate:down\ndrop table b;\n")},
+	})
+	assert.NoError(t, err)
+
+	states, err := m.Status(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []State{{Version: "1", Name: "1_a.sql"}, {Version: "2", Name: "2_b.sql"}}, states)
+
+	applied, err := m.Up(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(applied))
+	_, err = pool.Exec(ctx, "insert into a (id) values (1); insert into b (id) values (1)")
+	assert.NoError(t, err)
+
+	// Applying them again does nothing.
+	applied, err = m.Up(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(applied))
+
+	rolledBack, err := m.Down(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "2", rolledBack.Version)
+	states, err = m.Status(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []State{{Version: "1", Name: "1_a.sql", Applied: true}, {Version: "2", Name: "2_b.sql"}}, states)
+	pending, err := m.Pending(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(pending))
+	assert.Equal(t, "2", pending[0].Version)
+
+	t.Run("failed migrations are rolled back", func(t *testing.T) {
+		m, err := New(pool, fstest.MapFS{
+			"3_fail.sql": {Data: []byte("-- migrate:up\ncreate table c (id int);\nselect * from missing;\n")},
+		})
+		assert.NoError(t, err)
+		_, err = m.Up(ctx)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "3_fail.sql")
+
+		var exists bool
+		assert.NoError(t, pool.QueryRow(ctx, "select to_regclass('c') is not null").Scan(&exists))
+		assert.False(t, exists)
+	})
+
+	t.Run("applied versions without migration", func(t *testing.T) {
+		_, err := pool.Exec(ctx, "insert into schema_migrations (version) values ('5')")
+		assert.NoError(t, err)
+
+		states, err := m.Status(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, State{Version: "5", Applied: true}, states[len(states)-1])
+
+		_, err = m.Down(ctx)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "no migration found")
+	})
+}
+
+// testSchema returns a connection to an empty schema in the test database, so that the migrations don't
+// interfere with the schema of the application.
+func testSchema(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+	ctx := context.Background()
+
+	schema := "migrate_" + strings.ToLower(testhelper.RandomString(10))
+	setup := testhelper.Database(t)
+	_, err := setup.Exec(ctx, "create schema "+schema)
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		_, _ = setup.Exec(ctx, "drop schema "+schema+" cascade")
+	})
+
+	cfg, err := pgxpool.ParseConfig(os.Getenv("TEST_DATABASE_DSN"))
+	assert.NoError(t, err)
+	cfg.ConnConfig.RuntimeParams["search_path"] = schema
+	pool, err := pgxpool.ConnectConfig(ctx, cfg)
+	assert.NoError(t, err)
+	t.Cleanup(pool.Close)
+	return pool
+}
@@ -0,0 +1,214 @@
+// Package oidc implements the login with an external identity provider via OpenID Connect.
+// It uses the authorization code flow with PKCE, see RFC 7636, and maps the claims of the
+// ID token to the properties of our users.
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+// CallbackPath is the path the identity provider redirects to after the login.
+// The redirect URL of the configuration must point to it.
+const CallbackPath = "/login/oidc/callback"
+
+// ErrLoginFailed is returned if the identity provider rejected the login or returned an invalid response.
+var ErrLoginFailed = errors.New("login with the identity provider failed")
+
+// Config contains the settings for the identity provider.
+type Config struct {
+	// Issuer is the URL of the identity provider, e.g. "https://auth.example.com/realms/main".
+	// The login with OIDC is disabled if it's empty.
+	Issuer       string `toml:"issuer"`
+	ClientID     string `toml:"client-id"`
+	ClientSecret string `toml:"client-secret" secret:"true"`
+
+	// RedirectURL is the public URL of the callback, e.g. "https://mahlzeit.example.com/login/oidc/callback".
+	RedirectURL string `toml:"redirect-url"`
+
+	// Scopes are requested in addition to "openid", "email" and "profile".
+	Scopes []string `toml:"scopes"`
+
+	// ActivationClaims activate users if their ID token contains all of these claims with the given values.
+	// For claims with a list of values, like groups, the value must be part of the list.
+	// Without activation claims, users have to be activated manually.
+	ActivationClaims map[string]string `toml:"activation-claims"`
+
+	// Members of AdminGroup are superusers, everyone else is not. The groups are read from the
+	// claim GroupsClaim. If either is empty, the superuser flag isn't changed by the login.
+	GroupsClaim string `toml:"groups-claim"`
+	AdminGroup  string `toml:"admin-group"`
+}
+
+// Enabled reports whether an identity provider is configured.
+func (c Config) Enabled() bool {
+	return c.Issuer != ""
+}
+
+// Provider performs the login with an identity provider.
+type Provider struct {
+	cfg      Config
+	oauth2   oauth2.Config
+	verifier *oidc.IDTokenVerifier
+}
+
+// NewProvider returns a provider for the given configuration. The endpoints of the identity provider
+// are discovered with its metadata document, so it must be reachable.
+func NewProvider(ctx context.Context, cfg Config) (*Provider, error) {
+	if cfg.ClientID == "" {
+		return nil, fmt.Errorf("no OIDC client ID configured")
+	}
+	if !strings.HasSuffix(cfg.RedirectURL, CallbackPath) {
+		return nil, fmt.Errorf("the OIDC redirect URL %q must end with %q", cfg.RedirectURL, CallbackPath)
+	}
+
+	p, err := oidc.NewProvider(ctx, cfg.Issuer)
+	if err != nil {
+		return nil, fmt.Errorf("discovering OIDC provider %q: %w", cfg.Issuer, err)
+	}
+
+	return &Provider{
+		cfg: cfg,
+		oauth2: oauth2.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			Endpoint:     p.Endpoint(),
+			RedirectURL:  cfg.RedirectURL,
+			Scopes:       append([]string{oidc.ScopeOpenID, "email", "profile"}, cfg.Scopes...),
+		},
+		verifier: p.Verifier(&oidc.Config{ClientID: cfg.ClientID}),
+	}, nil
+}
+
+// AuthRequest contains the random values of a single login. They are kept by the browser until
+// the identity provider redirects back, and are required to finish the login.
+type AuthRequest struct {
+	State    string // protects the callback against CSRF
+	Nonce    string // binds the ID token to this login
+	Verifier string // the PKCE code verifier, only its hash is sent to the identity provider
+}
+
+// NewAuthRequest returns an AuthRequest with new random values.
+func NewAuthRequest() (AuthRequest, error) {
+	var values [3]string
+	for i := range values {
+		b := make([]byte, 32)
+		if _, err := rand.Read(b); err != nil {
+			return AuthRequest{}, fmt.Errorf("generating random value: %w", err)
+		}
+		values[i] = base64.RawURLEncoding.EncodeToString(b)
+	}
+	return AuthRequest{State: values[0], Nonce: values[1], Verifier: values[2]}, nil
+}
+
+// AuthCodeURL returns the URL of the identity provider to which the user is sent for the login.
+func (p *Provider) AuthCodeURL(req AuthRequest) string {
+	challenge := sha256.Sum256([]byte(req.Verifier))
+	return p.oauth2.AuthCodeURL(req.State,
+		oidc.Nonce(req.Nonce),
+		oauth2.SetAuthURLParam("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:])),
+		oauth2.SetAuthURLParam("code_challenge_method", "S256"),
+	)
+}
+
+// Identity is a user as seen by the identity provider.
+type Identity struct {
+	Issuer        string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+
+	// Activated is true if the claims of the configuration for the activation are fulfilled.
+	Activated bool
+
+	// Superuser reports whether the user is member of the admin group. It's nil if no admin group is configured.
+	Superuser *bool
+}
+
+// Exchange finishes the login of req with the authorization code of the callback.
+// It returns [ErrLoginFailed] if the code or the ID token are invalid.
+func (p *Provider) Exchange(ctx context.Context, req AuthRequest, code string) (Identity, error) {
+	token, err := p.oauth2.Exchange(ctx, code, oauth2.SetAuthURLParam("code_verifier", req.Verifier))
+	if err != nil {
+		return Identity{}, fmt.Errorf("%w: exchanging code: %v", ErrLoginFailed, err)
+	}
+
+	raw, ok := token.Extra("id_token").(string)
+	if !ok {
+		return Identity{}, fmt.Errorf("%w: no ID token in the token response", ErrLoginFailed)
+	}
+	idToken, err := p.verifier.Verify(ctx, raw)
+	if err != nil {
+		return Identity{}, fmt.Errorf("%w: verifying ID token: %v", ErrLoginFailed, err)
+	}
+	if idToken.Nonce != req.Nonce {
+		return Identity{}, fmt.Errorf("%w: the nonce of the ID token doesn't match", ErrLoginFailed)
+	}
+
+	var claims map[string]any
+	if err := idToken.Claims(&claims); err != nil {
+		return Identity{}, fmt.Errorf("%w: parsing claims: %v", ErrLoginFailed, err)
+	}
+
+	return p.identity(idToken.Issuer, idToken.Subject, claims), nil
+}
+
+// identity maps the claims of an ID token to an Identity.
+func (p *Provider) identity(issuer, subject string, claims map[string]any) Identity {
+	id := Identity{
+		Issuer:        issuer,
+		Subject:       subject,
+		Email:         stringClaim(claims, "email"),
+		EmailVerified: hasClaim(claims, "email_verified", "true"),
+		Name:          stringClaim(claims, "name"),
+		Activated:     len(p.cfg.ActivationClaims) > 0,
+	}
+	if id.Name == "" {
+		id.Name = stringClaim(claims, "preferred_username")
+	}
+
+	for claim, want := range p.cfg.ActivationClaims {
+		if !hasClaim(claims, claim, want) {
+			id.Activated = false
+		}
+	}
+
+	if p.cfg.GroupsClaim != "" && p.cfg.AdminGroup != "" {
+		superuser := hasClaim(claims, p.cfg.GroupsClaim, p.cfg.AdminGroup)
+		id.Superuser = &superuser
+	}
+
+	return id
+}
+
+func stringClaim(claims map[string]any, name string) string {
+	s, _ := claims[name].(string)
+	return strings.TrimSpace(s)
+}
+
+// hasClaim reports whether the claim has the given value. If the claim is a list, one of its values must match.
+// Values that are no strings are compared in their JSON representation, e.g. "true" for booleans.
+func hasClaim(claims map[string]any, name, want string) bool {
+	switch v := claims[name].(type) {
+	case nil:
+		return false
+	case []any:
+		for _, elem := range v {
+			if fmt.Sprint(elem) == want {
+				return true
+			}
+		}
+		return false
+	default:
+		return fmt.Sprint(v) == want
+	}
+}
@@ -0,0 +1,299 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alecthomas/assert/v2"
+)
+
+// fakeProvider is a minimal identity provider. Its authorization endpoint logs in every user immediately
+// with the configured claims, so tests can follow the redirects without a browser.
+type fakeProvider struct {
+	*httptest.Server
+	key    *rsa.PrivateKey
+	claims map[string]any
+
+	mu    sync.Mutex
+	codes map[string]fakeAuthorization
+}
+
+type fakeAuthorization struct {
+	challenge string
+	nonce     string
+}
+
+func newFakeProvider(t *testing.T, claims map[string]any) *fakeProvider {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	p := &fakeProvider{key: key, claims: claims, codes: map[string]fakeAuthorization{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", p.discovery)
+	mux.HandleFunc("/keys", p.keys)
+	mux.HandleFunc("/authorize", p.authorize)
+	mux.HandleFunc("/token", p.token)
+	p.Server = httptest.NewServer(mux)
+	t.Cleanup(p.Close)
+	return p
+}
+
+func (p *fakeProvider) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"issuer":                                p.URL,
+		"authorization_endpoint":                p.URL + "/authorize",
+		"token_endpoint":                        p.URL + "/token",
+		"jwks_uri":                              p.URL + "/keys",
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+	})
+}
+
+func (p *fakeProvider) keys(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"alg": "RS256",
+			"use": "sig",
+			"kid": "test",
+			"n":   base64.RawURLEncoding.EncodeToString(p.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(p.key.E)).Bytes()),
+		}},
+	})
+}
+
+func (p *fakeProvider) authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" {
+		http.Error(w, "PKCE is required", http.StatusBadRequest)
+		return
+	}
+
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	code := base64.RawURLEncoding.EncodeToString(b)
+	p.mu.Lock()
+	p.codes[code] = fakeAuthorization{challenge: q.Get("code_challenge"), nonce: q.Get("nonce")}
+	p.mu.Unlock()
+
+	http.Redirect(w, r, q.Get("redirect_uri")+"?"+url.Values{"code": {code}, "state": {q.Get("state")}}.Encode(), http.StatusFound)
+}
+
+func (p *fakeProvider) token(w http.ResponseWriter, r *http.Request) {
+	if id, secret, _ := r.BasicAuth(); id != "mahlzeit" || secret != "secret" {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	p.mu.Lock()
+	auth, ok := p.codes[r.PostFormValue("code")]
+	delete(p.codes, r.PostFormValue("code"))
+	p.mu.Unlock()
+
+	challenge := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+	if !ok || auth.challenge != base64.RawURLEncoding.EncodeToString(challenge[:]) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	claims := map[string]any{
+		"iss":   p.URL,
+		"sub":   "user-1",
+		"aud":   "mahlzeit",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"iat":   time.Now().Unix(),
+		"nonce": auth.nonce,
+	}
+	for k, v := range p.claims {
+		claims[k] = v
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token": "access",
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     p.sign(claims),
+	})
+}
+
+// sign returns claims as a JWT signed with RS256.
+func (p *fakeProvider) sign(claims map[string]any) string {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": "test", "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	hash := sha256.Sum256([]byte(signingInput))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, p.key, crypto.SHA256, hash[:])
+	if err != nil {
+		panic(err)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// authorize follows the redirect of the fake provider like a browser and returns the parameters of the callback.
+func authorize(t *testing.T, p *Provider, req AuthRequest) url.Values {
+	t.Helper()
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	res, err := client.Get(p.AuthCodeURL(req))
+	assert.NoError(t, err)
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusFound, res.StatusCode)
+
+	loc, err := url.Parse(res.Header.Get("Location"))
+	assert.NoError(t, err)
+	assert.Equal(t, CallbackPath, loc.Path)
+	return loc.Query()
+}
+
+func newTestProvider(t *testing.T, fake *fakeProvider, cfg Config) *Provider {
+	t.Helper()
+
+	cfg.Issuer = fake.URL
+	cfg.ClientID = "mahlzeit"
+	cfg.ClientSecret = "secret"
+	cfg.RedirectURL = "http://mahlzeit.test" + CallbackPath
+	p, err := NewProvider(context.Background(), cfg)
+	assert.NoError(t, err)
+	return p
+}
+
+func TestProvider_Exchange(t *testing.T) {
+	t.Parallel()
+
+	superuser, regularUser := true, false
+	tests := []struct {
+		name   string
+		cfg    Config
+		claims map[string]any
+		want   Identity
+	}{
+		{
+			name:   "without mapping",
+			claims: map[string]any{"email": "alice@example.com", "email_verified": true, "name": "Alice"},
+			want:   Identity{Subject: "user-1", Email: "alice@example.com", EmailVerified: true, Name: "Alice"},
+		},
+		{
+			name:   "username instead of name",
+			claims: map[string]any{"email": "alice@example.com", "preferred_username": "alice"},
+			want:   Identity{Subject: "user-1", Email: "alice@example.com", Name: "alice"},
+		},
+		{
+			name:   "activation claims fulfilled",
+			cfg:    Config{ActivationClaims: map[string]string{"email_verified": "true", "roles": "cook"}},
+			claims: map[string]any{"email_verified": true, "roles": []string{"guest", "cook"}},
+			want:   Identity{Subject: "user-1", EmailVerified: true, Activated: true},
+		},
+		{
+			name:   "activation claims not fulfilled",
+			cfg:    Config{ActivationClaims: map[string]string{"email_verified": "true", "roles": "cook"}},
+			claims: map[string]any{"email_verified": true, "roles": []string{"guest"}},
+			want:   Identity{Subject: "user-1", EmailVerified: true},
+		},
+		{
+			name:   "member of admin group",
+			cfg:    Config{GroupsClaim: "groups", AdminGroup: "admins"},
+			claims: map[string]any{"groups": []string{"users", "admins"}},
+			want:   Identity{Subject: "user-1", Superuser: &superuser},
+		},
+		{
+			name:   "not member of admin group",
+			cfg:    Config{GroupsClaim: "groups", AdminGroup: "admins"},
+			claims: map[string]any{"groups": []string{"users"}},
+			want:   Identity{Subject: "user-1", Superuser: &regularUser},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			fake := newFakeProvider(t, tt.claims)
+			p := newTestProvider(t, fake, tt.cfg)
+
+			req, err := NewAuthRequest()
+			assert.NoError(t, err)
+			callback := authorize(t, p, req)
+			assert.Equal(t, req.State, callback.Get("state"))
+
+			got, err := p.Exchange(context.Background(), req, callback.Get("code"))
+			assert.NoError(t, err)
+
+			tt.want.Issuer = fake.URL
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestProvider_ExchangeFails(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		modify func(req *AuthRequest, code *string)
+	}{
+		{name: "invalid code", modify: func(_ *AuthRequest, code *string) { *code = "invalid" }},
+		{name: "wrong code verifier", modify: func(req *AuthRequest, _ *string) { req.Verifier = "wrong" }},
+		{name: "wrong nonce", modify: func(req *AuthRequest, _ *string) { req.Nonce = "wrong" }},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			fake := newFakeProvider(t, nil)
+			p := newTestProvider(t, fake, Config{})
+
+			req, err := NewAuthRequest()
+			assert.NoError(t, err)
+			code := authorize(t, p, req).Get("code")
+			tt.modify(&req, &code)
+
+			_, err = p.Exchange(context.Background(), req, code)
+			assert.True(t, errors.Is(err, ErrLoginFailed))
+		})
+	}
+}
+
+func TestNewProvider(t *testing.T) {
+	t.Parallel()
+
+	fake := newFakeProvider(t, nil)
+	_, err := NewProvider(context.Background(), Config{
+		Issuer:      fake.URL,
+		ClientID:    "mahlzeit",
+		RedirectURL: "http://mahlzeit.test/login",
+	})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "must end with")
+
+	_, err = NewProvider(context.Background(), Config{
+		Issuer:      fake.URL + "/other",
+		ClientID:    "mahlzeit",
+		RedirectURL: "http://mahlzeit.test" + CallbackPath,
+	})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "discovering OIDC provider")
+}
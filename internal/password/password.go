@@ -0,0 +1,74 @@
+// Package password hashes passwords with argon2id. Hashes are encoded in the PHC string format,
+// e.g. "$argon2id$v=19$m=65536,t=1,p=4$<salt>$<hash>", so that the parameters can be changed later
+// without invalidating existing hashes.
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// Algorithm is stored next to each hash in the column users.password_hash_algorithm.
+const Algorithm = "argon2id"
+
+// The parameters follow the recommendation of RFC 9106 for memory-constrained environments.
+const (
+	memory     = 64 * 1024
+	iterations = 1
+	threads    = 4
+	saltSize   = 16
+	keyLength  = 32
+)
+
+var ErrInvalidHash = errors.New("invalid password hash")
+
+var encoding = base64.RawStdEncoding
+
+// Hash returns the encoded hash of password with a random salt.
+func Hash(password string) (string, error) {
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("generating salt: %w", err)
+	}
+
+	key := argon2.IDKey([]byte(password), salt, iterations, memory, threads, keyLength)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, memory, iterations, threads, encoding.EncodeToString(salt), encoding.EncodeToString(key)), nil
+}
+
+// Verify reports whether password matches the encoded hash. An error is only returned if the hash is malformed.
+func Verify(password, encoded string) (bool, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[0] != "" || parts[1] != "argon2id" {
+		return false, ErrInvalidHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return false, ErrInvalidHash
+	}
+
+	var m, t uint32
+	var p uint8
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &m, &t, &p); err != nil || m == 0 || t == 0 || p == 0 {
+		return false, ErrInvalidHash
+	}
+
+	salt, err := encoding.DecodeString(parts[4])
+	if err != nil {
+		return false, ErrInvalidHash
+	}
+	key, err := encoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return false, ErrInvalidHash
+	}
+
+	other := argon2.IDKey([]byte(password), salt, t, m, p, uint32(len(key)))
+	return subtle.ConstantTimeCompare(key, other) == 1, nil
+}
@@ -0,0 +1,57 @@
+package password
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+)
+
+func TestHash(t *testing.T) {
+	t.Parallel()
+
+	hash, err := Hash("correct horse battery staple")
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=65536,t=1,p=4$"))
+
+	other, err := Hash("correct horse battery staple")
+	assert.NoError(t, err)
+	assert.NotEqual(t, hash, other, "salts must be random")
+}
+
+func TestVerify(t *testing.T) {
+	t.Parallel()
+
+	hash, err := Hash("correct horse battery staple")
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		password string
+		hash     string
+		want     bool
+		wantErr  bool
+	}{
+		{name: "correct password", password: "correct horse battery staple", hash: hash, want: true},
+		{name: "wrong password", password: "Tr0ub4dor&3", hash: hash},
+		{name: "empty password", password: "", hash: hash},
+		{name: "empty hash", password: "x", hash: "", wantErr: true},
+		{name: "other algorithm", password: "x", hash: strings.Replace(hash, "argon2id", "argon2i", 1), wantErr: true},
+		{name: "invalid parameters", password: "x", hash: strings.Replace(hash, "m=65536", "m=x", 1), wantErr: true},
+		{name: "invalid salt", password: "x", hash: "$argon2id$v=19$m=65536,t=1,p=4$!$AAAA", wantErr: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := Verify(tt.password, tt.hash)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
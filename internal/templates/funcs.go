@@ -1,12 +1,16 @@
 package templates
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"hash/fnv"
 	"html/template"
 	"strings"
+	"time"
 
+	"codeberg.org/mahlzeit/mahlzeit/internal/duration"
+	"codeberg.org/mahlzeit/mahlzeit/internal/i18n"
 	"codeberg.org/mahlzeit/mahlzeit/web/assets/icons"
 	"github.com/google/uuid"
 	"github.com/speps/go-hashids/v2"
@@ -28,6 +32,7 @@ var appFunctions = template.FuncMap{
 
 		return template.HTML(content), nil //nolint:gosec // all icons are predefined by us
 	},
+	"locales": i18n.Supported,
 	"random": func() string {
 		return uuid.New().String()
 	},
@@ -57,3 +62,45 @@ var appFunctions = template.FuncMap{
 		return "id-" + s, nil
 	},
 }
+
+// requestFunctions returns all functions that depend on the current request, like its locale.
+// They are replaced on each rendering, see [Templates.RenderTemplate].
+func requestFunctions(ctx context.Context) template.FuncMap {
+	l := i18n.FromContext(ctx)
+	userName, _ := ctx.Value(userNameKey{}).(string)
+	csrfToken, _ := ctx.Value(csrfTokenKey{}).(string)
+
+	return template.FuncMap{
+		// currentUser returns the name of the logged-in user, or an empty string for visitors.
+		"currentUser":    func() string { return userName },
+		"csrfToken":      func() string { return csrfToken },
+		"t":              l.T,
+		"locale":         l.Locale,
+		"formatNumber":   l.Number,
+		"formatDate":     l.Date,
+		"formatDateTime": l.DateTime,
+		// duration formats a duration, e.g. "1 Std. 30 Min.". The locale of the request can be overridden.
+		"duration": func(d time.Duration, locale ...string) string {
+			if len(locale) == 0 {
+				return duration.Format(d, l.Locale())
+			}
+			return duration.Format(d, locale[0])
+		},
+	}
+}
+
+type userNameKey struct{}
+
+// WithUserName returns a copy of ctx that carries the name of the logged-in user,
+// which is available in the templates as currentUser.
+func WithUserName(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, userNameKey{}, name)
+}
+
+type csrfTokenKey struct{}
+
+// WithCSRFToken returns a copy of ctx that carries the CSRF token of the request,
+// which is available in the templates as csrfToken.
+func WithCSRFToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, csrfTokenKey{}, token)
+}
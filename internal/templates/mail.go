@@ -0,0 +1,42 @@
+package templates
+
+import (
+	"bytes"
+	"context"
+	"path"
+	"strings"
+	"text/template"
+
+	"github.com/Masterminds/sprig/v3"
+)
+
+// RenderMail renders an email by the name, e.g. "verify-email.tmpl". The template must define the
+// templates "subject" and "body". Emails are plain text, so unlike pages, they are rendered with
+// text/template and nothing is escaped. Texts are localized for the [i18n.Localizer] of ctx.
+func (tc *Templates) RenderMail(ctx context.Context, name string, data any) (subject, body string, err error) {
+	done := tc.startRendering(ctx, name)
+	defer func() { done(err) }()
+
+	// Emails are rare compared to pages, so they are always parsed on demand.
+	ts, err := template.New(name).
+		Funcs(sprig.TxtFuncMap()).
+		Funcs(template.FuncMap(appFunctions)).
+		Funcs(template.FuncMap(requestFunctions(ctx))).
+		ParseFS(tc.files, path.Join("mails", name))
+	if err != nil {
+		return "", "", TemplateRenderingError{err: err, TemplateName: name}
+	}
+
+	var buf bytes.Buffer
+	if err := ts.ExecuteTemplate(&buf, "subject", data); err != nil {
+		return "", "", TemplateRenderingError{err: err, TemplateName: name}
+	}
+	// Line breaks in the subject would break the header of the email.
+	subject = strings.Join(strings.Fields(buf.String()), " ")
+
+	buf.Reset()
+	if err := ts.ExecuteTemplate(&buf, "body", data); err != nil {
+		return "", "", TemplateRenderingError{err: err, TemplateName: name}
+	}
+	return subject, strings.TrimSpace(buf.String()) + "\n", nil
+}
@@ -2,13 +2,19 @@ package templates
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"html/template"
 	"io"
-	"path/filepath"
+	"io/fs"
+	"path"
 	"strings"
+	"time"
 
+	"codeberg.org/mahlzeit/mahlzeit/internal/tracing"
 	"github.com/Masterminds/sprig/v3"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type TemplateRenderingError struct {
@@ -25,89 +31,138 @@ func (e TemplateRenderingError) Unwrap() error {
 }
 
 type Templates struct {
-	dir   string
-	cache map[string]*template.Template
+	files    fs.FS
+	assets   Assets
+	cache    map[string]*template.Template
+	observer Observer
 }
 
-func NewTemplates(templateDir string) Templates {
-	return Templates{dir: templateDir}
+// Observer is notified about each rendered page or mail, e.g. to record metrics. If the rendering failed,
+// err is usually a [TemplateRenderingError].
+type Observer func(name string, duration time.Duration, err error)
+
+// Assets returns the URLs of the compiled assets, e.g. of "js/app.js", for the template function "asset".
+type Assets interface {
+	URL(name string) string
+}
+
+// NewTemplates returns the templates of files, which contains the base template and the directories "pages",
+// "partials" and "mails". Without assets, the URLs of assets are their names below "/assets/".
+func NewTemplates(files fs.FS, assets Assets) Templates {
+	return Templates{files: files, assets: assets}
+}
+
+// SetObserver sets the observer of all renderings.
+func (tc *Templates) SetObserver(o Observer) {
+	tc.observer = o
+}
+
+// startRendering starts the span of a rendering. The returned function ends it and passes the rendering to the
+// observer.
+func (tc *Templates) startRendering(ctx context.Context, name string) func(err error) {
+	start := time.Now()
+	_, span := tracing.Start(ctx, "render "+name, trace.WithAttributes(attribute.String("template", name)))
+	return func(err error) {
+		tracing.End(span, err)
+		if tc.observer != nil {
+			tc.observer(name, time.Since(start), err)
+		}
+	}
 }
 
 // RenderPage renders a page by the name. If the internal cache is built, e.g. during startup,
 // it's used. Otherwise, templates are rendered with the given data on demand. Any errors
 // are wrapped inside a [TemplateRenderingError] for further analysis.
-func (tc *Templates) RenderPage(w io.Writer, page string, data any) error {
-	return tc.RenderTemplate(w, page, "base", data)
+func (tc *Templates) RenderPage(ctx context.Context, w io.Writer, page string, data any) error {
+	return tc.RenderTemplate(ctx, w, page, "base", data)
 }
 
 // RenderTemplate is used for rendering specific templates on a given page.
 // If the internal cache is built, e.g. during startup, it's used.
 // Otherwise, templates are rendered with the given data on demand. Any errors
 // are wrapped inside a [TemplateRenderingError] for further analysis.
-func (tc *Templates) RenderTemplate(w io.Writer, page string, template string, data any) error {
+// Texts, numbers and dates are localized for the [i18n.Localizer] of ctx.
+func (tc *Templates) RenderTemplate(ctx context.Context, w io.Writer, page string, template string, data any) (err error) {
+	done := tc.startRendering(ctx, page)
+	defer func() { done(err) }()
+	funcs := requestFunctions(ctx)
+
 	// If we don't have the templates cached, build them on demand.
 	if tc.cache == nil {
-		tmpl, err := buildPageTemplate(tc.dir, page)
+		tmpl, err := tc.buildPageTemplate(page)
 		if err != nil {
 			return TemplateRenderingError{err: err, TemplateName: page}
 		}
 
-		if err := safeRenderTemplate(tmpl, w, data, template); err != nil {
+		if err := safeRenderTemplate(tmpl.Funcs(funcs), w, data, template); err != nil {
 			return TemplateRenderingError{err: err, TemplateName: page}
 		}
 		return nil
 	}
 
 	// If we have a cache, all templates must be rendered in advance, so we can fetch it.
-	ts, ok := tc.cache[page]
+	cached, ok := tc.cache[page]
 	if !ok {
 		return fmt.Errorf("the template %s does not exist", page)
 	}
 
-	if err := safeRenderTemplate(ts, w, data, template); err != nil {
+	// The cached templates are never executed, but cloned instead. This allows replacing the request
+	// functions for each request, which isn't possible anymore after a template was executed.
+	ts, err := cached.Clone()
+	if err != nil {
+		return TemplateRenderingError{err: err, TemplateName: page}
+	}
+
+	if err := safeRenderTemplate(ts.Funcs(funcs), w, data, template); err != nil {
 		return TemplateRenderingError{err: err, TemplateName: page}
 	}
 	return nil
 }
 
+// BuildCache parses all pages in advance. Afterwards, changes of the files are ignored.
 func (tc *Templates) BuildCache() error {
 	cache := map[string]*template.Template{}
 
-	pagesSubDir := filepath.Join(tc.dir, "pages")
-	pages, err := filepath.Glob(filepath.Join(tc.dir, "./**/*.tmpl"))
-	if err != nil {
-		return fmt.Errorf("error during template search: %w", err)
-	}
-
-	for _, page := range pages {
-		// Let's make the relative path the template name.
-		// The [1:] is used to remove the trailing slash.
-		name := strings.TrimPrefix(page, pagesSubDir)[1:]
+	err := fs.WalkDir(tc.files, "pages", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || path.Ext(p) != ".tmpl" {
+			return err
+		}
 
-		rendered, err := buildPageTemplate(tc.dir, name)
+		// The path relative to the pages is the name of the template, e.g. "recipes/edit.tmpl".
+		name := strings.TrimPrefix(p, "pages/")
+		rendered, err := tc.buildPageTemplate(name)
 		if err != nil {
-			return fmt.Errorf("rendering template %s failed: %w", page, err)
+			return fmt.Errorf("rendering template %s failed: %w", p, err)
 		}
 		cache[name] = rendered
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("error during template search: %w", err)
 	}
 
-	// Return the map.
 	tc.cache = cache
 	return nil
 }
 
 // buildPageTemplate renders all necessary files for a single page.
-func buildPageTemplate(templateDir, page string) (*template.Template, error) {
-	files := []string{
-		filepath.Join(templateDir, "base.tmpl"),
-		filepath.Join(templateDir, "partials", "manifest.partial.tmpl"),
-		filepath.Join(templateDir, "pages", page),
+// Besides the page itself, this includes the base template and all partials.
+func (tc *Templates) buildPageTemplate(page string) (*template.Template, error) {
+	partials, err := fs.Glob(tc.files, "partials/*.partial.tmpl")
+	if err != nil {
+		return nil, fmt.Errorf("searching partials failed: %w", err)
 	}
 
+	files := []string{"base.tmpl"}
+	files = append(files, partials...)
+	files = append(files, path.Join("pages", page))
+
 	ts, err := template.New(page).
 		Funcs(sprig.HtmlFuncMap()).
 		Funcs(appFunctions).
-		ParseFiles(files...)
+		Funcs(template.FuncMap{"asset": tc.assetURL}).
+		Funcs(requestFunctions(context.Background())).
+		ParseFS(tc.files, files...)
 	if err != nil {
 		return nil, fmt.Errorf("parsing templates failed: %w", err)
 	}
@@ -115,6 +170,14 @@ func buildPageTemplate(templateDir, page string) (*template.Template, error) {
 	return ts, nil
 }
 
+// assetURL returns the URL of a compiled asset by its name, e.g. "css/app.css".
+func (tc *Templates) assetURL(name string) string {
+	if tc.assets == nil {
+		return "/assets/" + name
+	}
+	return tc.assets.URL(name)
+}
+
 // safeRenderTemplate renders a page with the given data to the passed writer, only if the rendering process
 // is successful. Any runtime errors are wrapped inside a TemplateRenderingError.
 func safeRenderTemplate(t *template.Template, w io.Writer, data any, templateName string) error {
@@ -2,11 +2,19 @@ package templates
 
 import (
 	"bytes"
+	"context"
 	"errors"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"testing"
+	"testing/fstest"
+	"time"
+
+	"codeberg.org/mahlzeit/mahlzeit/internal/i18n"
+	"codeberg.org/mahlzeit/mahlzeit/internal/tracing"
+	"go.opentelemetry.io/otel/codes"
 )
 
 const testBaseTemplate = `{{ define "base" -}}
@@ -19,11 +27,11 @@ const testPageTemplate = `{{ define "main" -}}testPage{{- end }}`
 
 func TestTemplates_Render(t *testing.T) {
 	root := t.TempDir()
-	tmpl := NewTemplates(root)
+	tmpl := NewTemplates(os.DirFS(root), nil)
 
 	t.Run("template rendering without any files", func(t *testing.T) {
 		templateName := "not_existing.tmpl"
-		err := tmpl.RenderPage(io.Discard, templateName, nil)
+		err := tmpl.RenderPage(context.Background(), io.Discard, templateName, nil)
 		if err == nil {
 			t.Fatalf("expected error, got none")
 		}
@@ -53,7 +61,7 @@ func TestTemplates_Render(t *testing.T) {
 	const expectedContent = "testPage"
 	t.Run("test page is rendered", func(t *testing.T) {
 		var b bytes.Buffer
-		err := tmpl.RenderPage(&b, testPageName, nil)
+		err := tmpl.RenderPage(context.Background(), &b, testPageName, nil)
 		if err != nil {
 			t.Fatalf("page rendering failed: %s", err)
 		}
@@ -77,7 +85,7 @@ func TestTemplates_Render(t *testing.T) {
 		}
 
 		var b bytes.Buffer
-		err := tmpl.RenderPage(&b, testPageName, nil)
+		err := tmpl.RenderPage(context.Background(), &b, testPageName, nil)
 		if err != nil {
 			t.Fatalf("page rendering failed: %s", err)
 		}
@@ -87,3 +95,125 @@ func TestTemplates_Render(t *testing.T) {
 		}
 	})
 }
+
+func TestTemplates_RenderLocalized(t *testing.T) {
+	root := t.TempDir()
+	tmpl := NewTemplates(os.DirFS(root), nil)
+
+	if err := os.WriteFile(filepath.Join(root, "base.tmpl"), []byte(testBaseTemplate), 0o600); err != nil {
+		t.Fatalf("writing test base template failed: %v", err)
+	}
+	if err := os.Mkdir(filepath.Join(root, "pages"), 0o775); err != nil {
+		t.Fatalf("creating temporary pages directory failed: %v", err)
+	}
+	const page = `{{ define "main" }}{{ t "common.edit" }} {{ formatNumber 1.5 }}{{ end }}`
+	if err := os.WriteFile(filepath.Join(root, "pages", "test.tmpl"), []byte(page), 0o600); err != nil {
+		t.Fatalf("writing test page failed: %v", err)
+	}
+	if err := tmpl.BuildCache(); err != nil {
+		t.Fatalf("building cache failed: %v", err)
+	}
+
+	// The cached template is rendered multiple times with different locales, to ensure that the
+	// localized functions are replaced for each rendering.
+	for _, tt := range []struct {
+		locale string
+		want   string
+	}{
+		{locale: "de", want: "Bearbeiten 1,5"},
+		{locale: "en", want: "Edit 1.5"},
+		{locale: "de", want: "Bearbeiten 1,5"},
+	} {
+		ctx := i18n.WithLocalizer(context.Background(), i18n.New(tt.locale))
+
+		var b bytes.Buffer
+		if err := tmpl.RenderPage(ctx, &b, "test.tmpl", nil); err != nil {
+			t.Fatalf("page rendering failed: %s", err)
+		}
+		if b.String() != tt.want {
+			t.Fatalf("content differs for locale %s, want: %s, got: %s", tt.locale, tt.want, b.String())
+		}
+	}
+}
+
+func TestTemplates_RenderMail(t *testing.T) {
+	root := t.TempDir()
+	tmpl := NewTemplates(os.DirFS(root), nil)
+
+	if err := os.Mkdir(filepath.Join(root, "mails"), 0o775); err != nil {
+		t.Fatalf("creating temporary mails directory failed: %v", err)
+	}
+	const mail = `{{ define "subject" }}
+  {{ t "common.edit" }}: {{ .Name }}
+{{ end }}
+{{ define "body" }}
+Hello {{ .Name }},
+open {{ .Link }}
+{{ end }}`
+	if err := os.WriteFile(filepath.Join(root, "mails", "test.tmpl"), []byte(mail), 0o600); err != nil {
+		t.Fatalf("writing test mail failed: %v", err)
+	}
+
+	ctx := i18n.WithLocalizer(context.Background(), i18n.New("en"))
+	data := map[string]string{"Name": "Tom & Jerry", "Link": "https://example.com/?a=1&b=2"}
+	subject, body, err := tmpl.RenderMail(ctx, "test.tmpl", data)
+	if err != nil {
+		t.Fatalf("mail rendering failed: %s", err)
+	}
+
+	// Unlike pages, emails are not escaped.
+	if want := "Edit: Tom & Jerry"; subject != want {
+		t.Fatalf("subject differs, want: %q, got: %q", want, subject)
+	}
+	if want := "Hello Tom & Jerry,\nopen https://example.com/?a=1&b=2\n"; body != want {
+		t.Fatalf("body differs, want: %q, got: %q", want, body)
+	}
+}
+
+func TestTemplates_Observer(t *testing.T) {
+	tmpl := NewTemplates(fstest.MapFS{
+		"base.tmpl":       {Data: []byte(testBaseTemplate)},
+		"pages/test.tmpl": {Data: []byte(testPageTemplate)},
+	}, nil)
+	var observed []string
+	tmpl.SetObserver(func(name string, _ time.Duration, err error) {
+		observed = append(observed, fmt.Sprintf("%s: %v", name, err != nil))
+	})
+
+	if err := tmpl.RenderPage(context.Background(), io.Discard, "test.tmpl", nil); err != nil {
+		t.Fatalf("rendering page failed: %v", err)
+	}
+	if err := tmpl.RenderPage(context.Background(), io.Discard, "missing.tmpl", nil); err == nil {
+		t.Fatalf("expected error, got none")
+	}
+
+	want := []string{"test.tmpl: false", "missing.tmpl: true"}
+	if fmt.Sprint(observed) != fmt.Sprint(want) {
+		t.Fatalf("observed renderings differ, got: %v, want: %v", observed, want)
+	}
+}
+
+func TestTemplates_Tracing(t *testing.T) {
+	exporter, restore := tracing.SetupInMemory()
+	defer restore()
+
+	tmpl := NewTemplates(fstest.MapFS{
+		"base.tmpl":       {Data: []byte(testBaseTemplate)},
+		"pages/test.tmpl": {Data: []byte(testPageTemplate)},
+	}, nil)
+	if err := tmpl.RenderPage(context.Background(), io.Discard, "test.tmpl", nil); err != nil {
+		t.Fatalf("rendering page failed: %v", err)
+	}
+	_ = tmpl.RenderPage(context.Background(), io.Discard, "missing.tmpl", nil)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got: %d", len(spans))
+	}
+	if spans[0].Name != "render test.tmpl" || spans[0].Status.Code != codes.Unset {
+		t.Fatalf("unexpected span of the page: %s (%s)", spans[0].Name, spans[0].Status.Code)
+	}
+	if spans[1].Name != "render missing.tmpl" || spans[1].Status.Code != codes.Error {
+		t.Fatalf("unexpected span of the missing page: %s (%s)", spans[1].Name, spans[1].Status.Code)
+	}
+}
@@ -0,0 +1,143 @@
+// Package textdiff computes word-based differences between two texts, e.g. to show
+// the changes between two revisions of a recipe in a human-readable way.
+package textdiff
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Op describes how a segment of text changed.
+type Op string
+
+const (
+	Equal  Op = "equal"
+	Insert Op = "insert"
+	Delete Op = "delete"
+)
+
+// Segment is a part of the text with the same Op.
+type Segment struct {
+	Op   Op
+	Text string
+}
+
+// maxTokens limits the size of the LCS table. Texts that are longer are treated as completely replaced.
+// Recipes usually contain a few hundred words at most, so this limit is not reached in practice.
+const maxTokens = 5000
+
+// Words returns the segments needed to transform a into b. Texts are split into words and whitespace,
+// so the concatenation of all Equal and Delete segments results in a, and the one of all Equal
+// and Insert segments results in b.
+func Words(a, b string) []Segment {
+	if a == b {
+		if a == "" {
+			return nil
+		}
+		return []Segment{{Op: Equal, Text: a}}
+	}
+
+	x, y := tokenize(a), tokenize(b)
+	if len(x) > maxTokens || len(y) > maxTokens {
+		return merge([]Segment{{Op: Delete, Text: a}, {Op: Insert, Text: b}})
+	}
+
+	// lcs[i][j] contains the length of the longest common subsequence of x[i:] and y[j:].
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			switch {
+			case x[i] == y[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var res []Segment
+	i, j := 0, 0
+	for i < len(x) && j < len(y) {
+		switch {
+		case x[i] == y[j]:
+			res = append(res, Segment{Op: Equal, Text: x[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			res = append(res, Segment{Op: Delete, Text: x[i]})
+			i++
+		default:
+			res = append(res, Segment{Op: Insert, Text: y[j]})
+			j++
+		}
+	}
+	for ; i < len(x); i++ {
+		res = append(res, Segment{Op: Delete, Text: x[i]})
+	}
+	for ; j < len(y); j++ {
+		res = append(res, Segment{Op: Insert, Text: y[j]})
+	}
+
+	return merge(res)
+}
+
+// tokenize splits s into words and the whitespace between them.
+func tokenize(s string) []string {
+	var (
+		tokens    []string
+		start     int
+		prevSpace bool
+	)
+	for i, r := range s {
+		space := unicode.IsSpace(r)
+		if i > 0 && space != prevSpace {
+			tokens = append(tokens, s[start:i])
+			start = i
+		}
+		prevSpace = space
+	}
+	if s != "" {
+		tokens = append(tokens, s[start:])
+	}
+	return tokens
+}
+
+// merge combines adjacent segments with the same Op. Whitespace that is surrounded by
+// changes is attached to them, which makes the result easier to read.
+func merge(segments []Segment) []Segment {
+	var res []Segment
+	for i, s := range segments {
+		if s.Text == "" {
+			continue
+		}
+		if s.Op == Equal && strings.TrimSpace(s.Text) == "" && i > 0 && i < len(segments)-1 &&
+			segments[i-1].Op != Equal && segments[i+1].Op != Equal {
+			// The whitespace is part of both texts, so it's both deleted and inserted.
+			res = appendSegment(res, Segment{Op: Delete, Text: s.Text})
+			res = appendSegment(res, Segment{Op: Insert, Text: s.Text})
+			continue
+		}
+		res = appendSegment(res, s)
+	}
+	return res
+}
+
+// appendSegment appends s to segments. If the last segment has the same Op, the text is joined.
+// Deletions are kept in front of insertions, so that "delete, insert, delete" becomes "delete, insert".
+func appendSegment(segments []Segment, s Segment) []Segment {
+	n := len(segments)
+	if n > 0 && segments[n-1].Op == s.Op {
+		segments[n-1].Text += s.Text
+		return segments
+	}
+	if s.Op == Delete && n > 1 && segments[n-1].Op == Insert && segments[n-2].Op == Delete {
+		segments[n-2].Text += s.Text
+		return segments
+	}
+	return append(segments, s)
+}
@@ -0,0 +1,74 @@
+package textdiff
+
+import (
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+)
+
+func TestWords(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		a, b string
+		want []Segment
+	}{
+		{
+			name: "empty texts",
+		},
+		{
+			name: "equal texts",
+			a:    "Zwiebeln schneiden",
+			b:    "Zwiebeln schneiden",
+			want: []Segment{{Op: Equal, Text: "Zwiebeln schneiden"}},
+		},
+		{
+			name: "added text",
+			b:    "Zwiebeln schneiden",
+			want: []Segment{{Op: Insert, Text: "Zwiebeln schneiden"}},
+		},
+		{
+			name: "removed text",
+			a:    "Zwiebeln schneiden",
+			want: []Segment{{Op: Delete, Text: "Zwiebeln schneiden"}},
+		},
+		{
+			name: "changed word",
+			a:    "Zwiebeln fein schneiden",
+			b:    "Zwiebeln grob schneiden",
+			want: []Segment{
+				{Op: Equal, Text: "Zwiebeln "},
+				{Op: Delete, Text: "fein"},
+				{Op: Insert, Text: "grob"},
+				{Op: Equal, Text: " schneiden"},
+			},
+		},
+		{
+			name: "appended words",
+			a:    "Zwiebeln schneiden",
+			b:    "Zwiebeln schneiden und anbraten",
+			want: []Segment{
+				{Op: Equal, Text: "Zwiebeln schneiden"},
+				{Op: Insert, Text: " und anbraten"},
+			},
+		},
+		{
+			name: "multiple changed words are grouped",
+			a:    "Zwiebeln fein schneiden",
+			b:    "Knoblauch grob hacken",
+			want: []Segment{
+				{Op: Delete, Text: "Zwiebeln fein schneiden"},
+				{Op: Insert, Text: "Knoblauch grob hacken"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, Words(tt.a, tt.b))
+		})
+	}
+}
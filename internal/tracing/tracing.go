@@ -0,0 +1,194 @@
+// Package tracing records traces with OpenTelemetry. Each request gets a span, which is named after its route
+// pattern, e.g. "GET /recipes/{id}/", with child spans for the database queries and the rendered templates.
+//
+// The spans are created with the global tracer provider, which doesn't record anything unless it's replaced by
+// [Setup], e.g. to export the spans to a collector with OTLP.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/jackc/pgx/v4"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// instrumentationName is the name of the tracer of all spans.
+const instrumentationName = "codeberg.org/mahlzeit/mahlzeit"
+
+// Config is the configuration of the tracing in the config.toml.
+type Config struct {
+	// Exporter is either "none" (default) or "otlp", which sends the spans to a collector with OTLP over HTTP.
+	Exporter string `toml:"exporter"`
+	// Endpoint is the address of the collector, e.g. "localhost:4318". Without an endpoint, the environment
+	// variables of OpenTelemetry are used, e.g. OTEL_EXPORTER_OTLP_ENDPOINT, and so are their headers.
+	Endpoint string `toml:"endpoint"`
+	// Insecure disables TLS, e.g. for a collector on the same host.
+	Insecure bool `toml:"insecure"`
+	// SampleRatio is the fraction of the traces that are recorded, from 0 to 1. Traces that were started by
+	// another service are only recorded if that service recorded them, too.
+	SampleRatio float64 `toml:"sample-ratio"`
+}
+
+// Enabled returns true if the spans are exported.
+func (c Config) Enabled() bool {
+	return c.Exporter == "otlp"
+}
+
+// Setup replaces the global tracer provider if the tracing is enabled. The returned function flushes the
+// remaining spans and must be called before the process exits. Spans that can't be exported are logged.
+func Setup(ctx context.Context, cfg Config, logger *zap.Logger) (shutdown func(ctx context.Context) error, err error) {
+	if !cfg.Enabled() {
+		return func(context.Context) error { return nil }, nil
+	}
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		logger.Warn("tracing failed", zap.Error(err))
+	}))
+
+	var opts []otlptracehttp.Option
+	if cfg.Endpoint != "" {
+		opts = append(opts, otlptracehttp.WithEndpoint(cfg.Endpoint))
+	}
+	if cfg.Insecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	exporter, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("creating OTLP exporter: %w", err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName("mahlzeit")))
+	if err != nil {
+		return nil, err
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	install(provider)
+	return provider.Shutdown, nil
+}
+
+// SetupInMemory replaces the global tracer provider with one that records all spans in memory, which is intended
+// for tests. The returned function restores the previous provider.
+func SetupInMemory() (*tracetest.InMemoryExporter, func()) {
+	previous := otel.GetTracerProvider()
+	exporter := tracetest.NewInMemoryExporter()
+	install(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	return exporter, func() { otel.SetTracerProvider(previous) }
+}
+
+func install(provider trace.TracerProvider) {
+	otel.SetTracerProvider(provider)
+	// The W3C headers are used by most services, e.g. by reverse proxies.
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+}
+
+// Start starts a span, which is a child of the span of ctx, if any.
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, opts...)
+}
+
+// End ends the span and records the error, if any.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Middleware starts a span for each request, which continues the trace of the caller if it's propagated in the
+// headers. It's named after the route pattern once the request is routed, so it must be added to the middleware
+// stack of the [chi.Mux], after middleware.RequestID.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := Start(ctx, r.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPMethod(r.Method),
+				semconv.HTTPTarget(r.URL.Path),
+				attribute.String("request_id", middleware.GetReqID(r.Context())),
+			),
+		)
+		defer span.End()
+
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r.WithContext(ctx))
+
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			span.SetName(r.Method + " " + rctx.RoutePattern())
+			span.SetAttributes(semconv.HTTPRoute(rctx.RoutePattern()))
+		}
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		span.SetAttributes(semconv.HTTPStatusCode(status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	})
+}
+
+// queryName matches the name of the queries generated by sqlc, e.g. "-- name: GetRecipeByID :one".
+var queryName = regexp.MustCompile(`^-- name: (\w+)`)
+
+// QueryLogger creates a span for each query, which is a child of the span of its context. Since pgx v4 has no
+// hooks for tracing, the spans are created from its log messages, which are written after the query with its
+// duration. The arguments aren't recorded, since they may contain secrets.
+type QueryLogger struct{}
+
+var _ pgx.Logger = QueryLogger{}
+
+func (QueryLogger) Log(ctx context.Context, level pgx.LogLevel, msg string, data map[string]any) {
+	duration, ok := data["time"].(time.Duration)
+	if !ok {
+		// Only the messages of statements have a duration, the others are about connections.
+		return
+	}
+
+	name := msg
+	sql, _ := data["sql"].(string)
+	if m := queryName.FindStringSubmatch(sql); m != nil {
+		name = m[1]
+	}
+	end := time.Now()
+	attrs := []attribute.KeyValue{semconv.DBSystemPostgreSQL}
+	if sql != "" {
+		attrs = append(attrs, semconv.DBStatement(sql))
+	}
+	_, span := Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithTimestamp(end.Add(-duration)),
+		trace.WithAttributes(attrs...),
+	)
+
+	if level <= pgx.LogLevelError {
+		err, _ := data["err"].(error)
+		if err == nil {
+			err = errors.New(msg + " failed")
+		}
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End(trace.WithTimestamp(end))
+}
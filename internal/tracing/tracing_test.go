@@ -0,0 +1,101 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"codeberg.org/mahlzeit/mahlzeit/internal/zaphelper"
+	"github.com/alecthomas/assert/v2"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/jackc/pgx/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// The tests replace the global tracer provider, so they must not run in parallel.
+
+func TestMiddleware(t *testing.T) {
+	exporter, restore := SetupInMemory()
+	defer restore()
+
+	core, logs := observer.New(zap.InfoLevel)
+	r := chi.NewRouter()
+	r.Use(middleware.RequestID, Middleware, zaphelper.InjectLogger(zap.New(core)))
+	r.Get("/recipes/{id}", func(w http.ResponseWriter, r *http.Request) {
+		zaphelper.FromRequest(r).Info("loading recipe")
+		QueryLogger{}.Log(r.Context(), pgx.LogLevelInfo, "Query", map[string]any{
+			"sql":  "-- name: GetRecipeByID :one\nselect * from recipes where id = $1",
+			"args": []any{1},
+			"time": 3 * time.Millisecond,
+		})
+		QueryLogger{}.Log(r.Context(), pgx.LogLevelError, "Exec", map[string]any{
+			"sql":  "delete from recipes",
+			"err":  errors.New("permission denied"),
+			"time": time.Millisecond,
+		})
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/recipes/1", nil)
+	req.Header.Set("traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := exporter.GetSpans()
+	assert.Equal(t, 3, len(spans))
+	query, exec, request := spans[0], spans[1], spans[2]
+
+	assert.Equal(t, "GET /recipes/{id}", request.Name)
+	assert.Equal(t, "0af7651916cd43dd8448eb211c80319c", request.SpanContext.TraceID().String(), "the trace of the caller is continued")
+	assert.Equal(t, codes.Error, request.Status.Code)
+	assert.True(t, hasAttribute(request, attribute.String("http.route", "/recipes/{id}")))
+	assert.True(t, hasAttribute(request, attribute.Int("http.status_code", 500)))
+	assert.NotEqual(t, "", attributeValue(request, "request_id"), "the request ID is recorded")
+
+	assert.Equal(t, "GetRecipeByID", query.Name)
+	assert.Equal(t, request.SpanContext.SpanID(), query.Parent.SpanID())
+	assert.Equal(t, 3*time.Millisecond, query.EndTime.Sub(query.StartTime))
+	assert.Equal(t, "", attributeValue(query, "args"), "the arguments aren't recorded")
+	assert.Equal(t, "Exec", exec.Name)
+	assert.Equal(t, codes.Error, exec.Status.Code)
+	assert.Equal(t, "permission denied", exec.Status.Description)
+
+	entries := logs.FilterMessage("loading recipe").All()
+	assert.Equal(t, 1, len(entries))
+	fields := entries[0].ContextMap()
+	assert.Equal[any](t, request.SpanContext.TraceID().String(), fields["trace_id"])
+	assert.Equal[any](t, request.SpanContext.SpanID().String(), fields["span_id"])
+}
+
+func TestQueryLogger_IgnoresConnectionMessages(t *testing.T) {
+	exporter, restore := SetupInMemory()
+	defer restore()
+
+	QueryLogger{}.Log(context.Background(), pgx.LogLevelInfo, "Dialing PostgreSQL server", map[string]any{"host": "localhost"})
+	assert.Equal(t, 0, len(exporter.GetSpans()))
+}
+
+func hasAttribute(span tracetest.SpanStub, kv attribute.KeyValue) bool {
+	for _, a := range span.Attributes {
+		if a == kv {
+			return true
+		}
+	}
+	return false
+}
+
+func attributeValue(span tracetest.SpanStub, key attribute.Key) string {
+	for _, a := range span.Attributes {
+		if a.Key == key {
+			return a.Value.Emit()
+		}
+	}
+	return ""
+}
@@ -0,0 +1,174 @@
+package zaphelper
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// Config is the configuration of the logging in the config.toml.
+type Config struct {
+	// Format is either "console" (default), which is meant to be read by humans, or "json" for log collectors.
+	Format string `toml:"format"`
+	// Level is the minimum level of the messages: "debug", "info" (default), "warn" or "error".
+	Level string `toml:"level"`
+	// Levels overrides the level of the named loggers and their children, e.g. {http = "warn"} omits the
+	// requests. The loggers are named after the parts of the application: http, health, mail, metrics and tracing.
+	Levels map[string]string `toml:"levels"`
+	// Sampling limits repeated messages to the first 100 per second, and every 100th after that.
+	Sampling bool `toml:"sampling"`
+	// File is the path of the log file, which is rotated once it reaches MaxSize megabytes (default 100).
+	// Without a file, the messages are written to stdout.
+	File    string `toml:"file"`
+	MaxSize int    `toml:"max-size"`
+	// MaxBackups and MaxAge (in days) limit the rotated files that are kept. All are kept if both are 0.
+	MaxBackups int `toml:"max-backups"`
+	MaxAge     int `toml:"max-age"`
+}
+
+// NewLogger returns the logger for the configuration. Its levels can be changed at runtime with the returned
+// [Levels].
+func NewLogger(cfg Config) (*zap.Logger, *Levels, error) {
+	levels, err := NewLevels(cfg.Level, cfg.Levels)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var encoder zapcore.Encoder
+	switch cfg.Format {
+	case "", "console":
+		encoder = zapcore.NewConsoleEncoder(zap.NewDevelopmentEncoderConfig())
+	case "json":
+		encConfig := zap.NewProductionEncoderConfig()
+		encConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+		encoder = zapcore.NewJSONEncoder(encConfig)
+	default:
+		return nil, nil, fmt.Errorf("unknown format %q, expected console or json", cfg.Format)
+	}
+
+	var out zapcore.WriteSyncer = zapcore.Lock(os.Stdout)
+	if cfg.File != "" {
+		// The writer of lumberjack is safe for concurrent use, and writes directly to the file.
+		out = zapcore.AddSync(&lumberjack.Logger{
+			Filename:   cfg.File,
+			MaxSize:    cfg.MaxSize,
+			MaxBackups: cfg.MaxBackups,
+			MaxAge:     cfg.MaxAge,
+		})
+	}
+
+	// The levels are checked by levelCore, since they depend on the name of the logger.
+	var core zapcore.Core = zapcore.NewCore(encoder, out, zapcore.DebugLevel)
+	if cfg.Sampling {
+		core = zapcore.NewSamplerWithOptions(core, time.Second, 100, 100)
+	}
+	logger := zap.New(levelCore{Core: core, levels: levels}, zap.AddCaller(), zap.AddStacktrace(zapcore.ErrorLevel))
+	return logger, levels, nil
+}
+
+// levelCore only logs the messages that are enabled by the levels for the name of their logger.
+type levelCore struct {
+	zapcore.Core
+	levels *Levels
+}
+
+func (c levelCore) Enabled(level zapcore.Level) bool {
+	return c.levels.minLevel() <= level
+}
+
+func (c levelCore) With(fields []zapcore.Field) zapcore.Core {
+	return levelCore{Core: c.Core.With(fields), levels: c.levels}
+}
+
+func (c levelCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if entry.Level < c.levels.Level(entry.LoggerName) {
+		return ce
+	}
+	return c.Core.Check(entry, ce)
+}
+
+// Levels are the levels of a logger and its named loggers, which can be changed at runtime.
+type Levels struct {
+	mu    sync.RWMutex
+	level zapcore.Level
+	named map[string]zapcore.Level
+}
+
+// NewLevels parses the default level and those of the named loggers. An empty level is info.
+func NewLevels(level string, named map[string]string) (*Levels, error) {
+	l := &Levels{named: make(map[string]zapcore.Level, len(named))}
+	if err := l.SetLevel("", level); err != nil {
+		return nil, err
+	}
+	for name, level := range named {
+		if err := l.SetLevel(name, level); err != nil {
+			return nil, fmt.Errorf("logger %s: %w", name, err)
+		}
+	}
+	return l, nil
+}
+
+// Level returns the level of the named logger. The name of a child logger, e.g. "http.api", falls back to the
+// level of its parents, and then to the default level.
+func (l *Levels) Level(name string) zapcore.Level {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	for name != "" {
+		if level, ok := l.named[name]; ok {
+			return level
+		}
+		i := strings.LastIndexByte(name, '.')
+		if i < 0 {
+			break
+		}
+		name = name[:i]
+	}
+	return l.level
+}
+
+// SetLevel changes the level of the named logger, or the default level if the name is empty.
+func (l *Levels) SetLevel(name, level string) error {
+	lvl, err := zapcore.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if name == "" {
+		l.level = lvl
+	} else {
+		l.named[name] = lvl
+	}
+	return nil
+}
+
+// minLevel returns the lowest of all levels, below which nothing is logged.
+func (l *Levels) minLevel() zapcore.Level {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	lowest := l.level
+	for _, level := range l.named {
+		if level < lowest {
+			lowest = level
+		}
+	}
+	return lowest
+}
+
+// All returns the default level and those of the named loggers, e.g. "info" and {"http": "warn"}.
+func (l *Levels) All() (string, map[string]string) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	named := make(map[string]string, len(l.named))
+	for name, level := range l.named {
+		named[name] = level.String()
+	}
+	return l.level.String(), named
+}
@@ -0,0 +1,76 @@
+package zaphelper
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestNewLogger(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "mahlzeit.log")
+	logger, levels, err := NewLogger(Config{
+		Format: "json",
+		Level:  "info",
+		Levels: map[string]string{"http": "warn", "mail": "debug"},
+		File:   path,
+	})
+	assert.NoError(t, err)
+
+	logger.Debug("hidden")
+	logger.Named("http").Info("hidden")
+	logger.Named("http").Named("api").Info("hidden")
+	logger.Named("http").Warn("request failed")
+	logger.Named("mail").Debug("sending mail")
+
+	assert.NoError(t, levels.SetLevel("http.api", "debug"))
+	logger.Named("http").Named("api").Debug("authenticated")
+	logger.Named("http").Info("hidden")
+
+	assert.NoError(t, logger.Sync())
+	content, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	var messages []string
+	for _, line := range strings.Split(strings.TrimSpace(string(content)), "\n") {
+		var entry struct {
+			Logger  string `json:"logger"`
+			Message string `json:"msg"`
+		}
+		assert.NoError(t, json.Unmarshal([]byte(line), &entry))
+		messages = append(messages, entry.Logger+": "+entry.Message)
+	}
+	assert.Equal(t, []string{"http: request failed", "mail: sending mail", "http.api: authenticated"}, messages)
+}
+
+func TestNewLogger_Invalid(t *testing.T) {
+	t.Parallel()
+
+	_, _, err := NewLogger(Config{Format: "logfmt"})
+	assert.EqualError(t, err, `unknown format "logfmt", expected console or json`)
+	_, _, err = NewLogger(Config{Levels: map[string]string{"http": "loud"}})
+	assert.EqualError(t, err, `logger http: unrecognized level: "loud"`)
+}
+
+func TestLevels(t *testing.T) {
+	t.Parallel()
+
+	levels, err := NewLevels("", map[string]string{"http": "warn"})
+	assert.NoError(t, err)
+	assert.Equal(t, zapcore.InfoLevel, levels.Level(""))
+	assert.Equal(t, zapcore.WarnLevel, levels.Level("http.api"), "children fall back to their parent")
+	assert.Equal(t, zapcore.InfoLevel, levels.Level("mail"))
+
+	assert.NoError(t, levels.SetLevel("", "debug"))
+	assert.NoError(t, levels.SetLevel("http.api", "error"))
+	assert.EqualError(t, levels.SetLevel("http", "loud"), `unrecognized level: "loud"`)
+
+	level, named := levels.All()
+	assert.Equal(t, "debug", level)
+	assert.Equal(t, map[string]string{"http": "warn", "http.api": "error"}, named)
+}
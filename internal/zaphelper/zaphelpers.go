@@ -5,10 +5,15 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"net/url"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 var (
@@ -35,11 +40,16 @@ func InjectLogger(logger *zap.Logger) HTTPMiddleware {
 			// Attach several pre-known request fields to the logger.
 			// Do NOT use `logger = logger.With` as this will overwrite the old reference,
 			// causing the fields of the previous request added to the new one.
-			requestLog := logger.With(
+			fields := []zap.Field{
 				zap.String("proto", r.Proto),
 				zap.String("path", r.URL.Path),
 				zap.String("request_id", middleware.GetReqID(r.Context())),
-			)
+			}
+			// The IDs of the trace correlate the log lines with the spans of the request, see tracing.Middleware.
+			if sc := trace.SpanContextFromContext(r.Context()); sc.IsValid() {
+				fields = append(fields, zap.String("trace_id", sc.TraceID().String()), zap.String("span_id", sc.SpanID().String()))
+			}
+			requestLog := logger.With(fields...)
 
 			ctx := context.WithValue(r.Context(), contextKey, requestLog)
 			r = r.WithContext(ctx)
@@ -49,9 +59,15 @@ func InjectLogger(logger *zap.Logger) HTTPMiddleware {
 	}
 }
 
-// RequestLogger logs each HTTP request. It should be added to the middleware stack
-// after InjectLogger.
-func RequestLogger() HTTPMiddleware {
+// RequestObserver is notified about each finished request, e.g. to record metrics.
+type RequestObserver func(r *http.Request, status int, duration time.Duration)
+
+// RequestLogger logs each HTTP request and passes it to the observers. It should be added
+// to the middleware stack after InjectLogger.
+//
+// The query is logged with the values of sensitive parameters redacted, e.g. tokens. The headers are
+// only logged at the debug level, with the values of credentials and sensitive cookies redacted.
+func RequestLogger(observers ...RequestObserver) HTTPMiddleware {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			logger := FromContext(r.Context())
@@ -59,12 +75,24 @@ func RequestLogger() HTTPMiddleware {
 			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
 			t1 := time.Now()
 			defer func() {
-				reqLogger := logger.With(
+				duration := time.Since(t1)
+				for _, observe := range observers {
+					observe(r, ww.Status(), duration)
+				}
+				fields := []zap.Field{
 					zap.String("http_method", r.Method),
-					zap.Duration("duration", time.Since(t1)),
+					zap.Duration("duration", duration),
 					zap.Int("status", ww.Status()),
 					zap.Int("response_bytes", ww.BytesWritten()),
-				)
+				}
+				if r.URL.RawQuery != "" {
+					fields = append(fields, zap.String("query", redactQuery(r.URL.Query())))
+				}
+				// The headers are only logged for debugging, since they are rather verbose.
+				if logger.Check(zap.DebugLevel, "") != nil {
+					fields = append(fields, zap.Object("headers", redactedHeaders(r.Header)))
+				}
+				reqLogger := logger.With(fields...)
 				reqLogger.Info(fmt.Sprintf("%s %s - %d", r.Method, r.URL.Path, ww.Status()))
 			}()
 			next.ServeHTTP(ww, r)
@@ -72,6 +100,67 @@ func RequestLogger() HTTPMiddleware {
 	}
 }
 
+// redacted replaces the values of sensitive query parameters, headers and cookies in the log.
+const redacted = "[REDACTED]"
+
+// sensitiveNames are parts of the names of query parameters, headers and cookies whose values grant access, e.g.
+// the token of /verify-email, the code of the OIDC callback, the Authorization header or the session cookie.
+var sensitiveNames = []string{"auth", "code", "csrf", "key", "password", "secret", "session", "state", "token"}
+
+func isSensitive(name string) bool {
+	name = strings.ToLower(name)
+	for _, s := range sensitiveNames {
+		if strings.Contains(name, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactQuery returns the encoded query with the values of sensitive parameters redacted.
+func redactQuery(query url.Values) string {
+	for name, values := range query {
+		if isSensitive(name) {
+			for i := range values {
+				values[i] = redacted
+			}
+		}
+	}
+	// The brackets of the placeholder are kept readable.
+	return strings.ReplaceAll(query.Encode(), url.QueryEscape(redacted), redacted)
+}
+
+// redactedHeaders logs the headers with the values of sensitive headers and cookies redacted.
+type redactedHeaders http.Header
+
+func (h redactedHeaders) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	names := make([]string, 0, len(h))
+	for name := range h {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		value := strings.Join(h[name], ", ")
+		switch {
+		case name == "Cookie":
+			cookies := (&http.Request{Header: http.Header{"Cookie": h[name]}}).Cookies()
+			parts := make([]string, len(cookies))
+			for i, c := range cookies {
+				if isSensitive(c.Name) {
+					c.Value = redacted
+				}
+				parts[i] = c.Name + "=" + c.Value
+			}
+			value = strings.Join(parts, "; ")
+		case isSensitive(name):
+			value = redacted
+		}
+		enc.AddString(name, value)
+	}
+	return nil
+}
+
 // FromContext extracts a logger from the context, if it's injected there.
 // Otherwise, a no-op logger is returned.
 func FromContext(ctx context.Context) *zap.Logger {
@@ -0,0 +1,51 @@
+package zaphelper
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestRequestLogger_Redacts(t *testing.T) {
+	t.Parallel()
+
+	core, logs := observer.New(zap.DebugLevel)
+	handler := InjectLogger(zap.New(core))(RequestLogger()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+
+	req := httptest.NewRequest(http.MethodGet, "/verify-email?token=s3cr3t&lang=de", nil)
+	req.Header.Set("Authorization", "Bearer s3cr3t")
+	req.Header.Set("X-CSRF-Token", "s3cr3t")
+	req.Header.Set("Cookie", "session=s3cr3t; locale=de")
+	req.Header.Set("User-Agent", "curl/8.0")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	entries := logs.All()
+	assert.Equal(t, 1, len(entries))
+	fields := entries[0].ContextMap()
+	assert.Equal[any](t, "lang=de&token=[REDACTED]", fields["query"])
+	assert.Equal[any](t, map[string]any{
+		"Authorization": "[REDACTED]",
+		"Cookie":        "session=[REDACTED]; locale=de",
+		"User-Agent":    "curl/8.0",
+		"X-Csrf-Token":  "[REDACTED]",
+	}, fields["headers"])
+}
+
+func TestRequestLogger_HeadersOnlyForDebugging(t *testing.T) {
+	t.Parallel()
+
+	core, logs := observer.New(zapcore.InfoLevel)
+	handler := InjectLogger(zap.New(core))(RequestLogger()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/recipes/", nil))
+
+	entries := logs.All()
+	assert.Equal(t, 1, len(entries))
+	fields := entries[0].ContextMap()
+	assert.Equal[any](t, nil, fields["headers"])
+	assert.Equal[any](t, nil, fields["query"])
+}
@@ -0,0 +1,93 @@
+package web
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// Assets serves compiled assets under content-hashed names, e.g. "js/app.3f2a9c1b7e.js" for "js/app.js". Because
+// the name changes with every change of the content, browsers can cache them forever.
+type Assets struct {
+	files  fs.FS
+	hashed map[string]string // hashed names by the original names
+	names  map[string]string // original names by the hashed names
+}
+
+// NewAssets hashes all files. Hidden files, like placeholders of empty directories, are ignored.
+func NewAssets(files fs.FS) (*Assets, error) {
+	a := &Assets{files: files, hashed: map[string]string{}, names: map[string]string{}}
+	err := fs.WalkDir(files, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || strings.HasPrefix(d.Name(), ".") {
+			return err
+		}
+
+		hash, err := hashFile(files, name)
+		if err != nil {
+			return err
+		}
+
+		ext := path.Ext(name)
+		hashed := strings.TrimSuffix(name, ext) + "." + hash + ext
+		a.hashed[name], a.names[hashed] = hashed, name
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("hashing assets: %w", err)
+	}
+	return a, nil
+}
+
+func hashFile(files fs.FS, name string) (string, error) {
+	f, err := files.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("reading %s: %w", name, err)
+	}
+	return hex.EncodeToString(h.Sum(nil))[:10], nil
+}
+
+// Empty reports whether there are no compiled assets at all.
+func (a *Assets) Empty() bool {
+	return len(a.hashed) == 0
+}
+
+// URL returns the URL of the asset with the hashed name. Unknown assets keep their name, so that
+// missing files are visible as failed requests in the browser.
+func (a *Assets) URL(name string) string {
+	if hashed, ok := a.hashed[name]; ok {
+		return "/assets/" + hashed
+	}
+	return "/assets/" + name
+}
+
+// ServeHTTP serves the assets below "/assets/". Assets requested by their hashed name are cached forever,
+// assets requested by their original name must be revalidated.
+func (a *Assets) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/assets/")
+	if original, ok := a.names[name]; ok {
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+		name = original
+	} else if _, ok := a.hashed[name]; ok {
+		w.Header().Set("Cache-Control", "no-cache")
+	} else {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("ETag", `"`+a.hashed[name]+`"`)
+
+	r2 := r.Clone(r.Context())
+	r2.URL.Path = "/" + name
+	http.FileServer(http.FS(a.files)).ServeHTTP(w, r2)
+}
@@ -1,4 +1,50 @@
-import "./htmx.min";
+import htmx from "./htmx.min";
+
+// Lists marked with data-sortable can be reordered by drag and drop. Once an item is dropped, an "end" event is
+// dispatched on the list, so that the new order can be submitted with hx-trigger="end".
+function initSortable(list) {
+    let dragged = null
+
+    list.addEventListener('dragstart', e => {
+        // Sortable lists can be nested, so only items of this list are handled.
+        const item = e.target.closest('[draggable="true"]')
+        if (item && item.parentNode === list) {
+            dragged = item
+            e.dataTransfer.effectAllowed = 'move'
+        }
+    })
+    list.addEventListener('dragover', e => {
+        const target = e.target.closest('[draggable="true"]')
+        if (!dragged || !target || target === dragged || target.parentNode !== list) {
+            return
+        }
+
+        e.preventDefault()
+        const {top, height} = target.getBoundingClientRect()
+        if (e.clientY < top + height / 2) {
+            list.insertBefore(dragged, target)
+        } else {
+            list.insertBefore(dragged, target.nextSibling)
+        }
+    })
+    list.addEventListener('dragend', () => {
+        if (dragged) {
+            dragged = null
+            list.dispatchEvent(new Event('end'))
+        }
+    })
+}
+
+htmx.onLoad(content => content.querySelectorAll('[data-sortable]').forEach(initSortable))
+
+// HTMX doesn't swap responses of errors. Invalid forms are an exception: they are sent back with the status 422 and
+// their error messages, and replace the submitted form like a successful response.
+htmx.on('htmx:beforeSwap', e => {
+    if (e.detail.xhr.status === 422) {
+        e.detail.shouldSwap = true
+        e.detail.isError = false
+    }
+})
 
 if (!window.IS_PRODUCTION) {
     new EventSource(`${window.ESBUILD_HOST}/esbuild`).addEventListener('change', e => {
@@ -0,0 +1,85 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"codeberg.org/mahlzeit/mahlzeit/internal/templates"
+	"github.com/alecthomas/assert/v2"
+)
+
+func TestAssets(t *testing.T) {
+	t.Parallel()
+
+	assets, err := NewAssets(fstest.MapFS{
+		"js/app.js":   {Data: []byte("console.log(1)")},
+		"css/app.css": {Data: []byte("body{}")},
+		".gitkeep":    {},
+	})
+	assert.NoError(t, err)
+	assert.False(t, assets.Empty())
+
+	url := assets.URL("js/app.js")
+	assert.True(t, strings.HasPrefix(url, "/assets/js/app."), url)
+	assert.True(t, strings.HasSuffix(url, ".js"), url)
+	assert.NotEqual(t, "/assets/js/app.js", url)
+	assert.Equal(t, "/assets/missing.js", assets.URL("missing.js"))
+
+	tests := []struct {
+		path      string
+		wantCode  int
+		wantCache string
+		wantBody  string
+	}{
+		{path: url, wantCode: http.StatusOK, wantCache: "public, max-age=31536000, immutable", wantBody: "console.log(1)"},
+		{path: "/assets/js/app.js", wantCode: http.StatusOK, wantCache: "no-cache", wantBody: "console.log(1)"},
+		{path: "/assets/.gitkeep", wantCode: http.StatusNotFound},
+		{path: "/assets/js/", wantCode: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.path, func(t *testing.T) {
+			t.Parallel()
+
+			rec := httptest.NewRecorder()
+			assets.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			assert.Equal(t, tt.wantCode, rec.Code)
+			assert.Equal(t, tt.wantCache, rec.Header().Get("Cache-Control"))
+			if tt.wantBody != "" {
+				assert.Equal(t, tt.wantBody, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestBuildAssets(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	assert.NoError(t, BuildAssets(".", dir))
+
+	assets, err := NewAssets(os.DirFS(dir))
+	assert.NoError(t, err)
+	for _, name := range []string{"js/app.js", "css/app.css"} {
+		assert.NotEqual(t, "/assets/"+name, assets.URL(name))
+	}
+
+	// The live reload of the development server is removed from the production build.
+	js, err := os.ReadFile(filepath.Join(dir, "js", "app.js"))
+	assert.NoError(t, err)
+	assert.NotContains(t, string(js), "ESBUILD_HOST")
+	assert.NotContains(t, string(js), "/esbuild")
+}
+
+func TestTemplates(t *testing.T) {
+	t.Parallel()
+
+	// All embedded pages must be parseable, otherwise the server can't start.
+	tmpl := templates.NewTemplates(Templates(), nil)
+	assert.NoError(t, tmpl.BuildCache())
+}
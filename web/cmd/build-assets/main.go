@@ -0,0 +1,17 @@
+// Command build-assets compiles the assets for the production into the dist directory, from which they are
+// embedded into the binary. It's run with go generate in the web directory.
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"codeberg.org/mahlzeit/mahlzeit/web"
+)
+
+func main() {
+	if err := web.BuildAssets(".", "dist"); err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -2,7 +2,9 @@ package web
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
 	"path/filepath"
 
 	"github.com/evanw/esbuild/pkg/api"
@@ -13,20 +15,37 @@ const (
 	liveReloadPort = 5173 // backwards compatibility with Vite
 )
 
-func ServeAssets(ctx context.Context, baseDir string) error {
-	// HACK (nachtjasmin): This is probably the dirtiest hack to move one directory up, but for now, it works.
-	absDir, _ := filepath.Abs(baseDir + "/../")
-	options := api.BuildOptions{
+// buildOptions returns the options of esbuild for the sources in webDir, which are shared by the development
+// server and the production build.
+func buildOptions(webDir string) (api.BuildOptions, error) {
+	absDir, err := filepath.Abs(webDir)
+	if err != nil {
+		return api.BuildOptions{}, fmt.Errorf("resolving directory: %w", err)
+	}
+
+	return api.BuildOptions{
 		EntryPoints:   []string{"assets/css/app.css", "assets/js/app.js"},
 		Bundle:        true,
 		AbsWorkingDir: absDir,
-		Sourcemap:     api.SourceMapInline,
 		Format:        api.FormatESModule,
 		Outdir:        "assets",
 		Define: map[string]string{
-			"window.IS_PRODUCTION": "false",
-			"window.ESBUILD_HOST":  fmt.Sprintf(`"http://%s:%d"`, liveReloadHost, liveReloadPort),
+			"window.IS_PRODUCTION": "true",
 		},
+	}, nil
+}
+
+// ServeAssets starts the development server of esbuild, which compiles the sources of the assets in webDir on
+// every change and reloads the pages, see [DevAssets].
+func ServeAssets(ctx context.Context, webDir string) error {
+	options, err := buildOptions(webDir)
+	if err != nil {
+		return err
+	}
+	options.Sourcemap = api.SourceMapInline
+	options.Define = map[string]string{
+		"window.IS_PRODUCTION": "false",
+		"window.ESBUILD_HOST":  fmt.Sprintf(`"http://%s:%d"`, liveReloadHost, liveReloadPort),
 	}
 
 	buildCtx, ctxErr := api.Context(options)
@@ -34,7 +53,7 @@ func ServeAssets(ctx context.Context, baseDir string) error {
 		return fmt.Errorf("creating context: %w", ctxErr)
 	}
 
-	err := buildCtx.Watch(api.WatchOptions{})
+	err = buildCtx.Watch(api.WatchOptions{})
 	if err != nil {
 		return fmt.Errorf("starting watch mode: %w", err)
 	}
@@ -56,3 +75,39 @@ func ServeAssets(ctx context.Context, baseDir string) error {
 	}()
 	return nil
 }
+
+// BuildAssets compiles the sources of the assets in webDir once for the production, minified and without live
+// reload. The compiled assets are written to outDir, e.g. "css/app.css", which are served with [Assets].
+func BuildAssets(webDir, outDir string) error {
+	options, err := buildOptions(webDir)
+	if err != nil {
+		return err
+	}
+	if options.Outdir, err = filepath.Abs(outDir); err != nil {
+		return fmt.Errorf("resolving output directory: %w", err)
+	}
+	options.MinifyWhitespace = true
+	options.MinifyIdentifiers = true
+	options.MinifySyntax = true
+	options.Write = true
+	options.LogLevel = api.LogLevelWarning
+
+	result := api.Build(options)
+	if len(result.Errors) > 0 {
+		msgs := api.FormatMessages(result.Errors, api.FormatMessagesOptions{Kind: api.ErrorMessage})
+		return fmt.Errorf("building assets: %w", errors.New(msgs[0]))
+	}
+	return nil
+}
+
+// DevAssets are the URLs of the assets on the development server, see [ServeAssets].
+type DevAssets struct{}
+
+// URL returns the URL of the asset on the development server. Within GitHub Codespaces, the forwarded port is used.
+func (DevAssets) URL(name string) string {
+	host := fmt.Sprintf("%s:%d", liveReloadHost, liveReloadPort)
+	if os.Getenv("CODESPACES") != "" {
+		host = fmt.Sprintf("%s-%d.%s", os.Getenv("CODESPACE_NAME"), liveReloadPort, os.Getenv("GITHUB_CODESPACES_PORT_FORWARDING_DOMAIN"))
+	}
+	return "http://" + host + "/" + name
+}
@@ -0,0 +1,4 @@
+package web
+
+// The compiled assets are embedded, see [CompiledAssets].
+//go:generate go run ./cmd/build-assets
@@ -0,0 +1,37 @@
+// Package web contains the user interface: the templates, the sources of the assets and the compiled assets.
+// The templates and compiled assets are embedded, so that the binary can be deployed on its own.
+package web
+
+import (
+	"embed"
+	"io/fs"
+)
+
+var (
+	//go:embed templates
+	templateFiles embed.FS
+
+	// The compiled assets are not part of the repository, so the directory only contains a placeholder
+	// until they are built into it.
+	//go:embed all:dist
+	distFiles embed.FS
+)
+
+// Templates returns the embedded templates, see [templates.NewTemplates].
+func Templates() fs.FS {
+	return mustSub(templateFiles, "templates")
+}
+
+// CompiledAssets returns the embedded assets, which are compiled from the sources in "assets".
+func CompiledAssets() fs.FS {
+	return mustSub(distFiles, "dist")
+}
+
+// mustSub returns the subdirectory of an embedded file system, which always exists.
+func mustSub(fsys fs.FS, dir string) fs.FS {
+	sub, err := fs.Sub(fsys, dir)
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}